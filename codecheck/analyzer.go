@@ -0,0 +1,762 @@
+// Package codecheck is a static analyzer that looks for runtime bugs and
+// security problems in Go source code.
+//
+// The codecheck command is a thin wrapper around this package; other tools
+// can embed the analyzer directly:
+//
+//	a := codecheck.New(codecheck.Options{})
+//	findings, err := a.AnalyzePackage("./internal/store")
+//	if err != nil {
+//		return err
+//	}
+//	for _, f := range findings {
+//		fmt.Println(f)
+//	}
+package codecheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Version is the analyzer version reported in machine-readable output.
+const Version = "0.1.0"
+
+// Detector is a single analysis rule. Besides the built-in detectors,
+// custom ones can be added with Register. An Analyzer may call Check for
+// several packages at once, so Check must not modify state shared between
+// calls.
+type Detector interface {
+	// Name returns the rule id used in findings.
+	Name() string
+	// Check inspects the code in ctx and returns any findings.
+	Check(ctx *Context) []Finding
+}
+
+// Describer is implemented by detectors that document their rule. The
+// description is a single sentence, used for example as the SARIF rule's
+// short description. The default severity is the one the rule's typical
+// finding carries; detectors may still report individual findings at other
+// severities unless the configuration overrides it.
+type Describer interface {
+	Description() string
+	DefaultSeverity() Severity
+}
+
+// OptIn is implemented by detectors that only run when the configuration
+// enables them, such as audits too broad to run by default.
+type OptIn interface {
+	OptIn() bool
+}
+
+// Documenter is implemented by detectors that document their rule at
+// length, for `codecheck explain` and the help of SARIF rules.
+type Documenter interface {
+	Doc() RuleDoc
+}
+
+// RuleDoc is the longer documentation of a rule.
+type RuleDoc struct {
+	// Rationale explains why the rule's findings matter, in a paragraph or
+	// two of plain text.
+	Rationale string
+	// Example is Go code showing the pattern the rule reports, and Fix the
+	// same code written as the rule recommends.
+	Example, Fix string
+}
+
+// Rule describes a rule an Analyzer can report.
+type Rule struct {
+	ID              string
+	Description     string
+	DefaultSeverity Severity
+	Doc             RuleDoc
+}
+
+// Context is the parsed and type-checked code handed to each detector: a
+// single file, or the files of one package. Detectors must treat it as
+// read-only.
+type Context struct {
+	// Fset holds the positions of Files.
+	Fset *token.FileSet
+	// Files are the syntax trees, parsed with comments.
+	Files []*ast.File
+	// Pkg and Info are the result of type-checking Files. Type errors
+	// don't stop the analysis, so Info may lack entries for code that
+	// doesn't type-check. Info.FileVersions holds the Go version of each
+	// file, from the go directive of its module's go.mod unless a build
+	// constraint raises it; it is empty when neither says.
+	Pkg  *types.Package
+	Info *types.Info
+
+	// sources holds the contents of each file, by file name.
+	sources map[string][]byte
+
+	argFactsOnce sync.Once
+	argFactsMap  map[*types.Var][]argFact
+}
+
+// NewFinding builds a finding spanning node n, with high confidence.
+func (c *Context) NewFinding(rule string, sev Severity, n ast.Node, format string, args ...any) Finding {
+	return Finding{
+		Rule:       rule,
+		Severity:   sev,
+		Confidence: ConfidenceHigh,
+		Position:   c.Fset.Position(n.Pos()),
+		End:        c.Fset.Position(n.End()),
+		Message:    fmt.Sprintf(format, args...),
+	}
+}
+
+// Options configures an Analyzer.
+type Options struct {
+	// IgnoredErrorAllow lists functions whose error results may be
+	// discarded without an ignored-error finding; see IgnoredErrorDetector.
+	IgnoredErrorAllow []string
+	// Config enables, disables and sets the severity of rules. Rules it
+	// doesn't mention keep their defaults.
+	Config *Config
+	// MinConfidence drops findings below the given confidence. Zero
+	// reports every finding.
+	MinConfidence Confidence
+
+	// Dir is the directory package patterns are resolved in; it selects
+	// the module whose packages are loaded. Empty means the current
+	// directory.
+	Dir string
+	// BuildTags are the build tags that select which files are loaded.
+	BuildTags []string
+	// Exclude lists glob patterns of files to leave out of the analysis,
+	// such as "vendor", "*.pb.go" or "internal/**/mock_*.go"; see
+	// excludedName for how they match. Files with a "// Code generated
+	// ... DO NOT EDIT." comment are left out too unless IncludeGenerated
+	// is set. Excluded files of a package still take part in
+	// type-checking it but are parsed only for their declarations, and
+	// nothing in them is reported.
+	Exclude          []string
+	IncludeGenerated bool
+	// IncludeTests also analyzes _test.go files.
+	IncludeTests bool
+
+	// Jobs bounds how many files or packages are analyzed at once. Zero
+	// means runtime.GOMAXPROCS(0).
+	Jobs int
+	// CacheDir, if set, is a directory in which findings are cached by a
+	// hash of the analyzed code, the analyzer version and the rule
+	// configuration, so unchanged files and packages are not analyzed
+	// again. See DefaultCacheDir.
+	CacheDir string
+
+	// Logger, if set, receives debug diagnostics: the files and packages
+	// parsed, cache hits, and how long each detector took.
+	Logger *slog.Logger
+	// Profile, if set, accumulates the time each detector takes.
+	Profile *Profile
+}
+
+// Analyzer runs a set of detectors over Go source files.
+//
+// An Analyzer is immutable once created: it is safe to reuse for any number
+// of analyses and to call from multiple goroutines at once. Each call
+// parses and type-checks its input afresh and shares no state with other
+// calls. Callers must not modify the Options (or the Config it points to)
+// passed to New while the Analyzer is in use.
+type Analyzer struct {
+	opts      Options
+	config    Config
+	detectors []Detector
+	cache     *resultCache
+	salt      string
+	log       *slog.Logger
+
+	mu       sync.Mutex
+	analyzed map[string]bool // see AnalyzedFiles
+}
+
+func builtinDetectors(opts Options) []Detector {
+	var printfFuncs []string
+	if opts.Config != nil {
+		printfFuncs = opts.Config.PrintfFuncs
+	}
+	return []Detector{
+		NilDerefDetector{},
+		SQLInjectionDetector{},
+		DivByZeroDetector{},
+		IndexBoundsDetector{},
+		SliceMutationDuringIterationDetector{},
+		IgnoredErrorDetector{Allow: opts.IgnoredErrorAllow},
+		MaybeUninitializedDetector{},
+		ResourceLeakDetector{},
+		ShadowingDetector{},
+		PrintfDetector{Funcs: printfFuncs},
+		AppendResultDetector{},
+		NonTerminatingLoopDetector{},
+		NilMapWriteDetector{},
+		TypeAssertDetector{},
+		StringConcatLoopDetector{},
+		ErrorAlwaysIgnoredDetector{},
+		DeferInLoopDetector{},
+		ErrorCompareDetector{},
+		LoopVarCaptureDetector{},
+		ContextFirstDetector{},
+		ContextPropagationDetector{},
+		ExposedStateDetector{},
+		LoopVarAddressDetector{},
+		ResliceBoundsDetector{},
+		ErrorWrapDetector{},
+		HardcodedCredentialDetector{},
+	}
+}
+
+// New returns an Analyzer running every built-in and registered detector
+// that opts.Config leaves enabled.
+func New(opts Options) *Analyzer {
+	all := allDetectors(opts)
+	a := &Analyzer{opts: opts, config: resolveConfig(all, opts.Config), log: opts.Logger}
+	if a.log == nil {
+		a.log = slog.New(slog.DiscardHandler)
+	}
+	for _, d := range all {
+		if *a.config.Rules[d.Name()].Enabled {
+			a.detectors = append(a.detectors, d)
+		}
+	}
+	if opts.CacheDir != "" {
+		a.cache = &resultCache{dir: opts.CacheDir}
+		a.salt = a.cacheSalt()
+	}
+	return a
+}
+
+// Config returns the configuration in effect: every known rule, with its
+// enabled flag and severity filled in from the defaults where the
+// configuration file left them unset.
+func (a *Analyzer) Config() Config {
+	return a.config
+}
+
+// Rules returns the rules of the Analyzer's enabled detectors, in the order
+// they run.
+func (a *Analyzer) Rules() []Rule {
+	rules := make([]Rule, len(a.detectors))
+	for i, d := range a.detectors {
+		rules[i] = ruleOf(d)
+	}
+	return rules
+}
+
+// KnownRules returns every rule codecheck can report, enabled or not: those
+// of the built-in and registered detectors, in the order they run, followed
+// by UnusedIgnoreRule.
+func KnownRules() []Rule {
+	var rules []Rule
+	for _, d := range allDetectors(Options{}) {
+		rules = append(rules, ruleOf(d))
+	}
+	return append(rules, unusedIgnoreRule)
+}
+
+func ruleOf(d Detector) Rule {
+	r := Rule{ID: d.Name(), DefaultSeverity: SeverityWarning}
+	if desc, ok := d.(Describer); ok {
+		r.Description = desc.Description()
+		r.DefaultSeverity = desc.DefaultSeverity()
+	}
+	if doc, ok := d.(Documenter); ok {
+		r.Doc = doc.Doc()
+	}
+	return r
+}
+
+// AnalyzeFile parses and type-checks a single Go file and runs every
+// detector over it.
+func (a *Analyzer) AnalyzeFile(path string) ([]Finding, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if a.excluded(path, src) {
+		a.log.Debug("skipped file", "file", path)
+		return nil, nil
+	}
+	a.record(path)
+	goVersion := moduleGoVersion(filepath.Dir(path))
+	var key string
+	if a.cache != nil {
+		key = a.fileCacheKey(path, goVersion, src)
+		if findings, ok := a.cache.get(key); ok {
+			a.log.Debug("cache hit", "file", path)
+			// The entry may have been written by a run that named the file
+			// differently, e.g. relative to another directory.
+			for i := range findings {
+				f := &findings[i]
+				f.Position.Filename, f.End.Filename = path, path
+				for j := range f.Related {
+					f.Related[j].Position.Filename = path
+				}
+				if f.Fix != nil {
+					for j := range f.Fix.Edits {
+						f.Fix.Edits[j].File = path
+					}
+				}
+			}
+			return findings, nil
+		}
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	a.log.Debug("parsed file", "file", path)
+	findings := a.run(newContext(fset, []*ast.File{file}, map[string][]byte{path: src}, packagePath(filepath.Dir(path)), goVersion))
+	a.cache.put(key, findings)
+	return findings, nil
+}
+
+// AnalyzeSource analyzes src as the contents of the Go file filename, such
+// as an editor's unsaved buffer; the file need not exist. filename gives
+// the findings their positions and places the file in the package of its
+// directory, which is loaded with src in place of the file on disk so that
+// imports and the rest of the package resolve; only findings in the file
+// itself are returned. If the directory's package can't be loaded, or
+// build constraints exclude the file from it, src is analyzed on its own
+// as AnalyzeFile would. Results are not cached.
+func (a *Analyzer) AnalyzeSource(filename string, src []byte) ([]Finding, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	cfg := a.packagesConfig(filepath.Dir(abs), packages.NeedName|packages.NeedFiles|packages.NeedSyntax|
+		packages.NeedImports|packages.NeedTypes|packages.NeedTypesInfo|packages.NeedModule|packages.NeedForTest)
+	if a.excluded(filename, src) {
+		a.log.Debug("skipped source", "file", filename)
+		return nil, nil
+	}
+	a.record(filename)
+	cfg.Overlay = map[string][]byte{abs: src}
+	cfg.Tests = cfg.Tests || strings.HasSuffix(abs, "_test.go")
+	pkgs, err := packages.Load(cfg, ".")
+	if err != nil {
+		a.log.Debug("analyzing source on its own", "file", filename, "err", err)
+		pkgs = nil
+	}
+	for _, pkg := range pkgs {
+		if !slices.Contains(pkg.GoFiles, abs) || slices.ContainsFunc(pkg.Errors, func(e packages.Error) bool {
+			return e.Kind != packages.TypeError
+		}) {
+			continue
+		}
+		a.log.Debug("parsed source", "file", filename, "package", pkg.ID)
+		files, sources := a.analyzedFiles(pkg.Fset, pkg.Syntax, cfg.Overlay)
+		ctx := &Context{Fset: pkg.Fset, Files: files, Pkg: pkg.Types, Info: pkg.TypesInfo, sources: sources}
+		var findings []Finding
+		for _, f := range a.run(ctx) {
+			if f.Position.Filename == abs {
+				renameFile(&f, abs, filename)
+				findings = append(findings, f)
+			}
+		}
+		return findings, nil
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	a.log.Debug("parsed source", "file", filename)
+	return a.run(newContext(fset, []*ast.File{file}, map[string][]byte{filename: src}, packagePath(filepath.Dir(abs)), moduleGoVersion(filepath.Dir(abs)))), nil
+}
+
+// renameFile changes the file name from to to in the positions of f.
+func renameFile(f *Finding, from, to string) {
+	rename := func(name *string) {
+		if *name == from {
+			*name = to
+		}
+	}
+	rename(&f.Position.Filename)
+	rename(&f.End.Filename)
+	for i := range f.Related {
+		rename(&f.Related[i].Position.Filename)
+	}
+	if f.Fix != nil {
+		for i := range f.Fix.Edits {
+			rename(&f.Fix.Edits[i].File)
+		}
+	}
+}
+
+// AnalyzeFiles analyzes each file as AnalyzeFile does, up to Options.Jobs
+// at a time, and returns all findings sorted by position. If any file
+// fails, the error for the first such file in paths is returned.
+func (a *Analyzer) AnalyzeFiles(paths ...string) ([]Finding, error) {
+	results := make([][]Finding, len(paths))
+	errs := make([]error, len(paths))
+	a.parallel(len(paths), func(i int) {
+		results[i], errs[i] = a.AnalyzeFile(paths[i])
+	})
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return mergeFindings(results), nil
+}
+
+// AnalyzePackage loads the single package in directory dir with full type
+// information and runs every detector over it. A relative dir is resolved
+// against Options.Dir.
+func (a *Analyzer) AnalyzePackage(dir string) ([]Finding, error) {
+	if !filepath.IsAbs(dir) && a.opts.Dir != "" {
+		dir = filepath.Join(a.opts.Dir, dir)
+	}
+	return a.analyzePackages(dir, ".")
+}
+
+// AnalyzePackages loads the packages matching patterns (as understood by
+// the go command, e.g. "./..." or an import path) with full type
+// information and runs every detector over each of them.
+func (a *Analyzer) AnalyzePackages(patterns ...string) ([]Finding, error) {
+	return a.analyzePackages(a.opts.Dir, patterns...)
+}
+
+// AnalyzedFiles returns the files the Analyzer has analyzed so far, or
+// found cached findings for, sorted: the paths given to AnalyzeFile and
+// AnalyzeSource as given, and the files of packages as the go command
+// names them. Excluded files are left out.
+func (a *Analyzer) AnalyzedFiles() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	files := make([]string, 0, len(a.analyzed))
+	for name := range a.analyzed {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// record adds names to the files AnalyzedFiles returns.
+func (a *Analyzer) record(names ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.analyzed == nil {
+		a.analyzed = map[string]bool{}
+	}
+	for _, name := range names {
+		a.analyzed[name] = true
+	}
+}
+
+func (a *Analyzer) analyzePackages(dir string, patterns ...string) ([]Finding, error) {
+	// With a cache, list the packages and their files first; if every
+	// package has cached findings, nothing needs to be parsed.
+	var keys map[string]string
+	cached := map[string][]Finding{}
+	if a.cache != nil {
+		cfg := a.packagesConfig(dir, packages.NeedName|packages.NeedFiles|packages.NeedImports|
+			packages.NeedDeps|packages.NeedModule|packages.NeedForTest)
+		if pkgs, err := packages.Load(cfg, patterns...); err == nil && len(pkgs) > 0 {
+			pkgs = withoutTestDuplicates(pkgs)
+			keys = a.packageCacheKeys(pkgs)
+			for _, p := range pkgs {
+				if key := keys[p.ID]; key != "" {
+					if fs, ok := a.cache.get(key); ok {
+						a.log.Debug("cache hit", "package", p.ID)
+						cached[p.ID] = fs
+					}
+				}
+			}
+			if len(cached) == len(pkgs) {
+				results := make([][]Finding, 0, len(pkgs))
+				for _, p := range pkgs {
+					results = append(results, cached[p.ID])
+					for _, name := range p.GoFiles {
+						if !a.Excludes(name) {
+							a.record(name)
+						}
+					}
+				}
+				return mergeFindings(results), nil
+			}
+		}
+	}
+
+	cfg := a.packagesConfig(dir, packages.NeedName|packages.NeedFiles|packages.NeedSyntax|
+		packages.NeedImports|packages.NeedTypes|packages.NeedTypesInfo|packages.NeedModule|packages.NeedForTest)
+	start := time.Now()
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	a.log.Debug("loaded packages", "patterns", strings.Join(patterns, " "), "packages", len(pkgs), "duration", time.Since(start))
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("no packages match %s", strings.Join(patterns, " "))
+	}
+	pkgs = withoutTestDuplicates(pkgs)
+	for _, pkg := range pkgs {
+		for _, e := range pkg.Errors {
+			// Type errors are tolerated as for single files; anything
+			// else means the package could not be loaded.
+			if e.Kind != packages.TypeError {
+				return nil, e
+			}
+		}
+	}
+	// The packages share a FileSet, which is safe for concurrent use;
+	// everything else a detector touches belongs to one package.
+	results := make([][]Finding, len(pkgs))
+	a.parallel(len(pkgs), func(i int) {
+		pkg := pkgs[i]
+		if fs, ok := cached[pkg.ID]; ok {
+			for _, name := range pkg.GoFiles {
+				if !a.Excludes(name) {
+					a.record(name)
+				}
+			}
+			results[i] = fs
+			return
+		}
+		files, sources := a.analyzedFiles(pkg.Fset, pkg.Syntax, nil)
+		for _, f := range files {
+			name := pkg.Fset.Position(f.Pos()).Filename
+			a.log.Debug("parsed file", "file", name, "package", pkg.ID)
+			a.record(name)
+		}
+		if len(files) > 0 {
+			ctx := &Context{Fset: pkg.Fset, Files: files, Pkg: pkg.Types, Info: pkg.TypesInfo, sources: sources}
+			results[i] = a.run(ctx)
+		}
+		if key := keys[pkg.ID]; key != "" {
+			a.cache.put(key, results[i])
+		}
+	})
+	return mergeFindings(results), nil
+}
+
+func (a *Analyzer) packagesConfig(dir string, mode packages.LoadMode) *packages.Config {
+	cfg := &packages.Config{Mode: mode, Dir: dir, Tests: a.opts.IncludeTests, ParseFile: a.parseFile}
+	if len(a.opts.BuildTags) > 0 {
+		cfg.BuildFlags = []string{"-tags=" + strings.Join(a.opts.BuildTags, ",")}
+	}
+	return cfg
+}
+
+// parallel calls fn(0), ..., fn(n-1) from a pool of Options.Jobs
+// goroutines and waits for them to finish.
+func (a *Analyzer) parallel(n int, fn func(i int)) {
+	jobs := a.opts.Jobs
+	if jobs <= 0 {
+		jobs = runtime.GOMAXPROCS(0)
+	}
+	jobs = min(jobs, n)
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+	for i := range n {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
+
+// mergeFindings concatenates per-file or per-package results and sorts
+// them, so the order does not depend on which worker finished first.
+func mergeFindings(results [][]Finding) []Finding {
+	var findings []Finding
+	for _, fs := range results {
+		findings = append(findings, fs...)
+	}
+	sortFindings(findings)
+	return findings
+}
+
+// withoutTestDuplicates drops packages whose files are all analyzed again
+// as part of their test variant ("p [p.test]"), and the generated test
+// main packages.
+func withoutTestDuplicates(pkgs []*packages.Package) []*packages.Package {
+	tested := map[string]bool{}
+	for _, p := range pkgs {
+		if p.ForTest != "" && p.PkgPath == p.ForTest {
+			tested[p.PkgPath] = true
+		}
+	}
+	var out []*packages.Package
+	for _, p := range pkgs {
+		switch {
+		case strings.HasSuffix(p.PkgPath, ".test") && p.Name == "main" && p.ForTest == "":
+		case p.ForTest == "" && tested[p.PkgPath]:
+		default:
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// newContext type-checks files on their own, as the package with import
+// path pkgPath and as Go goVersion, such as "go1.21", or the checker's own
+// version if goVersion is empty.
+func newContext(fset *token.FileSet, files []*ast.File, sources map[string][]byte, pkgPath, goVersion string) *Context {
+	info := &types.Info{
+		Types:        map[ast.Expr]types.TypeAndValue{},
+		Defs:         map[*ast.Ident]types.Object{},
+		Uses:         map[*ast.Ident]types.Object{},
+		Implicits:    map[ast.Node]types.Object{},
+		Selections:   map[*ast.SelectorExpr]*types.Selection{},
+		Scopes:       map[ast.Node]*types.Scope{},
+		FileVersions: map[*ast.File]string{},
+	}
+	// Type errors are tolerated: detectors work with whatever information
+	// the checker managed to record.
+	conf := types.Config{
+		Importer:  importer.Default(),
+		Error:     func(error) {},
+		GoVersion: goVersion,
+	}
+	pkg, _ := conf.Check(pkgPath, fset, files, info)
+	return &Context{Fset: fset, Files: files, Pkg: pkg, Info: info, sources: sources}
+}
+
+// moduleGoVersion returns the Go version declared by the go directive of
+// the go.mod in dir or the nearest directory above it, such as "go1.21", or
+// "" if there is none.
+func moduleGoVersion(dir string) string {
+	_, data := moduleFile(dir)
+	for _, line := range strings.Split(string(data), "\n") {
+		if f := strings.Fields(line); len(f) >= 2 && f[0] == "go" {
+			return "go" + f[1]
+		}
+	}
+	return ""
+}
+
+// packagePath returns the import path of the package in dir: the module
+// path of the nearest go.mod followed by dir's path below it, or, outside
+// a module, dir itself.
+func packagePath(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return filepath.ToSlash(dir)
+	}
+	modDir, data := moduleFile(dir)
+	for _, line := range strings.Split(string(data), "\n") {
+		f := strings.Fields(line)
+		if len(f) < 2 || f[0] != "module" {
+			continue
+		}
+		mod := f[1]
+		if m, err := strconv.Unquote(mod); err == nil {
+			mod = m
+		}
+		if rel, err := filepath.Rel(modDir, dir); err == nil {
+			return path.Join(mod, filepath.ToSlash(rel))
+		}
+	}
+	return filepath.ToSlash(dir)
+}
+
+// moduleFile returns the directory and contents of the go.mod in dir or
+// the nearest directory above it, or nil contents if there is none.
+func moduleFile(dir string) (string, []byte) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", nil
+	}
+	for {
+		if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, data
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+func (a *Analyzer) run(ctx *Context) []Finding {
+	var findings []Finding
+	var unit string
+	if len(ctx.Files) == 1 {
+		unit = ctx.Fset.Position(ctx.Files[0].Pos()).Filename
+	} else if ctx.Pkg != nil {
+		unit = ctx.Pkg.Path()
+	}
+	for _, d := range a.detectors {
+		start := time.Now()
+		fs := d.Check(ctx)
+		elapsed := time.Since(start)
+		a.log.Debug("ran detector", "rule", d.Name(), "in", unit, "findings", len(fs), "duration", elapsed)
+		if a.opts.Profile != nil {
+			a.opts.Profile.add(d.Name(), elapsed, len(fs), len(ctx.Files))
+		}
+		if sev, ok := a.severityOverride(d.Name()); ok {
+			for i := range fs {
+				fs[i].Severity = sev
+			}
+		}
+		for i := range fs {
+			if fs[i].Confidence == 0 {
+				fs[i].Confidence = ConfidenceHigh
+			}
+		}
+		findings = append(findings, fs...)
+	}
+	sortFindings(findings)
+	fingerprint(ctx, findings)
+	findings = a.applyIgnores(ctx, findings)
+	// Filtering comes after applying ignore directives so that a
+	// directive for a dropped finding isn't reported as unused.
+	if a.opts.MinConfidence > 0 {
+		kept := findings[:0]
+		for _, f := range findings {
+			if f.Confidence >= a.opts.MinConfidence {
+				kept = append(kept, f)
+			}
+		}
+		findings = kept
+	}
+	sortFindings(findings)
+	return findings
+}
+
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i].Position, findings[j].Position
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		if a.Column != b.Column {
+			return a.Column < b.Column
+		}
+		return findings[i].Rule < findings[j].Rule
+	})
+}
@@ -0,0 +1,209 @@
+package codecheck
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fixtures is the module the analyzer tests analyze. Each directory in it
+// named after a rule is a package of cases for that rule; see
+// TestDetectors.
+const fixtures = "testdata/src"
+
+// newFixtureAnalyzer returns an Analyzer for the fixtures module running
+// only the given rules, or every rule, opt-in ones included, if none are
+// given.
+func newFixtureAnalyzer(t *testing.T, jobs int, rules ...string) *Analyzer {
+	t.Helper()
+	cfg := &Config{}
+	if len(rules) == 0 {
+		rules = ruleIDs()
+	}
+	if err := cfg.SelectRules(rules, nil); err != nil {
+		t.Fatal(err)
+	}
+	return New(Options{Dir: fixtures, Config: cfg, Jobs: jobs})
+}
+
+// TestParallelAnalysis analyzes the whole fixtures module with many
+// workers, as packages and as separate files, and checks that the findings
+// are the same, in the same order, as with one. Run it with -race to check
+// that the workers share nothing unsynchronized.
+func TestParallelAnalysis(t *testing.T) {
+	var files []string
+	err := filepath.WalkDir(fixtures, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && strings.HasSuffix(path, ".go") {
+			files = append(files, path)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name    string
+		analyze func(a *Analyzer) ([]Finding, error)
+	}{
+		{"packages", func(a *Analyzer) ([]Finding, error) { return a.AnalyzePackages("./...") }},
+		{"files", func(a *Analyzer) ([]Finding, error) { return a.AnalyzeFiles(files...) }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			want, err := tc.analyze(newFixtureAnalyzer(t, 1))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(want) == 0 {
+				t.Fatal("no findings in the fixtures")
+			}
+			for range 3 {
+				got, err := tc.analyze(newFixtureAnalyzer(t, 8))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("findings with 8 jobs differ from those with 1:\ngot  %v\nwant %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+// wantComment matches the expectations in fixture files: a comment
+// `// want "regexp" ...` at the end of a line expects one finding on that
+// line for each regexp, which must match its rule and message, as in
+// "index-bounds: index 0 of ...". Findings on lines without one are errors.
+var wantComment = regexp.MustCompile("// want (.*)$")
+
+// checkFixture analyzes the package in the fixtures directory dir, running
+// only the given rules, and checks the findings against the want comments
+// in its files.
+func checkFixture(t *testing.T, dir string, rules ...string) {
+	t.Helper()
+	findings, err := newFixtureAnalyzer(t, 0, rules...).AnalyzePackage(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type line struct {
+		file string
+		n    int
+	}
+	wants := map[line][]*regexp.Regexp{}
+	paths, err := filepath.Glob(filepath.Join(fixtures, dir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, text := range strings.Split(string(data), "\n") {
+			m := wantComment.FindStringSubmatch(strings.TrimSuffix(text, "\r"))
+			if m == nil {
+				continue
+			}
+			for rest := strings.TrimSpace(m[1]); rest != ""; rest = strings.TrimSpace(rest) {
+				q, err := strconv.QuotedPrefix(rest)
+				if err != nil {
+					t.Fatalf("%s:%d: malformed want comment: %v", path, i+1, err)
+				}
+				rest = rest[len(q):]
+				pattern, _ := strconv.Unquote(q)
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					t.Fatalf("%s:%d: %v", path, i+1, err)
+				}
+				l := line{abs, i + 1}
+				wants[l] = append(wants[l], re)
+			}
+		}
+	}
+	for _, f := range findings {
+		l := line{f.Position.Filename, f.Position.Line}
+		text := f.Rule + ": " + f.Message
+		i := 0
+		for i < len(wants[l]) && !wants[l][i].MatchString(text) {
+			i++
+		}
+		if i == len(wants[l]) {
+			t.Errorf("%s: unexpected finding %s", f.Position, text)
+			continue
+		}
+		wants[l] = append(wants[l][:i], wants[l][i+1:]...)
+	}
+	for l, res := range wants {
+		for _, re := range res {
+			t.Errorf("%s:%d: no finding matching %q", l.file, l.n, re)
+		}
+	}
+}
+
+// TestDetectors checks each rule against its directory of cases in the
+// fixtures module, if it has one.
+func TestDetectors(t *testing.T) {
+	for _, d := range allDetectors(Options{}) {
+		dir := filepath.Join(fixtures, d.Name())
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+		t.Run(d.Name(), func(t *testing.T) {
+			t.Parallel()
+			checkFixture(t, d.Name(), d.Name())
+		})
+	}
+}
+
+// TestIgnoreDirectives checks that ignore directives suppress the findings
+// they cover and that those suppressing nothing are reported, and that
+// turning off unused-ignore stops those reports.
+func TestIgnoreDirectives(t *testing.T) {
+	checkFixture(t, "ignore", "ignored-error", UnusedIgnoreRule)
+
+	findings, err := newFixtureAnalyzer(t, 0, "ignored-error").AnalyzePackage("ignore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range findings {
+		if f.Rule == UnusedIgnoreRule {
+			t.Errorf("%s: %s reported with the rule disabled", f.Position, f.Message)
+		}
+	}
+	for _, cfg := range []string{"rules: {unused-ignore: {enabled: false}}", "rules: {unused-ignore: {severity: note}}"} {
+		if _, err := ParseConfig([]byte(cfg)); err != nil {
+			t.Errorf("%s: %v", cfg, err)
+		}
+	}
+	if err := new(Config).SelectRules(nil, []string{UnusedIgnoreRule}); err != nil {
+		t.Errorf("skipping %s: %v", UnusedIgnoreRule, err)
+	}
+}
+
+// TestGeneratedFiles checks that files with the generated-code comment
+// before the package clause are skipped unless IncludeGenerated is set,
+// and that a comment after it doesn't count.
+func TestGeneratedFiles(t *testing.T) {
+	checkFixture(t, "generated", "ignored-error")
+
+	a := newFixtureAnalyzer(t, 0, "ignored-error")
+	a.opts.IncludeGenerated = true
+	findings, err := a.AnalyzePackage("generated")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []string
+	for _, f := range findings {
+		files = append(files, filepath.Base(f.Position.Filename))
+	}
+	if want := []string{"api.pb.go", "late.go"}; !reflect.DeepEqual(files, want) {
+		t.Errorf("with IncludeGenerated, findings in %v, want %v", files, want)
+	}
+}
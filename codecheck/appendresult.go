@@ -0,0 +1,190 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// AppendResultDetector reports appends whose result is lost: an append
+// whose value is discarded as an expression statement, and `y := append(x, v)` (or `y = ...`) where y
+// is never read again but x still is, as if x had grown. append returns
+// the extended slice, possibly in a newly allocated array; x keeps its old
+// length either way. `x = append(x, v)` is the intended form and is not
+// reported, nor is an append into a new slice that is itself used, nor an
+// explicit `_ = append(...)`.
+type AppendResultDetector struct{}
+
+func (AppendResultDetector) Name() string { return "append-result" }
+
+func (AppendResultDetector) Description() string {
+	return "Result of append discarded or stored where it is never used"
+}
+
+func (AppendResultDetector) DefaultSeverity() Severity { return SeverityWarning }
+
+func (AppendResultDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: `append returns the extended slice; the slice passed in keeps its old length whether or not the array was reused. Discarding the result, or storing it in a variable that is never read while reading the original, loses the appended elements.`,
+		Example:   `append(names, name)`,
+		Fix:       `names = append(names, name)`,
+	}
+}
+
+const appendExplanation = "append may reallocate and returns a new slice header; the slice passed to it keeps its old length"
+
+func (d AppendResultDetector) Check(ctx *Context) []Finding {
+	reads := varReads(ctx)
+	// unsure holds variables whose reads can't be ordered by position:
+	// those captured by a function literal or whose address is taken, and
+	// named results, which bare returns read without naming them.
+	unsure := map[*types.Var]bool{}
+	for _, f := range ctx.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncType:
+				if n.Results != nil {
+					for _, field := range n.Results.List {
+						for _, name := range field.Names {
+							if v := identVar(ctx.Info, name); v != nil {
+								unsure[v] = true
+							}
+						}
+					}
+				}
+			case *ast.FuncLit:
+				ast.Inspect(n.Body, func(m ast.Node) bool {
+					if id, ok := m.(*ast.Ident); ok {
+						if v := identVar(ctx.Info, id); v != nil && (v.Pos() < n.Pos() || v.Pos() >= n.End()) {
+							unsure[v] = true
+						}
+					}
+					return true
+				})
+			case *ast.UnaryExpr:
+				if n.Op == token.AND {
+					if v := exprVar(ctx.Info, n.X); v != nil {
+						unsure[v] = true
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	var findings []Finding
+	for _, f := range ctx.Files {
+		inspectStack(f, func(n ast.Node, stack []ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.ExprStmt:
+				if call := appendCall(ctx, n.X); call != nil {
+					findings = append(findings, d.discarded(ctx, call))
+				}
+			case *ast.AssignStmt:
+				if len(n.Lhs) != len(n.Rhs) {
+					return true
+				}
+				for i, rhs := range n.Rhs {
+					call := appendCall(ctx, rhs)
+					if call == nil || len(call.Args) == 0 {
+						continue
+					}
+					id, ok := ast.Unparen(n.Lhs[i]).(*ast.Ident)
+					if !ok {
+						continue
+					}
+					if id.Name == "_" {
+						// An explicit discard is deliberate.
+						continue
+					}
+					y, x := identVar(ctx.Info, id), exprVar(ctx.Info, call.Args[0])
+					if y == nil || x == nil || x == y || unsure[x] || unsure[y] || y.Parent() == ctx.Pkg.Scope() {
+						continue
+					}
+					if f := d.checkLost(ctx, n, call, id, y, x, reads, stack); f != nil {
+						findings = append(findings, *f)
+					}
+				}
+			}
+			return true
+		})
+	}
+	return findings
+}
+
+// appendCall returns e as a call of the append builtin, if it is one.
+func appendCall(ctx *Context, e ast.Expr) *ast.CallExpr {
+	if call, ok := ast.Unparen(e).(*ast.CallExpr); ok && isBuiltin(ctx.Info, call, "append") {
+		return call
+	}
+	return nil
+}
+
+func (d AppendResultDetector) discarded(ctx *Context, call *ast.CallExpr) Finding {
+	f := ctx.NewFinding(d.Name(), SeverityWarning, call,
+		"result of `%s` is discarded, so the append has no effect; %s", ctx.sourceText(call), appendExplanation)
+	if len(call.Args) > 0 && addressable(call.Args[0]) {
+		s := ctx.sourceText(call.Args[0])
+		f.Suggestion = "assign the result back: `" + s + " = append(" + s + ", ...)`"
+	}
+	return f
+}
+
+// addressable reports whether e is a variable, field or element the
+// result of an append to it can be assigned back to.
+func addressable(e ast.Expr) bool {
+	switch ast.Unparen(e).(type) {
+	case *ast.Ident, *ast.SelectorExpr, *ast.IndexExpr:
+		return true
+	}
+	return false
+}
+
+// checkLost reports the append call in as, whose result is stored in y
+// declared or assigned by id, if y is never read afterwards but x, the
+// slice appended to, is.
+func (d AppendResultDetector) checkLost(ctx *Context, as *ast.AssignStmt, call *ast.CallExpr, id *ast.Ident, y, x *types.Var, reads map[*types.Var][]token.Pos, stack []ast.Node) *Finding {
+	// The reads that count are those in the rest of the enclosing function,
+	// or anywhere in an enclosing loop, which runs the rest of its body
+	// before coming back to the assignment.
+	from, to := as.End(), token.NoPos
+	for i := len(stack) - 1; i >= 0 && to == token.NoPos; i-- {
+		switch n := stack[i].(type) {
+		case *ast.ForStmt, *ast.RangeStmt:
+			from = min(from, n.Pos())
+		case *ast.FuncDecl:
+			to = n.End()
+		case *ast.FuncLit:
+			to = n.End()
+		}
+	}
+	if to == token.NoPos {
+		return nil
+	}
+	after := func(v *types.Var) token.Pos {
+		first := token.NoPos
+		for _, p := range reads[v] {
+			if p >= from && p < to && (p < as.Pos() || p >= as.End()) && (!first.IsValid() || p < first) {
+				first = p
+			}
+		}
+		return first
+	}
+	if after(y).IsValid() {
+		return nil
+	}
+	used := after(x)
+	if !used.IsValid() {
+		return nil
+	}
+	xs := ctx.sourceText(call.Args[0])
+	f := ctx.NewFinding(d.Name(), SeverityWarning, call,
+		"result of append to `%s` is stored in `%s`, which is never used, while `%s` is used again at line %d as if it had grown; %s",
+		xs, id.Name, xs, ctx.Fset.Position(used).Line, appendExplanation)
+	f.Related = append(f.Related, Related{Position: ctx.Fset.Position(used), Message: "`" + xs + "` is used here without the appended elements"})
+	// y may still be meant as a copy; the uses of x may not need the
+	// appended elements.
+	f.Confidence = ConfidenceMedium
+	f.Suggestion = "assign the result back: `" + xs + " = append(" + xs + ", ...)`"
+	return &f
+}
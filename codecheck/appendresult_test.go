@@ -0,0 +1,38 @@
+package codecheck
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestAppendResultDiscarded checks discarded appends, which don't compile
+// and so can't be fixtures, and that an assignment back is only suggested
+// for an operand that can be assigned to.
+func TestAppendResultDiscarded(t *testing.T) {
+	src := []byte(`package fixtures
+
+func discarded(names []string, b *buffer) {
+	append(names, "x")
+	append(b.wbuf[:0], 'x')
+}
+`)
+	findings, err := newFixtureAnalyzer(t, 0, "append-result").AnalyzeSource(filepath.Join(fixtures, "append-result", "discarded.go"), src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		line       int
+		suggestion string
+	}{
+		{4, "assign the result back: `names = append(names, ...)`"},
+		{5, ""},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("got %d findings, want %d: %v", len(findings), len(want), findings)
+	}
+	for i, f := range findings {
+		if f.Position.Line != want[i].line || f.Suggestion != want[i].suggestion {
+			t.Errorf("finding %d at line %d suggests %q, want line %d and %q", i, f.Position.Line, f.Suggestion, want[i].line, want[i].suggestion)
+		}
+	}
+}
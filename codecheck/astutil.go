@@ -0,0 +1,144 @@
+package codecheck
+
+import (
+	"bytes"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"go/types"
+)
+
+// identVar returns the variable id declares or refers to, if any.
+func identVar(info *types.Info, id *ast.Ident) *types.Var {
+	obj := info.Defs[id]
+	if obj == nil {
+		obj = info.Uses[id]
+	}
+	v, _ := obj.(*types.Var)
+	return v
+}
+
+// exprVar returns the variable denoted by e when e is a plain identifier.
+func exprVar(info *types.Info, e ast.Expr) *types.Var {
+	id, ok := ast.Unparen(e).(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	return identVar(info, id)
+}
+
+func isNilExpr(info *types.Info, e ast.Expr) bool {
+	id, ok := ast.Unparen(e).(*ast.Ident)
+	if !ok {
+		return false
+	}
+	_, isNil := info.Uses[id].(*types.Nil)
+	return isNil
+}
+
+// calleeFunc returns the function or method called by call, if it is
+// statically known.
+func calleeFunc(info *types.Info, call *ast.CallExpr) *types.Func {
+	var id *ast.Ident
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		id = fun
+	case *ast.SelectorExpr:
+		id = fun.Sel
+	default:
+		return nil
+	}
+	fn, _ := info.Uses[id].(*types.Func)
+	return fn
+}
+
+// isNoReturn reports whether call never returns normally.
+func isNoReturn(info *types.Info, call *ast.CallExpr) bool {
+	if id, ok := ast.Unparen(call.Fun).(*ast.Ident); ok {
+		if b, ok := info.Uses[id].(*types.Builtin); ok && b.Name() == "panic" {
+			return true
+		}
+	}
+	fn := calleeFunc(info, call)
+	if fn == nil || fn.Pkg() == nil {
+		return false
+	}
+	switch fn.Pkg().Path() + "." + fn.Name() {
+	case "os.Exit", "log.Fatal", "log.Fatalf", "log.Fatalln", "log.Panic", "log.Panicf", "log.Panicln":
+		return true
+	}
+	return false
+}
+
+// nodeString formats n as Go source on a single line.
+func nodeString(n ast.Node) string {
+	var buf bytes.Buffer
+	printer.Fprint(&buf, token.NewFileSet(), n)
+	return string(bytes.Join(bytes.Fields(buf.Bytes()), []byte(" ")))
+}
+
+// typeString formats t for a finding message, qualifying types from other
+// packages by package name as Go source would.
+func typeString(ctx *Context, t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == ctx.Pkg {
+			return ""
+		}
+		return p.Name()
+	})
+}
+
+// varReads returns where each variable in ctx is read, in no particular
+// order. Plain assignments to a variable don't count as reads.
+func varReads(ctx *Context) map[*types.Var][]token.Pos {
+	writes := map[*ast.Ident]bool{}
+	for _, f := range ctx.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			if as, ok := n.(*ast.AssignStmt); ok && as.Tok == token.ASSIGN {
+				for _, lhs := range as.Lhs {
+					if id, ok := ast.Unparen(lhs).(*ast.Ident); ok {
+						writes[id] = true
+					}
+				}
+			}
+			return true
+		})
+	}
+	reads := map[*types.Var][]token.Pos{}
+	for id, obj := range ctx.Info.Uses {
+		if v, ok := obj.(*types.Var); ok && !writes[id] {
+			reads[v] = append(reads[v], id.Pos())
+		}
+	}
+	return reads
+}
+
+// forEachFunc calls fn with the body of every function declaration and
+// function literal in ctx.
+func forEachFunc(ctx *Context, fn func(body *ast.BlockStmt)) {
+	for _, f := range ctx.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				if n.Body != nil {
+					fn(n.Body)
+				}
+			case *ast.FuncLit:
+				fn(n.Body)
+			}
+			return true
+		})
+	}
+}
+
+// forEachFuncDecl calls fn with the body of every top-level function in
+// ctx; function literals are visited as part of the enclosing body.
+func forEachFuncDecl(ctx *Context, fn func(body *ast.BlockStmt)) {
+	for _, f := range ctx.Files {
+		for _, d := range f.Decls {
+			if fd, ok := d.(*ast.FuncDecl); ok && fd.Body != nil {
+				fn(fd.Body)
+			}
+		}
+	}
+}
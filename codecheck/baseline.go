@@ -0,0 +1,54 @@
+package codecheck
+
+import (
+	"fmt"
+	"os"
+)
+
+// Baseline is a set of previously recorded findings, identified by
+// fingerprint, that should no longer be reported. A baseline file is a JSON
+// report as written by WriteJSON.
+type Baseline map[string]bool
+
+// LoadBaseline reads a baseline file.
+func LoadBaseline(path string) (Baseline, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	findings, err := ReadJSON(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading baseline %s: %v", path, err)
+	}
+	b := Baseline{}
+	for _, f := range findings {
+		b[f.Fingerprint] = true
+	}
+	return b, nil
+}
+
+// WriteBaseline records findings as a baseline file at path.
+func WriteBaseline(path string, findings []Finding) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteJSON(f, findings); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Filter returns the findings not recorded in b. Since fingerprints don't
+// depend on line numbers, findings whose code merely moved stay suppressed.
+func (b Baseline) Filter(findings []Finding) []Finding {
+	var out []Finding
+	for _, f := range findings {
+		if !b[f.Fingerprint] {
+			out = append(out, f)
+		}
+	}
+	return out
+}
@@ -0,0 +1,79 @@
+package codecheck
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestBaselineFilter records a baseline, then moves the baselined code and
+// adds the same mistake twice more: once next to it in the same function
+// and once in a new function. The moved finding must stay suppressed and
+// both new ones, though their messages match it, must be reported.
+func TestBaselineFilter(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.go")
+	baseline := filepath.Join(dir, "baseline.json")
+	a := newFixtureAnalyzer(t, 0, "ignored-error")
+	analyze := func(src string) []Finding {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(src), 0o666); err != nil {
+			t.Fatal(err)
+		}
+		findings, err := a.AnalyzeFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return findings
+	}
+
+	old := analyze(`package a
+
+import "os"
+
+func cleanup() {
+	_ = os.Remove("a")
+}
+`)
+	if len(old) != 1 {
+		t.Fatalf("got %d findings before, want 1", len(old))
+	}
+	if err := WriteBaseline(baseline, old); err != nil {
+		t.Fatal(err)
+	}
+	b, err := LoadBaseline(baseline)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	findings := analyze(`package a
+
+import "os"
+
+// cleanup removes what the tests leave behind.
+func cleanup() {
+
+	_ = os.Remove("a")
+	_ = os.Remove("a")
+}
+
+func reset() {
+	_ = os.Remove("a")
+}
+`)
+	if len(findings) != 3 {
+		t.Fatalf("got %d findings after, want 3", len(findings))
+	}
+	for _, f := range findings {
+		if f.Message != old[0].Message {
+			t.Fatalf("got message %q, want %q", f.Message, old[0].Message)
+		}
+	}
+	got := b.Filter(findings)
+	if len(got) != 2 {
+		t.Fatalf("got %d findings past the baseline, want 2: %v", len(got), got)
+	}
+	if got[0].Position.Line != 9 || got[1].Position.Line != 13 {
+		t.Errorf("got findings at lines %d and %d, want 9 and 13", got[0].Position.Line, got[1].Position.Line)
+	}
+}
@@ -0,0 +1,192 @@
+package codecheck
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// DefaultCacheDir returns the directory the command caches results in:
+// codecheck under the user's cache directory (os.UserCacheDir).
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "codecheck"), nil
+}
+
+// ClearCache removes every cached result under dir.
+func ClearCache(dir string) error {
+	return os.RemoveAll(dir)
+}
+
+// resultCache stores the findings of earlier runs on disk, one file per
+// analyzed file or package, named by a hash of everything the findings
+// depend on.
+type resultCache struct {
+	dir string
+}
+
+func (c *resultCache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+func (c *resultCache) get(key string) ([]Finding, bool) {
+	if c == nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var findings []Finding
+	if err := json.Unmarshal(data, &findings); err != nil {
+		return nil, false
+	}
+	return findings, true
+}
+
+// put records findings under key. Failures are ignored: the cache only
+// saves time.
+func (c *resultCache) put(key string, findings []Finding) {
+	if c == nil {
+		return
+	}
+	data, err := json.Marshal(findings)
+	if err != nil {
+		return
+	}
+	p := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return
+	}
+	// Write to a temporary file first so that concurrent runs never see a
+	// partial entry.
+	tmp, err := os.CreateTemp(filepath.Dir(p), key+".*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), p)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// cacheSalt identifies everything besides the analyzed code that affects
+// findings: the analyzer version, the rules enabled and their severities,
+// and the options detectors and package loading depend on. Changing any of
+// them makes every earlier cache entry unreachable.
+func (a *Analyzer) cacheSalt() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "codecheck %s %s\n", Version, runtime.Version())
+	var ids []string
+	for id := range a.config.Rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		rc := a.config.Rules[id]
+		sev, overridden := a.severityOverride(id)
+		fmt.Fprintf(&b, "rule %s enabled=%t severity=%s override=%t/%s\n", id, *rc.Enabled, rc.Severity, overridden, sev)
+	}
+	var printfFuncs []string
+	if a.opts.Config != nil {
+		printfFuncs = a.opts.Config.PrintfFuncs
+	}
+	fmt.Fprintf(&b, "allow %q\nprintf %q\nconfidence %d\ntags %q\ntests %t\n", a.opts.IgnoredErrorAllow, printfFuncs, a.opts.MinConfidence, a.opts.BuildTags, a.opts.IncludeTests)
+	fmt.Fprintf(&b, "exclude %q\ngenerated %t\n", a.opts.Exclude, a.opts.IncludeGenerated)
+	return b.String()
+}
+
+func (a *Analyzer) cacheKey(kind, name string, content []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s%s %s\n", a.salt, kind, name)
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// fileCacheKey is the cache key of a file analyzed on its own as Go
+// goVersion.
+func (a *Analyzer) fileCacheKey(path, goVersion string, src []byte) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return a.cacheKey("file", path+" "+goVersion, src)
+}
+
+// packageCacheKeys returns the cache key of each package in pkgs, which
+// must have been loaded with NeedFiles, NeedImports, NeedDeps and
+// NeedModule. A key covers the contents of the package's files and,
+// transitively, of the packages it imports; imports from the standard
+// library and from versioned module dependencies are identified by the Go
+// and module versions instead. Packages whose files cannot be read get no
+// key.
+func (a *Analyzer) packageCacheKeys(pkgs []*packages.Package) map[string]string {
+	hashes := map[string]string{}
+	var hash func(p *packages.Package) (string, bool)
+	hash = func(p *packages.Package) (string, bool) {
+		if h, ok := hashes[p.ID]; ok {
+			return h, h != ""
+		}
+		hashes[p.ID] = "" // breaks import cycles, which are errors anyway
+		h := sha256.New()
+		fmt.Fprintf(h, "package %s\n", p.ID)
+		switch {
+		case p.Module == nil && !strings.Contains(strings.Split(p.PkgPath, "/")[0], "."):
+			// The standard library changes only with the Go version,
+			// which is part of the salt.
+		case p.Module != nil && p.Module.Version != "" && p.Module.Replace == nil:
+			fmt.Fprintf(h, "module %s@%s\n", p.Module.Path, p.Module.Version)
+		default:
+			if p.Module != nil {
+				// The go directive decides the language version.
+				fmt.Fprintf(h, "go %s\n", p.Module.GoVersion)
+			}
+			for _, name := range p.GoFiles {
+				src, err := os.ReadFile(name)
+				if err != nil {
+					return "", false
+				}
+				fmt.Fprintf(h, "file %s %d\n", name, len(src))
+				h.Write(src)
+			}
+		}
+		var paths []string
+		for path := range p.Imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			ih, ok := hash(p.Imports[path])
+			if !ok {
+				return "", false
+			}
+			fmt.Fprintf(h, "import %s %s\n", path, ih)
+		}
+		sum := hex.EncodeToString(h.Sum(nil))
+		hashes[p.ID] = sum
+		return sum, true
+	}
+	keys := map[string]string{}
+	for _, p := range pkgs {
+		if h, ok := hash(p); ok {
+			keys[p.ID] = a.cacheKey("package", p.ID, []byte(h))
+		}
+	}
+	return keys
+}
@@ -0,0 +1,97 @@
+package codecheck
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCacheInvalidation analyzes a package with a cache, then changes in
+// turn the configuration, the rules, the build tags and a file, and checks
+// that each change gives the findings of a fresh analysis rather than the
+// cached ones. An unchanged run in between must hit the cache, so that the
+// others are known to have had one to miss.
+func TestCacheInvalidation(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, src string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.mod", "module example.com/m\n\ngo 1.21\n")
+	write("a.go", "package m\n\nimport \"os\"\n\nfunc a() {\n\t_ = os.Remove(\"a\")\n}\n")
+	write("tagged.go", "//go:build extra\n\npackage m\n\nimport \"os\"\n\nfunc tagged() {\n\t_ = os.Remove(\"b\")\n}\n")
+	cacheDir := t.TempDir()
+
+	config := func(opts ...func(*Config)) *Config {
+		cfg := &Config{}
+		if err := cfg.SelectRules([]string{"ignored-error"}, nil); err != nil {
+			t.Fatal(err)
+		}
+		for _, o := range opts {
+			o(cfg)
+		}
+		return cfg
+	}
+	asError := func(cfg *Config) {
+		sev := SeverityError
+		rc := cfg.Rules["ignored-error"]
+		rc.Severity = &sev
+		cfg.Rules["ignored-error"] = rc
+	}
+	otherRule := func(cfg *Config) {
+		if err := cfg.SelectRules([]string{"nil-deref"}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		opts    Options
+		change  func()
+		want    []string // the line and severity of each finding
+		wantHit bool
+	}{
+		{name: "first", opts: Options{Config: config()}, want: []string{"6 warning"}},
+		{name: "unchanged", opts: Options{Config: config()}, want: []string{"6 warning"}, wantHit: true},
+		{name: "config", opts: Options{Config: config(asError)}, want: []string{"6 error"}},
+		{name: "rules", opts: Options{Config: config(otherRule)}, want: nil},
+		{name: "tags", opts: Options{Config: config(), BuildTags: []string{"extra"}}, want: []string{"6 warning", "8 warning"}},
+		{
+			name:   "contents",
+			opts:   Options{Config: config()},
+			change: func() { write("a.go", "package m\n\nimport \"os\"\n\nfunc a() {\n\t_ = os.Remove(\"a\")\n\t_ = os.Remove(\"c\")\n}\n") },
+			want:   []string{"6 warning", "7 warning"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.change != nil {
+				tt.change()
+			}
+			var log bytes.Buffer
+			tt.opts.Dir = dir
+			tt.opts.CacheDir = cacheDir
+			tt.opts.Logger = slog.New(slog.NewTextHandler(&log, &slog.HandlerOptions{Level: slog.LevelDebug}))
+			findings, err := New(tt.opts).AnalyzePackages("./...")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, f := range findings {
+				got = append(got, fmt.Sprintf("%d %s", f.Position.Line, f.Severity))
+			}
+			if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+				t.Errorf("got findings %q, want %q", got, tt.want)
+			}
+			if hit := strings.Contains(log.String(), "cache hit"); hit != tt.wantHit {
+				t.Errorf("cache hit = %v, want %v", hit, tt.wantHit)
+			}
+		})
+	}
+}
@@ -0,0 +1,610 @@
+// Package cli implements the codecheck command, so that programs adding
+// custom detectors with codecheck.Register can offer the same command line.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/token"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shivansh-2003/github-code/codecheck"
+)
+
+const (
+	exitClean    = 0
+	exitFindings = 1
+	exitError    = 2
+)
+
+// Main runs the codecheck command with the program's arguments and returns
+// its exit status, as documented in cmd/codecheck, for os.Exit. Detectors
+// registered before Main is called run alongside the built-in ones.
+func Main() int {
+	logger = slog.New(newLogHandler(os.Stderr, slog.LevelInfo))
+	if len(os.Args) > 1 && os.Args[1] == "explain" {
+		return explain(os.Args[2:])
+	}
+	if len(os.Args) > 1 && os.Args[1] == "watch" {
+		return watch(os.Args[2:])
+	}
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: codecheck [flags] [file.go | dir | package pattern]...\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "       codecheck explain rule-id...\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "       codecheck watch [flags] [dir]\n")
+		flag.PrintDefaults()
+	}
+	allow := flag.String("ignored-error-allow", "", "comma-separated `functions` whose errors may be discarded, e.g. fmt.Fprintf")
+	format := flag.String("format", "text", "output `format`: text, json, sarif, html, junit or github-pr")
+	root := flag.String("root", ".", "repository root that file paths in sarif and github-pr output and in the -diff are relative to")
+	baseline := flag.String("baseline", "", "suppress findings recorded in the baseline `file`")
+	writeBaseline := flag.String("write-baseline", "", "record the current findings as a baseline `file` and exit")
+	minConfidence := flag.String("min-confidence", "low", "only report findings with at least this `confidence` (low, medium or high)")
+	failOn := flag.String("fail-on", "", "exit with status 1 if any finding is at or above this `severity` (error, warning or note)")
+	configPath := flag.String("config", "", "configuration `file` (default "+codecheck.ConfigFile+" if it exists)")
+	configInit := flag.Bool("config-init", false, "write a configuration file listing every rule with its defaults to the -config file or "+codecheck.ConfigFile+" and exit")
+	force := flag.Bool("force", false, "with -config-init, overwrite an existing configuration file")
+	var exclude globsFlag
+	flag.Var(&exclude, "exclude", "leave out files matching the `glob`, such as vendor, '*.pb.go' or 'internal/**/*_gen.go'; may be repeated")
+	includeGenerated := flag.Bool("include-generated", false, "also analyze files marked // Code generated ... DO NOT EDIT.")
+	includeTests := flag.Bool("include-tests", false, "also analyze _test.go files of packages")
+	tags := flag.String("tags", "", "comma-separated build `tags` used to select files in packages")
+	jobs := flag.Int("jobs", 0, "analyze up to `n` files or packages in parallel (default GOMAXPROCS)")
+	noCache := flag.Bool("no-cache", false, "neither read nor write cached results")
+	clearCache := flag.Bool("clear-cache", false, "remove all cached results and exit")
+	fix := flag.Bool("fix", false, "apply the safe suggested fixes to the analyzed files")
+	dryRun := flag.Bool("dry-run", false, "with -fix, print the fixes as a diff instead of applying them")
+	diffPath := flag.String("diff", "", "unified diff `file` of the changes under review, as from git diff, or - for standard input")
+	verbosity := flag.String("verbosity", "info", "log diagnostics to standard error at this `level` and above: error, warn, info or debug")
+	profile := flag.Bool("profile", false, "print the time each rule took, its findings and the files it visited to standard error")
+	cpuProfile := flag.String("cpuprofile", "", "write a pprof CPU profile of the run to `file`")
+	tabWidth := flag.Int("tabwidth", 0, "report columns as characters with tabs expanded to `n` columns, as editors show them, instead of as bytes")
+	stdin := flag.Bool("stdin", false, "analyze Go source read from standard input, such as an editor's unsaved buffer, as the -filename file")
+	filename := flag.String("filename", "", "with -stdin, the `file` the source is from, which gives findings their positions and places the source in its package")
+	var dedupe dedupeFlag
+	flag.Var(&dedupe, "dedupe", "collapse findings with the same rule and message into one with a count (-dedupe=rule, not -dedupe rule: all findings of a rule)")
+	dedupeShown := flag.Int("dedupe-locations", 5, "with -dedupe, list up to `n` of the collapsed findings' locations")
+	noSummary := flag.Bool("no-summary", false, "don't end the run with a summary of the findings, files analyzed and time taken on standard error")
+	only := flag.String("only", "", "run only the comma-separated `rules`, such as sql-injection,hardcoded-credential, whatever the configuration enables")
+	skip := flag.String("skip", "", "don't run the comma-separated `rules`, such as printf,shadow, whatever the configuration enables")
+	diffOnly := flag.Bool("diff-only", false, "only report findings spanning a line the -diff adds or changes (without -diff, read the diff from standard input)")
+	flag.Parse()
+	level, err := parseVerbosity(*verbosity)
+	if err != nil {
+		return fail(fmt.Errorf("-verbosity: %v", err))
+	}
+	logger = slog.New(newLogHandler(os.Stderr, level))
+	if *clearCache {
+		dir, err := codecheck.DefaultCacheDir()
+		if err != nil {
+			return fail(err)
+		}
+		if err := codecheck.ClearCache(dir); err != nil {
+			return fail(err)
+		}
+		logger.Info("removed " + dir)
+		return exitClean
+	}
+	if *configInit {
+		path := *configPath
+		if path == "" {
+			path = codecheck.ConfigFile
+		}
+		if err := initConfig(path, *force); err != nil {
+			return fail(err)
+		}
+		logger.Info("wrote " + path)
+		return exitClean
+	}
+	switch {
+	case *stdin:
+		if *filename == "" {
+			return fail(fmt.Errorf("-stdin requires -filename"))
+		}
+		if flag.NArg() > 0 {
+			return fail(fmt.Errorf("-stdin takes no file or package arguments"))
+		}
+		if *fix {
+			return fail(fmt.Errorf("-fix cannot rewrite standard input"))
+		}
+	case *filename != "":
+		return fail(fmt.Errorf("-filename requires -stdin"))
+	case flag.NArg() == 0:
+		flag.Usage()
+		return exitError
+	}
+	switch *format {
+	case "text", "json", "sarif", "html", "junit", "github-pr":
+	default:
+		return fail(fmt.Errorf("unknown format %q", *format))
+	}
+	if *dryRun && !*fix {
+		return fail(fmt.Errorf("-dry-run requires -fix"))
+	}
+	if err := dedupe.checkArgs(flag.Args()); err != nil {
+		return fail(err)
+	}
+	var threshold *codecheck.Severity
+	if *failOn != "" {
+		sev, err := codecheck.ParseSeverity(*failOn)
+		if err != nil {
+			return fail(fmt.Errorf("-fail-on: %v", err))
+		}
+		threshold = &sev
+	}
+
+	var diff *codecheck.Diff
+	if *diffOnly && *diffPath == "" {
+		*diffPath = "-"
+	}
+	if *stdin && *diffPath == "-" {
+		return fail(fmt.Errorf("-stdin and -diff - cannot both read standard input"))
+	}
+	if *diffPath != "" {
+		d, err := loadDiff(*diffPath)
+		if err != nil {
+			return fail(err)
+		}
+		diff = d
+	}
+
+	var opts codecheck.Options
+	conf, err := codecheck.ParseConfidence(*minConfidence)
+	if err != nil {
+		return fail(fmt.Errorf("-min-confidence: %v", err))
+	}
+	opts.MinConfidence = conf
+	if *allow != "" {
+		opts.IgnoredErrorAllow = strings.Split(*allow, ",")
+	}
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return fail(err)
+	}
+	if cfg, err = selectRules(cfg, *only, *skip); err != nil {
+		return fail(err)
+	}
+	opts.Config = cfg
+	opts.IncludeTests = *includeTests
+	opts.Exclude = exclude
+	opts.IncludeGenerated = *includeGenerated
+	opts.Jobs = *jobs
+	if !*noCache {
+		// Without a usable cache directory the analysis just runs uncached.
+		if dir, err := codecheck.DefaultCacheDir(); err == nil {
+			opts.CacheDir = dir
+		} else {
+			logger.Debug("not caching results", "err", err)
+		}
+	}
+	if *tags != "" {
+		opts.BuildTags = strings.Split(*tags, ",")
+	}
+	opts.Logger = logger
+	if *cpuProfile != "" {
+		stop, err := startCPUProfile(*cpuProfile)
+		if err != nil {
+			return fail(err)
+		}
+		defer stop()
+	}
+	if *profile {
+		opts.Profile = new(codecheck.Profile)
+		defer writeProfile(os.Stderr, opts.Profile)
+	}
+	a := codecheck.New(opts)
+	start := time.Now()
+	var findings []codecheck.Finding
+	// sources holds the source read from standard input, by the name its
+	// findings have once relativized, for -tabwidth.
+	var sources map[string][]byte
+	if *stdin {
+		var src []byte
+		findings, src, err = analyzeStdin(a, *filename)
+		name := *filename
+		if wd, err := os.Getwd(); err == nil {
+			name = relativePath(wd, name)
+		}
+		sources = map[string][]byte{name: src}
+	} else {
+		findings, err = analyze(a, flag.Args())
+	}
+	if err != nil {
+		return fail(err)
+	}
+	relativize(findings)
+
+	if *writeBaseline != "" {
+		if err := codecheck.WriteBaseline(*writeBaseline, findings); err != nil {
+			return fail(err)
+		}
+		logger.Info(fmt.Sprintf("wrote %d findings to %s", len(findings), *writeBaseline))
+		return exitClean
+	}
+	var b codecheck.Baseline
+	if *baseline != "" {
+		if b, err = codecheck.LoadBaseline(*baseline); err != nil {
+			return fail(err)
+		}
+		findings = b.Filter(findings)
+	}
+	if *fix {
+		if err := applyFixes(findings, *dryRun); err != nil {
+			return fail(err)
+		}
+		if *dryRun {
+			return exitClean
+		}
+		// Report what is left at its position in the rewritten files.
+		if findings, err = analyze(a, flag.Args()); err != nil {
+			return fail(err)
+		}
+		relativize(findings)
+		if b != nil {
+			findings = b.Filter(findings)
+		}
+	}
+	if *diffOnly {
+		findings = diff.Filter(findings, *root)
+	}
+	findings = present(findings, sources, *tabWidth, dedupe, *dedupeShown)
+
+	switch *format {
+	case "text":
+		writeText(os.Stdout, findings)
+	case "json":
+		err = codecheck.WriteJSON(os.Stdout, findings)
+	case "sarif":
+		err = codecheck.WriteSARIF(os.Stdout, a.Rules(), findings, *root)
+	case "html":
+		err = codecheck.WriteHTML(os.Stdout, findings)
+	case "junit":
+		err = codecheck.WriteJUnit(os.Stdout, analyzedFiles(a), findings)
+	case "github-pr":
+		err = codecheck.WriteGitHubReview(os.Stdout, findings, *root, diff)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	if !*noSummary {
+		writeSummary(os.Stderr, findings, len(a.AnalyzedFiles()), time.Since(start))
+	}
+	if threshold != nil {
+		for _, f := range findings {
+			if f.Severity >= *threshold {
+				return exitFindings
+			}
+		}
+	}
+	return exitClean
+}
+
+// analyze runs a over args: Go files are analyzed on their own and
+// everything else (directories, import paths, patterns like ./...) is
+// loaded as packages.
+func analyze(a *codecheck.Analyzer, args []string) ([]codecheck.Finding, error) {
+	files, patterns := splitArgs(args)
+	findings, err := a.AnalyzeFiles(files...)
+	if err != nil {
+		return nil, err
+	}
+	if len(patterns) > 0 {
+		fs, err := a.AnalyzePackages(patterns...)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, fs...)
+	}
+	return findings, nil
+}
+
+// analyzedFiles returns the files a has analyzed, with paths relative to
+// the current directory where they are inside it, as the findings' are.
+func analyzedFiles(a *codecheck.Analyzer) []string {
+	files := a.AnalyzedFiles()
+	if wd, err := os.Getwd(); err == nil {
+		for i := range files {
+			files[i] = relativePath(wd, files[i])
+		}
+		sort.Strings(files)
+	}
+	return files
+}
+
+// present rewrites findings as the output flags ask: with tabWidth, as
+// from -tabwidth, above 0 their columns count tabs expanded, reading the
+// files not in sources, and with dedupe those with the same message or rule
+// are collapsed, listing up to shown of their locations.
+func present(findings []codecheck.Finding, sources map[string][]byte, tabWidth int, dedupe dedupeFlag, shown int) []codecheck.Finding {
+	if tabWidth > 0 {
+		codecheck.ExpandTabs(findings, tabWidth, sources)
+	}
+	if dedupe != "" {
+		key := codecheck.DedupeByMessage
+		if dedupe == "rule" {
+			key = codecheck.DedupeByRule
+		}
+		findings = codecheck.Dedupe(findings, key, shown)
+	}
+	return findings
+}
+
+// splitArgs splits the command's arguments into Go files, analyzed on
+// their own, and package patterns.
+func splitArgs(args []string) (files, patterns []string) {
+	for _, arg := range args {
+		if strings.HasSuffix(arg, ".go") {
+			files = append(files, arg)
+			continue
+		}
+		if fi, err := os.Stat(arg); err == nil && fi.IsDir() && !strings.HasPrefix(arg, ".") && !filepath.IsAbs(arg) {
+			// The go command treats a bare "dir" as an import path.
+			arg = "./" + arg
+		}
+		patterns = append(patterns, arg)
+	}
+	return files, patterns
+}
+
+// analyzeStdin runs a over the Go source on standard input as the file
+// filename, and returns the source with the findings.
+func analyzeStdin(a *codecheck.Analyzer, filename string) ([]codecheck.Finding, []byte, error) {
+	if !strings.HasSuffix(filename, ".go") {
+		return nil, nil, fmt.Errorf("-filename %s is not a Go file", filename)
+	}
+	src, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, nil, err
+	}
+	findings, err := a.AnalyzeSource(filename, src)
+	return findings, src, err
+}
+
+// relativize rewrites finding paths under the current directory relative
+// to it.
+func relativize(findings []codecheck.Finding) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return
+	}
+	rel := func(pos *token.Position) {
+		pos.Filename = relativePath(wd, pos.Filename)
+	}
+	for i := range findings {
+		rel(&findings[i].Position)
+		rel(&findings[i].End)
+		for j := range findings[i].Related {
+			rel(&findings[i].Related[j].Position)
+		}
+		if fix := findings[i].Fix; fix != nil {
+			for j := range fix.Edits {
+				pos := token.Position{Filename: fix.Edits[j].File}
+				rel(&pos)
+				fix.Edits[j].File = pos.Filename
+			}
+		}
+	}
+}
+
+// relativePath returns the absolute path name relative to the directory
+// wd if it is inside it, and name itself otherwise.
+func relativePath(wd, name string) string {
+	if !filepath.IsAbs(name) {
+		return name
+	}
+	if r, err := filepath.Rel(wd, name); err == nil && !strings.HasPrefix(r, "..") {
+		return r
+	}
+	return name
+}
+
+// applyFixes applies the safe fixes of findings, or with dryRun prints
+// them as a diff.
+func applyFixes(findings []codecheck.Finding, dryRun bool) error {
+	files, fixed, err := codecheck.ApplyFixes(findings)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if dryRun {
+			old, err := os.ReadFile(name)
+			if err != nil {
+				return err
+			}
+			writeDiff(os.Stdout, name, old, files[name])
+			continue
+		}
+		fi, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(name, files[name], fi.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	if !dryRun {
+		n := 0
+		for _, ok := range fixed {
+			if ok {
+				n++
+			}
+		}
+		logger.Info(fmt.Sprintf("fixed %d findings in %d files", n, len(files)))
+	}
+	return nil
+}
+
+// logger receives the command's diagnostics, which go to standard error so
+// that standard output holds nothing but the report.
+var logger *slog.Logger
+
+// fail reports err and returns the exit status for a failed analysis.
+func fail(err error) int {
+	logger.Error(err.Error())
+	return exitError
+}
+
+func writeText(w io.Writer, findings []codecheck.Finding) {
+	for _, f := range findings {
+		fmt.Fprintln(w, f)
+		if f.Confidence != codecheck.ConfidenceHigh {
+			fmt.Fprintf(w, "\tconfidence: %s\n", f.Confidence)
+		}
+		for _, r := range f.Related {
+			fmt.Fprintf(w, "\t%s: note: %s\n", r.Position, r.Message)
+		}
+		if f.Suggestion != "" {
+			fmt.Fprintf(w, "\tsuggestion: %s\n", f.Suggestion)
+		}
+		if f.Fix != nil {
+			how := "apply with -fix"
+			if !f.Fix.Safe {
+				how = "needs review, not applied by -fix"
+			}
+			fmt.Fprintf(w, "\tfix: %s (%s)\n", f.Fix.Message, how)
+		}
+	}
+}
+
+// loadDiff reads the unified diff in file path, or on standard input if
+// path is "-".
+func loadDiff(path string) (*codecheck.Diff, error) {
+	if path == "-" {
+		return codecheck.ParseDiff(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	d, err := codecheck.ParseDiff(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return d, nil
+}
+
+// initConfig writes the configuration template to path, which must not
+// exist unless force is set.
+func initConfig(path string, force bool) error {
+	mode := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		mode = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, mode, 0o666)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%s already exists; use -force to overwrite it", path)
+	}
+	if err != nil {
+		return err
+	}
+	if err := codecheck.WriteConfigTemplate(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadConfig loads the configuration file at path or, if path is empty, the
+// default configuration file when one exists.
+func loadConfig(path string) (*codecheck.Config, error) {
+	if path == "" {
+		if _, err := os.Stat(codecheck.ConfigFile); err != nil {
+			return nil, nil
+		}
+		path = codecheck.ConfigFile
+	}
+	return codecheck.LoadConfig(path)
+}
+
+// selectRules applies the -only and -skip flags to cfg, which may be nil
+// if there is no configuration file, returning the configuration to use.
+func selectRules(cfg *codecheck.Config, only, skip string) (*codecheck.Config, error) {
+	if only == "" && skip == "" {
+		return cfg, nil
+	}
+	if cfg == nil {
+		cfg = &codecheck.Config{}
+	}
+	if err := cfg.SelectRules(ruleList(only), nil); err != nil {
+		return nil, fmt.Errorf("-only: %v", err)
+	}
+	if err := cfg.SelectRules(nil, ruleList(skip)); err != nil {
+		return nil, fmt.Errorf("-skip: %v", err)
+	}
+	return cfg, nil
+}
+
+// ruleList splits a comma-separated list of rule ids.
+func ruleList(s string) []string {
+	var ids []string
+	for _, id := range strings.Split(s, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// globsFlag is a flag that may be given several times, collecting its
+// values.
+type globsFlag []string
+
+func (g *globsFlag) String() string { return strings.Join(*g, ",") }
+
+func (g *globsFlag) Set(v string) error {
+	if _, err := path.Match(v, ""); err != nil {
+		return fmt.Errorf("bad pattern %q: %v", v, err)
+	}
+	*g = append(*g, v)
+	return nil
+}
+
+// dedupeFlag is the -dedupe flag: "message" when given on its own or as
+// -dedupe=message, "rule" as -dedupe=rule, and "" when not given.
+type dedupeFlag string
+
+func (d *dedupeFlag) String() string { return string(*d) }
+
+func (d *dedupeFlag) Set(v string) error {
+	switch v {
+	case "true", "message":
+		*d = "message"
+	case "rule":
+		*d = "rule"
+	case "false":
+		*d = ""
+	default:
+		return fmt.Errorf("want message or rule, not %q", v)
+	}
+	return nil
+}
+
+func (d *dedupeFlag) IsBoolFlag() bool { return true }
+
+// checkArgs rejects `-dedupe rule` and `-dedupe message`: since -dedupe is
+// a boolean flag, the value is read as the first file or package argument,
+// which is an error unless there is such a file.
+func (d dedupeFlag) checkArgs(args []string) error {
+	if d == "" || len(args) == 0 || args[0] != "rule" && args[0] != "message" {
+		return nil
+	}
+	if _, err := os.Stat(args[0]); err == nil {
+		return nil
+	}
+	return fmt.Errorf("no file or directory %q; to collapse findings by %s, write -dedupe=%s", args[0], args[0], args[0])
+}
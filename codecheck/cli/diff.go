@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// writeDiff writes a unified diff from old to new, both the contents of
+// the file name.
+func writeDiff(w io.Writer, name string, old, new []byte) {
+	a := splitLines(string(old))
+	b := splitLines(string(new))
+
+	// Only the lines between the common prefix and suffix need comparing,
+	// which keeps the quadratic part small for localized edits.
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	ops := diffLines(a[pre:len(a)-suf], b[pre:len(b)-suf])
+
+	// Expand the ops to cover the whole file, then group them into hunks.
+	type line struct {
+		op   byte // ' ', '-' or '+'
+		text string
+		ai   int // line index in a (for ' ' and '-') or of the next a line
+		bi   int
+	}
+	var lines []line
+	for i := 0; i < pre; i++ {
+		lines = append(lines, line{' ', a[i], i, i})
+	}
+	ai, bi := pre, pre
+	for _, op := range ops {
+		switch op {
+		case ' ':
+			lines = append(lines, line{' ', a[ai], ai, bi})
+			ai++
+			bi++
+		case '-':
+			lines = append(lines, line{'-', a[ai], ai, bi})
+			ai++
+		case '+':
+			lines = append(lines, line{'+', b[bi], ai, bi})
+			bi++
+		}
+	}
+	for i := 0; i < suf; i++ {
+		lines = append(lines, line{' ', a[ai+i], ai + i, bi + i})
+	}
+
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", name, name)
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+		start := max(i-diffContext, 0)
+		end := i
+		for end < len(lines) {
+			if lines[end].op != ' ' {
+				end++
+				continue
+			}
+			// Continue the hunk if another change follows closely.
+			next := end
+			for next < len(lines) && lines[next].op == ' ' {
+				next++
+			}
+			if next == len(lines) || next-end > 2*diffContext {
+				end = min(end+diffContext, len(lines))
+				break
+			}
+			end = next
+		}
+		var na, nb int
+		for _, l := range lines[start:end] {
+			if l.op != '+' {
+				na++
+			}
+			if l.op != '-' {
+				nb++
+			}
+		}
+		fmt.Fprintf(w, "@@ -%d,%d +%d,%d @@\n", lines[start].ai+1, na, lines[start].bi+1, nb)
+		for _, l := range lines[start:end] {
+			fmt.Fprintf(w, "%c%s\n", l.op, l.text)
+		}
+		i = end
+	}
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines returns the edit script turning a into b as a sequence of
+// ' ' (keep), '-' (delete from a) and '+' (insert from b), based on a
+// longest common subsequence.
+func diffLines(a, b []string) []byte {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var ops []byte
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, ' ')
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, '-')
+			i++
+		default:
+			ops = append(ops, '+')
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, '-')
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, '+')
+	}
+	return ops
+}
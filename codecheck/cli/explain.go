@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/shivansh-2003/github-code/codecheck"
+)
+
+// explain implements `codecheck explain rule-id...`, printing the
+// documentation of each rule.
+func explain(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, "usage: codecheck explain rule-id...\n")
+		return exitError
+	}
+	known := map[string]codecheck.Rule{}
+	var ids []string
+	for _, r := range codecheck.KnownRules() {
+		known[r.ID] = r
+		ids = append(ids, r.ID)
+	}
+	for i, id := range args {
+		r, ok := known[id]
+		if !ok {
+			if near := closestRules(id, ids); len(near) > 0 {
+				return fail(fmt.Errorf("unknown rule %q; did you mean %s?", id, strings.Join(quoted(near), " or ")))
+			}
+			sort.Strings(ids)
+			return fail(fmt.Errorf("unknown rule %q (known rules: %s)", id, strings.Join(ids, ", ")))
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Print(r.Help())
+	}
+	return exitClean
+}
+
+// closestRules returns the ids closest to id by edit distance, if any are
+// close enough to be a likely typo, or those containing id.
+func closestRules(id string, ids []string) []string {
+	var near []string
+	best := len(id)/3 + 1
+	for _, c := range ids {
+		switch d := editDistance(id, c); {
+		case d < best:
+			best, near = d, []string{c}
+		case d == best:
+			near = append(near, c)
+		}
+	}
+	if len(near) > 0 {
+		return near
+	}
+	for _, c := range ids {
+		if strings.Contains(c, id) {
+			near = append(near, c)
+		}
+	}
+	return near
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func quoted(ss []string) []string {
+	q := make([]string, len(ss))
+	for i, s := range ss {
+		q[i] = fmt.Sprintf("%q", s)
+	}
+	return q
+}
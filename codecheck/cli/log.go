@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// parseVerbosity parses a -verbosity value.
+func parseVerbosity(s string) (slog.Level, error) {
+	switch s {
+	case "error":
+		return slog.LevelError, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	}
+	return 0, fmt.Errorf("unknown verbosity %q (want error, warn, info or debug)", s)
+}
+
+// logHandler writes diagnostics one line per record, as
+// "codecheck: [level: ]message key=value ...", the level being left out for
+// info and error messages, which are the command's usual chatter and
+// failures.
+type logHandler struct {
+	mu    *sync.Mutex
+	w     io.Writer
+	level slog.Level
+	attrs string // preformatted attributes from WithAttrs
+	group string // prefix for attribute keys from WithGroup
+}
+
+func newLogHandler(w io.Writer, level slog.Level) *logHandler {
+	return &logHandler{mu: new(sync.Mutex), w: w, level: level}
+}
+
+func (h *logHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *logHandler) Handle(_ context.Context, r slog.Record) error {
+	var buf bytes.Buffer
+	buf.WriteString("codecheck: ")
+	switch {
+	case r.Level < slog.LevelInfo:
+		buf.WriteString("debug: ")
+	case r.Level >= slog.LevelWarn && r.Level < slog.LevelError:
+		buf.WriteString("warning: ")
+	}
+	buf.WriteString(r.Message)
+	buf.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		appendAttr(&buf, h.group, a)
+		return true
+	})
+	buf.WriteByte('\n')
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+func (h *logHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var buf bytes.Buffer
+	for _, a := range attrs {
+		appendAttr(&buf, h.group, a)
+	}
+	c := *h
+	c.attrs += buf.String()
+	return &c
+}
+
+func (h *logHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := *h
+	c.group += name + "."
+	return &c
+}
+
+func appendAttr(buf *bytes.Buffer, group string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			group += a.Key + "."
+		}
+		for _, g := range a.Value.Group() {
+			appendAttr(buf, group, g)
+		}
+		return
+	}
+	v := a.Value.String()
+	if v == "" || strings.ContainsAny(v, " \t\n\"=") {
+		v = strconv.Quote(v)
+	}
+	fmt.Fprintf(buf, " %s%s=%s", group, a.Key, v)
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime/pprof"
+	"text/tabwriter"
+	"time"
+
+	"github.com/shivansh-2003/github-code/codecheck"
+)
+
+// startCPUProfile starts writing a CPU profile to path and returns the
+// function that finishes it.
+func startCPUProfile(path string) (stop func(), err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("-cpuprofile: %v", err)
+	}
+	return func() {
+		pprof.StopCPUProfile()
+		if err := f.Close(); err != nil {
+			logger.Error(err.Error())
+		}
+	}, nil
+}
+
+// writeProfile prints p as a table, slowest rule first.
+func writeProfile(w io.Writer, p *codecheck.Profile) {
+	rules := p.Rules()
+	if len(rules) == 0 {
+		fmt.Fprintln(w, "codecheck: profile: no detector ran; every result came from the cache (use -no-cache)")
+		return
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "rule\ttime\tfindings\tfiles")
+	var total codecheck.RuleProfile
+	for _, r := range rules {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\n", r.Rule, r.Duration.Round(time.Microsecond), r.Findings, r.Files)
+		total.Duration += r.Duration
+		total.Findings += r.Findings
+	}
+	fmt.Fprintf(tw, "total\t%s\t%d\n", total.Duration.Round(time.Microsecond), total.Findings)
+	tw.Flush()
+}
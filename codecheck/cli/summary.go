@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shivansh-2003/github-code/codecheck"
+)
+
+// summaryFiles is how many of the files with the most findings the summary
+// names.
+const summaryFiles = 5
+
+// writeSummary writes the closing summary of a run to w: how many findings
+// were reported, by severity, in how many files, how long the analysis
+// took, and the files with the most findings. Findings collapsed by
+// -dedupe count as the findings they stand for. It is written whatever the
+// -verbosity, which only filters log messages.
+func writeSummary(w io.Writer, findings []codecheck.Finding, files int, elapsed time.Duration) {
+	bySeverity := map[codecheck.Severity]int{}
+	byFile := map[string]int{}
+	total := 0
+	var count func(codecheck.Finding)
+	count = func(f codecheck.Finding) {
+		total++
+		bySeverity[f.Severity]++
+		byFile[f.Position.Filename]++
+		for _, d := range f.Duplicates {
+			count(d)
+		}
+	}
+	for _, f := range findings {
+		count(f)
+	}
+
+	var msg strings.Builder
+	if total == 0 {
+		msg.WriteString("no findings")
+	} else {
+		msg.WriteString(plural(total, "finding"))
+	}
+	fmt.Fprintf(&msg, " in %s", plural(files, "file"))
+	if total > 0 {
+		var parts []string
+		for _, sev := range []codecheck.Severity{codecheck.SeverityError, codecheck.SeverityWarning, codecheck.SeverityNote} {
+			if n := bySeverity[sev]; n > 0 {
+				parts = append(parts, plural(n, sev.String()))
+			}
+		}
+		fmt.Fprintf(&msg, " (%s)", strings.Join(parts, ", "))
+	}
+	fmt.Fprintf(&msg, ", analyzed in %s", elapsed.Round(time.Millisecond))
+	fmt.Fprintf(w, "codecheck: %s\n", msg.String())
+
+	if total == 0 {
+		return
+	}
+	names := make([]string, 0, len(byFile))
+	for name := range byFile {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if byFile[names[i]] != byFile[names[j]] {
+			return byFile[names[i]] > byFile[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > summaryFiles {
+		names = names[:summaryFiles]
+	}
+	top := make([]string, len(names))
+	for i, name := range names {
+		top[i] = fmt.Sprintf("%s (%d)", name, byFile[name])
+	}
+	fmt.Fprintf(w, "codecheck: most findings: %s\n", strings.Join(top, ", "))
+}
+
+// plural formats n and noun, adding an s to noun unless n is 1.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
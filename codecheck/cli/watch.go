@@ -0,0 +1,366 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/shivansh-2003/github-code/codecheck"
+)
+
+// watch implements `codecheck watch [flags] [dir]`: it analyzes the
+// packages under dir, then re-analyzes the package of each Go file that
+// changes, printing the findings that appeared and those that went away,
+// until interrupted.
+//
+// Changes are found through fsnotify. Its watches are not recursive, so
+// each directory scan visits is watched, and the tree is scanned again
+// after every burst of changes to pick up directories created or removed
+// in it.
+func watch(args []string) int {
+	flags := flag.NewFlagSet("watch", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: codecheck watch [flags] [dir]\n")
+		flags.PrintDefaults()
+	}
+	debounce := flags.Duration("debounce", 300*time.Millisecond, "wait until no file has changed for this `long` before analyzing")
+	minConfidence := flags.String("min-confidence", "low", "only report findings with at least this `confidence` (low, medium or high)")
+	configPath := flags.String("config", "", "configuration `file` (default "+codecheck.ConfigFile+" in dir if it exists)")
+	only := flags.String("only", "", "run only the comma-separated `rules`, whatever the configuration enables")
+	skip := flags.String("skip", "", "don't run the comma-separated `rules`, whatever the configuration enables")
+	var exclude globsFlag
+	flags.Var(&exclude, "exclude", "leave out files matching the `glob`; may be repeated")
+	includeGenerated := flags.Bool("include-generated", false, "also analyze files marked // Code generated ... DO NOT EDIT.")
+	includeTests := flags.Bool("include-tests", false, "also analyze _test.go files of packages")
+	tags := flags.String("tags", "", "comma-separated build `tags` used to select files in packages")
+	noCache := flags.Bool("no-cache", false, "neither read nor write cached results")
+	verbosity := flags.String("verbosity", "info", "log diagnostics to standard error at this `level` and above: error, warn, info or debug")
+	tabWidth := flags.Int("tabwidth", 0, "report columns as characters with tabs expanded to `n` columns, as editors show them, instead of as bytes")
+	var dedupe dedupeFlag
+	flags.Var(&dedupe, "dedupe", "collapse findings with the same rule and message into one with a count (-dedupe=rule, not -dedupe rule: all findings of a rule)")
+	dedupeShown := flags.Int("dedupe-locations", 5, "with -dedupe, list up to `n` of the collapsed findings' locations")
+	if err := flags.Parse(args); err != nil {
+		return exitError
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return exitError
+	}
+	level, err := parseVerbosity(*verbosity)
+	if err != nil {
+		return fail(fmt.Errorf("-verbosity: %v", err))
+	}
+	logger = slog.New(newLogHandler(os.Stderr, level))
+	if err := dedupe.checkArgs(flags.Args()); err != nil {
+		return fail(err)
+	}
+	dir := "."
+	if flags.NArg() == 1 {
+		dir = flags.Arg(0)
+	}
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return fail(err)
+	}
+	if fi, err := os.Stat(root); err != nil {
+		return fail(err)
+	} else if !fi.IsDir() {
+		return fail(fmt.Errorf("%s is not a directory", dir))
+	}
+
+	opts := codecheck.Options{Dir: root, Exclude: exclude, IncludeGenerated: *includeGenerated, IncludeTests: *includeTests, Logger: logger}
+	if opts.MinConfidence, err = codecheck.ParseConfidence(*minConfidence); err != nil {
+		return fail(fmt.Errorf("-min-confidence: %v", err))
+	}
+	if *configPath == "" {
+		if _, err := os.Stat(filepath.Join(root, codecheck.ConfigFile)); err == nil {
+			*configPath = filepath.Join(root, codecheck.ConfigFile)
+		}
+	}
+	if *configPath != "" {
+		if opts.Config, err = codecheck.LoadConfig(*configPath); err != nil {
+			return fail(err)
+		}
+	}
+	if opts.Config, err = selectRules(opts.Config, *only, *skip); err != nil {
+		return fail(err)
+	}
+	if *tags != "" {
+		opts.BuildTags = strings.Split(*tags, ",")
+	}
+	if !*noCache {
+		if dir, err := codecheck.DefaultCacheDir(); err == nil {
+			opts.CacheDir = dir
+		} else {
+			logger.Debug("not caching results", "err", err)
+		}
+	}
+
+	events, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fail(err)
+	}
+	defer events.Close()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	w := &watcher{
+		a: codecheck.New(opts), root: root, events: events, findings: map[string][]codecheck.Finding{},
+		tabWidth: *tabWidth, dedupe: dedupe, dedupeShown: *dedupeShown,
+	}
+	w.files = w.scan()
+	if err := w.analyze(nil); err != nil {
+		return fail(err)
+	}
+	logger.Info(fmt.Sprintf("watching %d files in %s; interrupt to stop", len(w.files), dir))
+
+	changed := map[string]bool{}
+	// settled fires once no change has come in for -debounce.
+	var settled <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("stopped watching")
+			return exitClean
+		case err := <-events.Errors:
+			logger.Error(err.Error())
+		case ev := <-events.Events:
+			if ev.Op == fsnotify.Chmod {
+				continue
+			}
+			// A directory created may already hold files by the time
+			// it is watched; the scan after the burst finds them.
+			if strings.HasSuffix(ev.Name, ".go") {
+				changed[ev.Name] = true
+			} else if !ev.Has(fsnotify.Create) && !ev.Has(fsnotify.Remove) && !ev.Has(fsnotify.Rename) {
+				continue
+			}
+			settled = time.After(*debounce)
+		case <-settled:
+			settled = nil
+			files := w.scan()
+			for name := range diffFiles(w.files, files) {
+				changed[name] = true
+			}
+			w.files = files
+			dirs := map[string]bool{}
+			for name := range changed {
+				logger.Debug("changed", "file", name)
+				dirs[filepath.Dir(name)] = true
+			}
+			clear(changed)
+			if err := w.analyze(dirs); err != nil {
+				// Most likely a file saved half-edited; the next save
+				// tries again.
+				logger.Error(err.Error())
+			}
+		}
+	}
+}
+
+// A watcher holds the state of `codecheck watch`: the Go files under root,
+// the findings last reported for each package directory, and the output
+// flags to print findings with.
+type watcher struct {
+	a        *codecheck.Analyzer
+	root     string
+	events   *fsnotify.Watcher
+	files    map[string]bool
+	findings map[string][]codecheck.Finding
+
+	tabWidth    int
+	dedupe      dedupeFlag
+	dedupeShown int
+}
+
+// scan returns the Go files of the packages matched by ./... in root, and
+// adds the directories holding them to w.events: it skips directories
+// whose names start with "." or "_", testdata directories, and nested
+// modules, as the go command does.
+func (w *watcher) scan() map[string]bool {
+	files := map[string]bool{}
+	filepath.WalkDir(w.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path == w.root {
+				return w.watchDir(path)
+			}
+			if name := d.Name(); strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+			return w.watchDir(path)
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		files[path] = true
+		return nil
+	})
+	return files
+}
+
+// watchDir adds dir to w.events; adding a directory already watched does
+// nothing. A directory that can't be watched is logged and left out of
+// the scan, as if it couldn't be read.
+func (w *watcher) watchDir(dir string) error {
+	if err := w.events.Add(dir); err != nil {
+		logger.Warn("not watching "+dir, "err", err)
+		return filepath.SkipDir
+	}
+	return nil
+}
+
+// diffFiles returns the files added or removed between old and cur.
+func diffFiles(old, cur map[string]bool) map[string]bool {
+	changed := map[string]bool{}
+	for name := range cur {
+		if !old[name] {
+			changed[name] = true
+		}
+	}
+	for name := range old {
+		if _, ok := cur[name]; !ok {
+			changed[name] = true
+		}
+	}
+	return changed
+}
+
+// analyze analyzes the packages in dirs, or every package under w.root if
+// dirs is nil, and prints how their findings differ from those printed
+// before. A directory left without Go files loses its findings.
+func (w *watcher) analyze(dirs map[string]bool) error {
+	var patterns []string
+	if dirs == nil {
+		patterns = []string{"./..."}
+	}
+	for dir := range dirs {
+		if !w.hasFiles(dir) {
+			continue
+		}
+		rel, err := filepath.Rel(w.root, dir)
+		if err != nil {
+			return err
+		}
+		if rel != "." {
+			rel = "./" + filepath.ToSlash(rel)
+		}
+		patterns = append(patterns, rel)
+	}
+	sort.Strings(patterns)
+
+	cur := map[string][]codecheck.Finding{}
+	if len(patterns) > 0 {
+		start := time.Now()
+		findings, err := w.a.AnalyzePackages(patterns...)
+		if err != nil {
+			return err
+		}
+		logger.Debug("analyzed", "packages", strings.Join(patterns, " "), "took", time.Since(start))
+		// Expanded now, while the files hold the lines the findings are
+		// on, the columns stay right for the fixed: lines of later runs.
+		findings = present(findings, nil, w.tabWidth, "", 0)
+		for _, f := range findings {
+			dir := filepath.Dir(f.Position.Filename)
+			cur[dir] = append(cur[dir], f)
+		}
+	}
+	for dir := range dirs {
+		if _, ok := cur[dir]; !ok {
+			cur[dir] = nil
+		}
+	}
+
+	var added, fixed []codecheck.Finding
+	for dir, findings := range cur {
+		a, f := findingsDelta(w.findings[dir], findings)
+		added, fixed = append(added, a...), append(fixed, f...)
+		if len(findings) == 0 {
+			delete(w.findings, dir)
+		} else {
+			w.findings[dir] = findings
+		}
+	}
+	writeDelta(os.Stdout, present(added, nil, 0, w.dedupe, w.dedupeShown), fixed)
+	if dirs != nil {
+		total := 0
+		for _, findings := range w.findings {
+			total += len(findings)
+		}
+		logger.Info(fmt.Sprintf("%d new, %d fixed, %d in total", len(added), len(fixed), total))
+	}
+	return nil
+}
+
+// hasFiles reports whether the directory dir has any of w.files.
+func (w *watcher) hasFiles(dir string) bool {
+	for name := range w.files {
+		if filepath.Dir(name) == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// findingsDelta returns the findings of cur whose fingerprints old lacks
+// and those of old that cur lacks. Findings that only moved keep their
+// fingerprint and are in neither.
+func findingsDelta(old, cur []codecheck.Finding) (added, fixed []codecheck.Finding) {
+	in := func(findings []codecheck.Finding) map[string]bool {
+		m := map[string]bool{}
+		for _, f := range findings {
+			m[f.Fingerprint] = true
+		}
+		return m
+	}
+	before, after := in(old), in(cur)
+	for _, f := range cur {
+		if !before[f.Fingerprint] {
+			added = append(added, f)
+		}
+	}
+	for _, f := range old {
+		if !after[f.Fingerprint] {
+			fixed = append(fixed, f)
+		}
+	}
+	return added, fixed
+}
+
+// writeDelta prints the findings added, in the text format, and a line for
+// each finding fixed, both sorted by position.
+func writeDelta(w io.Writer, added, fixed []codecheck.Finding) {
+	for _, findings := range [][]codecheck.Finding{added, fixed} {
+		relativize(findings)
+		sort.SliceStable(findings, func(i, j int) bool {
+			a, b := findings[i].Position, findings[j].Position
+			if a.Filename != b.Filename {
+				return a.Filename < b.Filename
+			}
+			if a.Line != b.Line {
+				return a.Line < b.Line
+			}
+			return a.Column < b.Column
+		})
+	}
+	writeText(w, added)
+	for _, f := range fixed {
+		fmt.Fprintf(w, "fixed: %s\n", f)
+	}
+}
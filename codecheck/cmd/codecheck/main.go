@@ -0,0 +1,20 @@
+// Command codecheck runs the codecheck analyzer over Go source files and
+// prints its findings.
+//
+// Exit status:
+//
+//	0  no finding at or above the -fail-on severity (or -fail-on unset)
+//	1  at least one finding at or above the -fail-on severity
+//	2  the analysis itself failed: bad flags, unreadable or unparsable
+//	   files, invalid configuration
+package main
+
+import (
+	"os"
+
+	"github.com/shivansh-2003/github-code/codecheck/cli"
+)
+
+func main() {
+	os.Exit(cli.Main())
+}
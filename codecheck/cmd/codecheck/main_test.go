@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shivansh-2003/github-code/codecheck/cli"
+)
+
+// TestMain runs the command instead of the tests when the test binary is
+// started by run, so that the tests can check what the command prints and
+// its exit status.
+func TestMain(m *testing.M) {
+	if os.Getenv("CODECHECK_RUN_MAIN") == "1" {
+		os.Exit(cli.Main())
+	}
+	os.Exit(m.Run())
+}
+
+// run runs the command in dir with args and stdin as its standard input,
+// and returns its standard output and exit status.
+func run(t *testing.T, dir, stdin string, args ...string) (string, int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "CODECHECK_RUN_MAIN=1")
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	var exit *exec.ExitError
+	if err != nil && !errors.As(err, &exit) {
+		t.Fatal(err)
+	}
+	t.Logf("codecheck %s:\n%s%s", strings.Join(args, " "), &stdout, &stderr)
+	return stdout.String(), cmd.ProcessState.ExitCode()
+}
+
+// writeModule writes a module with the given files to a new directory and
+// returns it.
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files["go.mod"] = "module example.com/m\n\ngo 1.22\n"
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// TestStdinTabWidth checks that -tabwidth expands the tabs of the source
+// read with -stdin, not those of the file on disk.
+func TestStdinTabWidth(t *testing.T) {
+	const disk = "package m\n\nimport \"os\"\n\nfunc f() {\n\t_ = os.Remove(\"x\")\n}\n"
+	dir := writeModule(t, map[string]string{"tab.go": disk})
+	buffer := strings.Replace(disk, "\t_", "\t\t\t\t_", 1)
+	out, code := run(t, dir, buffer, "-stdin", "-filename", "tab.go", "-tabwidth", "8", "-only", "ignored-error", "-no-cache", "-no-summary")
+	if code != 0 {
+		t.Fatalf("exit status %d", code)
+	}
+	if want := "tab.go:6:33: "; !strings.HasPrefix(out, want) {
+		t.Errorf("got %q, want a finding at %s", out, want)
+	}
+}
+
+// TestFixDryRun checks that -fix -dry-run prints the fixes as a diff and
+// leaves the file alone, and that -fix then rewrites it.
+func TestFixDryRun(t *testing.T) {
+	const src = "package m\n\nimport \"strconv\"\n\nfunc f(s string) (int, error) {\n\tn, _ := strconv.Atoi(s)\n\treturn n, nil\n}\n"
+	dir := writeModule(t, map[string]string{"fix.go": src})
+	file := filepath.Join(dir, "fix.go")
+	out, _ := run(t, dir, "", "-fix", "-dry-run", "-only", "ignored-error", "-no-cache", "-no-summary", ".")
+	if !strings.Contains(out, "-\tn, _ := strconv.Atoi(s)\n+\tn, err := strconv.Atoi(s)\n") {
+		t.Errorf("-dry-run printed no diff of the fix:\n%s", out)
+	}
+	if got, err := os.ReadFile(file); err != nil || string(got) != src {
+		t.Fatalf("-dry-run changed the file to %q (%v)", got, err)
+	}
+	run(t, dir, "", "-fix", "-only", "ignored-error", "-no-cache", "-no-summary", ".")
+	got, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), "n, err := strconv.Atoi(s)\n\tif err != nil {") {
+		t.Errorf("-fix did not rewrite the file:\n%s", got)
+	}
+}
+
+// TestDedupeValue checks that -dedupe=rule collapses the findings of a
+// rule, and that `-dedupe rule`, which would analyze a package named rule,
+// is rejected unless there is one.
+func TestDedupeValue(t *testing.T) {
+	const src = "package m\n\nimport \"os\"\n\nfunc f() {\n\t_ = os.Remove(\"a\")\n\t_ = os.Chdir(\"b\")\n}\n"
+	dir := writeModule(t, map[string]string{"a.go": src})
+	out, code := run(t, dir, "", "-dedupe=rule", "-only", "ignored-error", "-no-cache", "-no-summary", ".")
+	if code != 0 || !strings.Contains(out, "[one of 2 ignored-error findings]") || strings.Count(out, "(ignored-error)") != 1 {
+		t.Errorf("-dedupe=rule: exit status %d, output:\n%s", code, out)
+	}
+	if _, code := run(t, dir, "", "-dedupe", "rule", "-only", "ignored-error", "-no-cache", "-no-summary"); code != 2 {
+		t.Errorf("-dedupe rule: got exit status %d, want 2", code)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "rule"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "rule", "r.go"), []byte("package rule\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, code := run(t, dir, "", "-dedupe", "-no-cache", "-no-summary", "rule"); code == 2 {
+		t.Errorf("-dedupe rule with a rule directory was rejected")
+	}
+}
+
+// TestExitStatus checks the exit status documented in the README: 0 with
+// no findings at or above the -fail-on severity, 1 with one, and 2 when
+// the command can't run.
+func TestExitStatus(t *testing.T) {
+	const src = "package m\n\nimport \"os\"\n\nfunc f() {\n\t_ = os.Remove(\"a\")\n}\n"
+	dir := writeModule(t, map[string]string{"a.go": src, "broken/b.go": "package broken\n\nfunc f() { undefined() }\n"})
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"findings without -fail-on", []string{"-only", "ignored-error", "."}, 0},
+		{"no findings", []string{"-only", "nil-deref", "-fail-on", "note", "."}, 0},
+		{"findings below -fail-on", []string{"-only", "ignored-error", "-fail-on", "error", "."}, 0},
+		{"findings at -fail-on", []string{"-only", "ignored-error", "-fail-on", "warning", "."}, 1},
+		{"findings above -fail-on", []string{"-only", "ignored-error", "-fail-on", "note", "."}, 1},
+		{"unknown flag", []string{"-no-such-flag", "."}, 2},
+		{"unknown severity", []string{"-fail-on", "fatal", "."}, 2},
+		{"unknown rule", []string{"-only", "no-such-rule", "."}, 2},
+		{"no arguments", nil, 2},
+		{"package does not compile", []string{"-fail-on", "note", "./broken"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, code := run(t, dir, "", append([]string{"-no-cache", "-no-summary"}, tt.args...)...); code != tt.want {
+				t.Errorf("got exit status %d, want %d", code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package codecheck
+
+import (
+	"bytes"
+	"go/token"
+	"os"
+	"unicode/utf8"
+)
+
+// ExpandTabs rewrites the columns of findings and their related locations
+// for editors that expand tabs: instead of counting bytes from 1, as
+// token.Position does, each column counts characters from 1, with a tab
+// advancing to the next multiple of tabWidth. The findings' files are read
+// to do so, except those in sources, which holds the contents of files
+// analyzed from memory, as with AnalyzeSource, by the names their findings
+// give them. A line ending in "\r\n" counts the same as one ending in
+// "\n", and a byte order mark at the start of a file takes up no column.
+// Columns of files that can't be read are left as they are.
+func ExpandTabs(findings []Finding, tabWidth int, sources map[string][]byte) {
+	if tabWidth < 1 {
+		tabWidth = 1
+	}
+	files := map[string][]byte{}
+	for name, src := range sources {
+		files[name] = src
+	}
+	expand := func(p *token.Position) {
+		if p.Filename == "" || p.Line < 1 || p.Column < 1 {
+			return
+		}
+		src, ok := files[p.Filename]
+		if !ok {
+			src, _ = os.ReadFile(p.Filename)
+			files[p.Filename] = src
+		}
+		line := nthLine(src, p.Line)
+		if p.Line == 1 && bytes.HasPrefix(line, []byte("\xef\xbb\xbf")) {
+			// Positions count the mark, editors don't show it.
+			if p.Column <= 3 {
+				return
+			}
+			line, p.Column = line[3:], p.Column-3
+		}
+		if line == nil {
+			return
+		}
+		// An end position may point past the line, at its "\r\n".
+		past := max(p.Column-1-len(line), 0)
+		col := 0
+		for prefix := line[:p.Column-1-past]; len(prefix) > 0; {
+			r, size := utf8.DecodeRune(prefix)
+			if r == '\t' {
+				col += tabWidth - col%tabWidth
+			} else {
+				col++
+			}
+			prefix = prefix[size:]
+		}
+		p.Column = col + past + 1
+	}
+	for i := range findings {
+		f := &findings[i]
+		expand(&f.Position)
+		if f.End.IsValid() {
+			expand(&f.End)
+		}
+		for j := range f.Related {
+			expand(&f.Related[j].Position)
+		}
+	}
+}
+
+// nthLine returns line n of src, counting from 1, without its line ending,
+// or nil if src has fewer lines.
+func nthLine(src []byte, n int) []byte {
+	for ; n > 1; n-- {
+		i := bytes.IndexByte(src, '\n')
+		if i < 0 {
+			return nil
+		}
+		src = src[i+1:]
+	}
+	if i := bytes.IndexByte(src, '\n'); i >= 0 {
+		src = src[:i]
+	}
+	return bytes.TrimSuffix(src, []byte("\r"))
+}
@@ -0,0 +1,38 @@
+package codecheck
+
+import "testing"
+
+// TestExpandTabs checks the columns reported for a file indented with tabs
+// and with "\r\n" line endings, for tab widths of 1 and 8.
+func TestExpandTabs(t *testing.T) {
+	cfg := &Config{}
+	if err := cfg.SelectRules([]string{"ignored-error"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	type pos struct{ line, col, endCol int }
+	for _, tc := range []struct {
+		tabWidth int
+		want     []pos
+	}{
+		{1, []pos{{7, 3, 4}, {9, 12, 13}}},
+		{8, []pos{{7, 17, 18}, {9, 22, 23}}},
+	} {
+		findings, err := New(Options{Config: cfg}).AnalyzeFile("testdata/columns/crlf.go")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ExpandTabs(findings, tc.tabWidth, nil)
+		var got []pos
+		for _, f := range findings {
+			got = append(got, pos{f.Position.Line, f.Position.Column, f.End.Column})
+		}
+		if len(got) != len(tc.want) {
+			t.Fatalf("tab width %d: got findings at %v, want %v", tc.tabWidth, got, tc.want)
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("tab width %d: finding %d at %v, want %v", tc.tabWidth, i, got[i], tc.want[i])
+			}
+		}
+	}
+}
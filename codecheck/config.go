@@ -0,0 +1,206 @@
+package codecheck
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile is the configuration file the command looks for in the
+// current directory.
+const ConfigFile = ".codecheck.yaml"
+
+// Config is the contents of a configuration file, for example:
+//
+//	rules:
+//	  sql-injection: {severity: error, enabled: true}
+//	  ignored-error: {enabled: false}
+//	printf-funcs:
+//	  - example.com/log.Infof
+type Config struct {
+	Rules map[string]RuleConfig `yaml:"rules"`
+	// PrintfFuncs lists further printf-like functions for the printf
+	// rule; see PrintfDetector.Funcs.
+	PrintfFuncs []string `yaml:"printf-funcs,omitempty"`
+}
+
+// RuleConfig configures a single rule. Unset fields keep the rule's
+// default: enabled, and reporting each finding at the severity its detector
+// chose. Setting Severity reports every finding of the rule at that
+// severity.
+type RuleConfig struct {
+	Enabled  *bool     `yaml:"enabled,omitempty"`
+	Severity *Severity `yaml:"severity,omitempty"`
+}
+
+// LoadConfig reads and validates a configuration file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := ParseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// ParseConfig parses and validates configuration file contents. Unknown
+// keys and rule ids are errors.
+func ParseConfig(data []byte) (*Config, error) {
+	cfg := &Config{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	ids := make([]string, 0, len(cfg.Rules))
+	for id := range cfg.Rules {
+		ids = append(ids, id)
+	}
+	if err := checkRules(ids); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// ruleIDs returns the ids of the rules a configuration can set: those of
+// the built-in and registered detectors, followed by UnusedIgnoreRule.
+func ruleIDs() []string {
+	var ids []string
+	for _, d := range allDetectors(Options{}) {
+		ids = append(ids, d.Name())
+	}
+	return append(ids, UnusedIgnoreRule)
+}
+
+// checkRules returns an error listing the known rules if any of ids is not
+// one of ruleIDs.
+func checkRules(ids []string) error {
+	known := map[string]bool{}
+	all := ruleIDs()
+	for _, id := range all {
+		known[id] = true
+	}
+	sort.Strings(all)
+	var unknown []string
+	for _, id := range ids {
+		if !known[id] && !slices.Contains(unknown, id) {
+			unknown = append(unknown, id)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown rule %s (known rules: %s)", strings.Join(quoteAll(unknown), ", "), strings.Join(all, ", "))
+	}
+	return nil
+}
+
+// SelectRules overrides the enabled flags of the configuration, as the
+// command's -only and -skip flags do. If only is not empty, the rules in
+// it are enabled, opt-in rules included, and every other rule is
+// disabled, whatever the configuration said; the rules in skip are then
+// disabled. Severities are left as configured. Unknown rule ids are
+// errors, and c is left unchanged.
+func (c *Config) SelectRules(only, skip []string) error {
+	if err := checkRules(append(slices.Clone(only), skip...)); err != nil {
+		return err
+	}
+	if c.Rules == nil {
+		c.Rules = map[string]RuleConfig{}
+	}
+	set := func(id string, enabled bool) {
+		rc := c.Rules[id]
+		rc.Enabled = &enabled
+		c.Rules[id] = rc
+	}
+	if len(only) > 0 {
+		for _, id := range ruleIDs() {
+			set(id, slices.Contains(only, id))
+		}
+	}
+	for _, id := range skip {
+		set(id, false)
+	}
+	return nil
+}
+
+// WriteConfigTemplate writes a configuration file listing every rule, built
+// in or registered, with a comment describing it and its default severity,
+// and its default enabled flag, as a starting point for a project's
+// configuration. The severities are commented out, as setting one makes
+// every finding of the rule take it.
+func WriteConfigTemplate(w io.Writer) error {
+	var b bytes.Buffer
+	b.WriteString(`# codecheck configuration. Every rule is listed with its default settings.
+# Set enabled: false to turn a rule off, or uncomment its severity and set it
+# to error, warning or note to report all of the rule's findings at that
+# level; left unset, each finding keeps the severity its detector chose.
+rules:
+`)
+	defaults := resolveConfig(allDetectors(Options{}), nil)
+	for _, r := range KnownRules() {
+		fmt.Fprintf(&b, "  # %s.\n", r.Description)
+		fmt.Fprintf(&b, "  %s:\n    enabled: %t\n    # severity: %s\n", r.ID, *defaults.Rules[r.ID].Enabled, r.DefaultSeverity)
+	}
+	b.WriteString(`
+# Further printf-like functions for the printf rule to check, by full name.
+# printf-funcs:
+#   - example.com/log.Infof
+`)
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func quoteAll(ss []string) []string {
+	q := make([]string, len(ss))
+	for i, s := range ss {
+		q[i] = fmt.Sprintf("%q", s)
+	}
+	return q
+}
+
+// resolveConfig merges cfg over the defaults of the given detectors.
+func resolveConfig(detectors []Detector, cfg *Config) Config {
+	resolved := Config{Rules: map[string]RuleConfig{}}
+	resolve := func(id string, enabled bool, sev Severity) {
+		if cfg != nil {
+			if c, ok := cfg.Rules[id]; ok {
+				if c.Enabled != nil {
+					enabled = *c.Enabled
+				}
+				if c.Severity != nil {
+					sev = *c.Severity
+				}
+			}
+		}
+		resolved.Rules[id] = RuleConfig{Enabled: &enabled, Severity: &sev}
+	}
+	for _, d := range detectors {
+		o, ok := d.(OptIn)
+		resolve(d.Name(), !ok || !o.OptIn(), ruleOf(d).DefaultSeverity)
+	}
+	resolve(UnusedIgnoreRule, true, unusedIgnoreRule.DefaultSeverity)
+	return resolved
+}
+
+// severityOverride returns the severity the configuration file sets for a
+// rule, if any.
+func (a *Analyzer) severityOverride(rule string) (Severity, bool) {
+	if a.opts.Config == nil {
+		return 0, false
+	}
+	c, ok := a.opts.Config.Rules[rule]
+	if !ok || c.Severity == nil {
+		return 0, false
+	}
+	return *c.Severity, true
+}
@@ -0,0 +1,113 @@
+package codecheck
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const selectConfig = `rules:
+  printf:
+    enabled: false
+    severity: note
+  shadow:
+    enabled: true
+`
+
+// TestSelectRules checks that -only and -skip take precedence over the
+// configuration file and the rules' defaults, opt-in rules included, and
+// leave the configured severities alone.
+func TestSelectRules(t *testing.T) {
+	tests := []struct {
+		name       string
+		only, skip []string
+		enabled    []string
+		disabled   []string
+	}{
+		{
+			name:     "neither",
+			enabled:  []string{"shadow", "nil-deref"},
+			disabled: []string{"printf", "context-propagation"},
+		},
+		{
+			name:     "only",
+			only:     []string{"printf", "context-propagation"},
+			enabled:  []string{"printf", "context-propagation"},
+			disabled: []string{"shadow", "nil-deref", UnusedIgnoreRule},
+		},
+		{
+			name:     "skip",
+			skip:     []string{"shadow", "nil-deref"},
+			enabled:  []string{UnusedIgnoreRule, "sql-injection"},
+			disabled: []string{"shadow", "nil-deref", "printf", "context-propagation"},
+		},
+		{
+			name:     "skip overrides only",
+			only:     []string{"printf", "shadow"},
+			skip:     []string{"shadow"},
+			enabled:  []string{"printf"},
+			disabled: []string{"shadow", "nil-deref"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseConfig([]byte(selectConfig))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := cfg.SelectRules(tt.only, tt.skip); err != nil {
+				t.Fatal(err)
+			}
+			resolved := resolveConfig(allDetectors(Options{}), cfg)
+			for _, id := range tt.enabled {
+				if !*resolved.Rules[id].Enabled {
+					t.Errorf("%s is disabled, want enabled", id)
+				}
+			}
+			for _, id := range tt.disabled {
+				if *resolved.Rules[id].Enabled {
+					t.Errorf("%s is enabled, want disabled", id)
+				}
+			}
+			if sev := *resolved.Rules["printf"].Severity; sev != SeverityNote {
+				t.Errorf("printf severity is %s, want the configured note", sev)
+			}
+		})
+	}
+}
+
+// TestSelectRulesUnknown checks that unknown rules in -only or -skip are
+// an error naming them and listing the known rules, and that the
+// configuration is left as it was.
+func TestSelectRulesUnknown(t *testing.T) {
+	tests := []struct {
+		name       string
+		only, skip []string
+		want       string
+	}{
+		{"only", []string{"printf", "no-such-rule"}, nil, `unknown rule "no-such-rule" (known rules: `},
+		{"skip", nil, []string{"nil-deref", "no-such-rule"}, `unknown rule "no-such-rule" (known rules: `},
+		{"both", []string{"zz-rule"}, []string{"aa-rule", "zz-rule"}, `unknown rule "aa-rule", "zz-rule" (known rules: `},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseConfig([]byte(selectConfig))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ := ParseConfig([]byte(selectConfig))
+			err = cfg.SelectRules(tt.only, tt.skip)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Fatalf("got error %v, want one starting %q", err, tt.want)
+			}
+			for _, id := range ruleIDs() {
+				if !strings.Contains(err.Error(), id) {
+					t.Errorf("error %q does not list known rule %s", err, id)
+				}
+			}
+			if !reflect.DeepEqual(cfg, want) {
+				t.Errorf("SelectRules changed the configuration to %+v", cfg.Rules)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// ContextFirstDetector reports functions, methods and interface methods
+// that take a context.Context other than as their first parameter. By
+// convention the context comes first, named ctx, so that it is easy to
+// spot and to thread through; parameters of type *testing.T, *testing.B,
+// *testing.F or testing.TB may precede it, as test helpers put those first.
+// A method named like a method of an interface type the package uses may
+// have its parameter order fixed by that interface, so it is reported at
+// low confidence.
+type ContextFirstDetector struct{}
+
+func (ContextFirstDetector) Name() string { return "context-first" }
+
+func (ContextFirstDetector) Description() string {
+	return "context.Context parameter that is not the first parameter"
+}
+
+func (ContextFirstDetector) DefaultSeverity() Severity { return SeverityNote }
+
+func (ContextFirstDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: "Go code passes the context.Context of a call as the first parameter, conventionally named ctx. Keeping it first everywhere makes it obvious which functions can be cancelled or carry request values, and makes forgetting to pass it along stand out.",
+		Example:   `func (s *Store) Get(key string, ctx context.Context) (Item, error)`,
+		Fix:       `func (s *Store) Get(ctx context.Context, key string) (Item, error)`,
+	}
+}
+
+func (d ContextFirstDetector) Check(ctx *Context) []Finding {
+	ifaceMethods := map[string]bool{}
+	for _, tv := range ctx.Info.Types {
+		if tv.Type == nil {
+			continue
+		}
+		if it, ok := tv.Type.Underlying().(*types.Interface); ok {
+			for i := range it.NumMethods() {
+				ifaceMethods[it.Method(i).Name()] = true
+			}
+		}
+	}
+
+	var findings []Finding
+	check := func(name string, ft *ast.FuncType, method bool) {
+		if ft.Params == nil {
+			return
+		}
+		for i, field := range ft.Params.List {
+			if !isContext(ctx.Info.TypeOf(field.Type)) {
+				continue
+			}
+			first := true
+			for _, f := range ft.Params.List[:i] {
+				first = first && isTestingParam(ctx.Info.TypeOf(f.Type))
+			}
+			if first {
+				return
+			}
+			n := 1
+			for _, f := range ft.Params.List[:i] {
+				n += max(len(f.Names), 1)
+			}
+			var at ast.Node = field.Type
+			param := "the context.Context"
+			if len(field.Names) > 0 {
+				at, param = field.Names[0], "`"+field.Names[0].Name+"`"
+			}
+			fd := ctx.NewFinding(d.Name(), SeverityNote, at,
+				"%s is parameter %d of `%s`; a context.Context should be the first parameter",
+				param, n, name)
+			if method && ifaceMethods[name] {
+				// An interface may dictate the order.
+				fd.Confidence = ConfidenceLow
+			}
+			fields := []string{ctx.sourceText(field)}
+			for j, f := range ft.Params.List {
+				if j != i {
+					fields = append(fields, ctx.sourceText(f))
+				}
+			}
+			fd.Suggestion = "move it to the front, as in `" + name + "(" + strings.Join(fields, ", ") + ")`, and update the callers"
+			findings = append(findings, fd)
+			return
+		}
+	}
+	for _, f := range ctx.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				check(n.Name.Name, n.Type, n.Recv != nil)
+			case *ast.InterfaceType:
+				for _, m := range n.Methods.List {
+					if ft, ok := m.Type.(*ast.FuncType); ok && len(m.Names) > 0 {
+						check(m.Names[0].Name, ft, false)
+					}
+				}
+			}
+			return true
+		})
+	}
+	return findings
+}
+
+// isContext reports whether t is context.Context.
+func isContext(t types.Type) bool {
+	named, ok := types.Unalias(t).(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "context" && named.Obj().Name() == "Context"
+}
+
+// isTestingParam reports whether t is *testing.T, *testing.B, *testing.F
+// or testing.TB.
+func isTestingParam(t types.Type) bool {
+	t = types.Unalias(t)
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || named.Obj().Pkg().Path() != "testing" {
+		return false
+	}
+	switch named.Obj().Name() {
+	case "T", "B", "F", "TB":
+		return true
+	}
+	return false
+}
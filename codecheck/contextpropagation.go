@@ -0,0 +1,132 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// ContextPropagationDetector reports context.Background() and
+// context.TODO() passed to a call where a context was available to pass
+// instead: a context.Context variable in scope, such as the function's ctx
+// parameter, or the Context method of an *http.Request in scope. The call
+// then outlives cancellation and loses the deadline and values of the
+// context it was meant to run under. Detaching from the caller's context is
+// sometimes deliberate, for work that must finish regardless, so findings
+// are of low confidence, medium for context.TODO() which marks a context
+// yet to be plumbed through, and the rule only runs when the configuration
+// enables it.
+type ContextPropagationDetector struct{}
+
+func (ContextPropagationDetector) Name() string { return "context-propagation" }
+
+func (ContextPropagationDetector) Description() string {
+	return "context.Background() or context.TODO() passed where a context is available"
+}
+
+func (ContextPropagationDetector) DefaultSeverity() Severity { return SeverityNote }
+
+func (ContextPropagationDetector) OptIn() bool { return true }
+
+func (ContextPropagationDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: "A context carries the cancellation, deadline and values of the request a function works for. Passing context.Background() or context.TODO() to a call where the function has a context of its own cuts the call off from it: it keeps running after the request is cancelled or times out, and loses request-scoped values such as trace ids.",
+		Example: `func (s *Store) Get(ctx context.Context, key string) (Item, error) {
+	row := s.db.QueryRowContext(context.Background(), query, key)
+	...
+}`,
+		Fix: `func (s *Store) Get(ctx context.Context, key string) (Item, error) {
+	row := s.db.QueryRowContext(ctx, query, key)
+	...
+}`,
+	}
+}
+
+func (d ContextPropagationDetector) Check(ctx *Context) []Finding {
+	if ctx.Pkg == nil {
+		return nil
+	}
+	var findings []Finding
+	for _, f := range ctx.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			sig, ok := ctx.Info.TypeOf(call.Fun).(*types.Signature)
+			if !ok {
+				return true
+			}
+			for i, arg := range call.Args {
+				inner, ok := ast.Unparen(arg).(*ast.CallExpr)
+				if !ok || i >= sig.Params().Len() || !isContext(sig.Params().At(i).Type()) {
+					continue
+				}
+				fn := calleeFunc(ctx.Info, inner)
+				if fn == nil || fn.FullName() != "context.Background" && fn.FullName() != "context.TODO" {
+					continue
+				}
+				avail, from := availableContext(ctx, arg.Pos())
+				if avail == "" {
+					continue
+				}
+				fd := ctx.NewFinding(d.Name(), SeverityNote, arg,
+					"`%s` is passed to `%s` although `%s` is available here; the call is not cancelled with it and loses its deadline and values",
+					ctx.sourceText(arg), types.ExprString(call.Fun), avail)
+				fd.Confidence = ConfidenceLow
+				if fn.Name() == "TODO" {
+					fd.Confidence = ConfidenceMedium
+				}
+				fd.Related = append(fd.Related, Related{Position: ctx.Fset.Position(from.Pos()), Message: "`" + from.Name() + "` is declared here"})
+				fd.Suggestion = "pass `" + avail + "`, or a context derived from it; if the call must outlive it, use `context.WithoutCancel(" + avail + ")` to keep its values"
+				fd.Fix = &SuggestedFix{
+					Message: "pass " + avail,
+					Edits:   []TextEdit{ctx.edit(arg.Pos(), arg.End(), avail)},
+				}
+				findings = append(findings, fd)
+			}
+			return true
+		})
+	}
+	return findings
+}
+
+// availableContext returns an expression for a context available at pos,
+// a context.Context variable or `r.Context()` for an *http.Request r,
+// declared in the enclosing functions before pos, with the variable it
+// uses. It returns "" if there is none.
+func availableContext(ctx *Context, pos token.Pos) (string, *types.Var) {
+	var request *types.Var
+	for s := ctx.Pkg.Scope().Innermost(pos); s != nil && s != ctx.Pkg.Scope() && s.Parent() != ctx.Pkg.Scope(); s = s.Parent() {
+		for _, name := range s.Names() {
+			v, ok := s.Lookup(name).(*types.Var)
+			if !ok || name == "_" {
+				continue
+			}
+			if _, obj := s.LookupParent(name, pos); obj != v {
+				// Declared after pos, or shadowed.
+				continue
+			}
+			if isContext(v.Type()) {
+				return name, v
+			}
+			if request == nil && isHTTPRequest(v.Type()) {
+				request = v
+			}
+		}
+	}
+	if request != nil {
+		return request.Name() + ".Context()", request
+	}
+	return "", nil
+}
+
+// isHTTPRequest reports whether t is *net/http.Request.
+func isHTTPRequest(t types.Type) bool {
+	p, ok := t.(*types.Pointer)
+	if !ok {
+		return false
+	}
+	named, ok := types.Unalias(p.Elem()).(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "net/http" && named.Obj().Name() == "Request"
+}
@@ -0,0 +1,71 @@
+package codecheck
+
+import "fmt"
+
+// DedupeKey says which findings Dedupe collapses into one.
+type DedupeKey int
+
+const (
+	// DedupeByMessage collapses findings with the same rule and message.
+	DedupeByMessage DedupeKey = iota
+	// DedupeByRule collapses all findings of a rule.
+	DedupeByRule
+)
+
+// Dedupe collapses each group of findings with the same key into the first
+// of them, so that a pattern repeated across a code base is reported once.
+// The finding kept gets the highest severity and confidence in its group,
+// so that a -fail-on threshold still trips on the group's worst, and a
+// message saying how many findings it stands for; the first shown of the
+// others are added to its Related locations, and all of them are kept in
+// its Duplicates. Groups of one finding are left as they are. findings
+// must be sorted, as the Analyzer returns them; the result is in the order
+// of each group's first finding.
+func Dedupe(findings []Finding, key DedupeKey, shown int) []Finding {
+	groupKey := func(f Finding) string {
+		if key == DedupeByRule {
+			return f.Rule
+		}
+		return f.Rule + "\n" + f.Message
+	}
+	index := map[string]int{}
+	var out []Finding
+	for _, f := range findings {
+		k := groupKey(f)
+		i, ok := index[k]
+		if !ok {
+			index[k] = len(out)
+			out = append(out, f)
+			continue
+		}
+		out[i].Duplicates = append(out[i].Duplicates, f)
+	}
+	for i := range out {
+		f := &out[i]
+		if len(f.Duplicates) == 0 {
+			continue
+		}
+		n := len(f.Duplicates) + 1
+		for j, d := range f.Duplicates {
+			f.Severity = max(f.Severity, d.Severity)
+			f.Confidence = max(f.Confidence, d.Confidence)
+			if j >= shown {
+				continue
+			}
+			msg := "also reported here"
+			if d.Message != f.Message {
+				msg = "also: " + d.Message
+			}
+			f.Related = append(f.Related, Related{Position: d.Position, Message: msg})
+		}
+		count := fmt.Sprintf("reported %d times", n)
+		if key == DedupeByRule {
+			count = fmt.Sprintf("one of %d %s findings", n, f.Rule)
+		}
+		if hidden := n - 1 - shown; hidden > 0 {
+			count += fmt.Sprintf(", %d more not shown", hidden)
+		}
+		f.Message += " [" + count + "]"
+	}
+	return out
+}
@@ -0,0 +1,113 @@
+package codecheck
+
+import (
+	"go/token"
+	"testing"
+)
+
+// TestDedupe checks the counts, related locations, severity and confidence
+// of collapsed findings, by message and by rule, with -dedupe-locations
+// capping the locations listed.
+func TestDedupe(t *testing.T) {
+	at := func(line int) token.Position { return token.Position{Filename: "a.go", Line: line, Column: 1} }
+	finding := func(line int, rule, msg string, sev Severity, conf Confidence) Finding {
+		return Finding{Rule: rule, Message: msg, Severity: sev, Confidence: conf, Position: at(line), End: at(line)}
+	}
+	findings := []Finding{
+		finding(1, "ignored-error", "error discarded", SeverityNote, ConfidenceLow),
+		finding(2, "ignored-error", "error discarded", SeverityWarning, ConfidenceMedium),
+		finding(3, "printf", "bad verb", SeverityWarning, ConfidenceHigh),
+		finding(4, "ignored-error", "other error discarded", SeverityError, ConfidenceLow),
+		finding(5, "ignored-error", "error discarded", SeverityNote, ConfidenceLow),
+	}
+	type want struct {
+		message    string
+		line       int
+		severity   Severity
+		confidence Confidence
+		related    []Related
+		duplicates int
+	}
+	tests := []struct {
+		name  string
+		key   DedupeKey
+		shown int
+		want  []want
+	}{
+		{
+			name:  "message",
+			key:   DedupeByMessage,
+			shown: 5,
+			want: []want{
+				{"error discarded [reported 3 times]", 1, SeverityWarning, ConfidenceMedium,
+					[]Related{{at(2), "also reported here"}, {at(5), "also reported here"}}, 2},
+				{"bad verb", 3, SeverityWarning, ConfidenceHigh, nil, 0},
+				{"other error discarded", 4, SeverityError, ConfidenceLow, nil, 0},
+			},
+		},
+		{
+			name:  "rule",
+			key:   DedupeByRule,
+			shown: 5,
+			want: []want{
+				{"error discarded [one of 4 ignored-error findings]", 1, SeverityError, ConfidenceMedium,
+					[]Related{{at(2), "also reported here"}, {at(4), "also: other error discarded"}, {at(5), "also reported here"}}, 3},
+				{"bad verb", 3, SeverityWarning, ConfidenceHigh, nil, 0},
+			},
+		},
+		{
+			name:  "locations cap",
+			key:   DedupeByRule,
+			shown: 1,
+			want: []want{
+				{"error discarded [one of 4 ignored-error findings, 2 more not shown]", 1, SeverityError, ConfidenceMedium,
+					[]Related{{at(2), "also reported here"}}, 3},
+				{"bad verb", 3, SeverityWarning, ConfidenceHigh, nil, 0},
+			},
+		},
+		{
+			name:  "no locations",
+			key:   DedupeByMessage,
+			shown: 0,
+			want: []want{
+				{"error discarded [reported 3 times, 2 more not shown]", 1, SeverityWarning, ConfidenceMedium, nil, 2},
+				{"bad verb", 3, SeverityWarning, ConfidenceHigh, nil, 0},
+				{"other error discarded", 4, SeverityError, ConfidenceLow, nil, 0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := append([]Finding(nil), findings...)
+			got := Dedupe(in, tt.key, tt.shown)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d findings, want %d: %v", len(got), len(tt.want), got)
+			}
+			for i, w := range tt.want {
+				g := got[i]
+				if g.Message != w.message || g.Position.Line != w.line || g.Severity != w.severity || g.Confidence != w.confidence {
+					t.Errorf("finding %d: got %q at line %d, %s, %s confidence; want %q at line %d, %s, %s confidence",
+						i, g.Message, g.Position.Line, g.Severity, g.Confidence, w.message, w.line, w.severity, w.confidence)
+				}
+				if len(g.Related) != len(w.related) {
+					t.Errorf("finding %d: got related %v, want %v", i, g.Related, w.related)
+				} else {
+					for j := range w.related {
+						if g.Related[j] != w.related[j] {
+							t.Errorf("finding %d: got related %v, want %v", i, g.Related, w.related)
+							break
+						}
+					}
+				}
+				if len(g.Duplicates) != w.duplicates {
+					t.Errorf("finding %d: got %d duplicates, want %d", i, len(g.Duplicates), w.duplicates)
+				}
+			}
+			for i := range findings {
+				if in[i].Message != findings[i].Message {
+					t.Errorf("Dedupe changed its input: finding %d is now %q", i, in[i].Message)
+				}
+			}
+		})
+	}
+}
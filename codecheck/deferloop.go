@@ -0,0 +1,100 @@
+package codecheck
+
+import "go/ast"
+
+// DeferInLoopDetector reports defer statements in loops. A deferred call
+// runs when the enclosing function returns, not at the end of the
+// iteration, so `defer rows.Close()` in a loop keeps every iteration's
+// rows open until the loop and the rest of the function are done. Defers
+// in a function literal called in the loop run each time it returns and are
+// not reported. Loops with a constant number of iterations, such as over an
+// array or up to a constant, are reported as notes.
+type DeferInLoopDetector struct{}
+
+func (DeferInLoopDetector) Name() string { return "defer-in-loop" }
+
+func (DeferInLoopDetector) Description() string {
+	return "Defer in a loop, which runs only when the function returns"
+}
+
+func (DeferInLoopDetector) DefaultSeverity() Severity { return SeverityWarning }
+
+func (DeferInLoopDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: `Deferred calls run when the enclosing function returns, not at the end of the loop iteration. Deferring a Close in a loop keeps every file or row set the loop opened open until the function is done, which can exhaust file descriptors or connections on long inputs.`,
+		Example: `for _, path := range paths {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	process(f)
+}`,
+		Fix: `for _, path := range paths {
+	err := func() error {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return process(f)
+	}()
+	if err != nil {
+		return err
+	}
+}`,
+	}
+}
+
+func (d DeferInLoopDetector) Check(ctx *Context) []Finding {
+	var findings []Finding
+	for _, f := range ctx.Files {
+		inspectStack(f, func(n ast.Node, stack []ast.Node) bool {
+			def, ok := n.(*ast.DeferStmt)
+			if !ok {
+				return true
+			}
+			var loop ast.Stmt
+			fn := "the function"
+		walk:
+			for i := len(stack) - 2; i >= 0; i-- {
+				switch s := stack[i].(type) {
+				case *ast.FuncLit:
+					break walk
+				case *ast.FuncDecl:
+					fn = "`" + s.Name.Name + "`"
+					break walk
+				case *ast.ForStmt, *ast.RangeStmt:
+					if loop == nil {
+						loop = s.(ast.Stmt)
+					}
+				}
+			}
+			if loop == nil {
+				return true
+			}
+			sev := SeverityWarning
+			bounded := false
+			switch l := loop.(type) {
+			case *ast.ForStmt:
+				bounded = constantTrips(ctx.Info, l)
+			case *ast.RangeStmt:
+				bounded = constantRange(ctx.Info, l)
+			}
+			if bounded {
+				sev = SeverityNote
+			}
+			header := loopHeader(ctx, loop)
+			f := ctx.NewFinding(d.Name(), sev, def,
+				"`defer %s` runs only when %s returns, not at the end of each iteration of `%s`, so the deferred calls and what they hold pile up until then",
+				ctx.sourceText(def.Call), fn, header)
+			if p := ctx.Fset.Position(loop.Pos()); p.Line != ctx.Fset.Position(def.Pos()).Line {
+				f.Related = append(f.Related, Related{Position: p, Message: "the loop: `" + header + "`"})
+			}
+			f.Suggestion = "move the loop body into a function literal called on each iteration, `func() { ...; defer " + ctx.sourceText(def.Call) + "; ... }()`, or call `" + ctx.sourceText(def.Call) + "` explicitly at the end of each iteration"
+			findings = append(findings, f)
+			return true
+		})
+	}
+	return findings
+}
@@ -0,0 +1,211 @@
+package codecheck
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Diff records which lines of which files a unified diff, such as the output
+// of git diff, adds or changes. Lines are those of the new revision of each
+// file, and paths those of the new revision too, as the diff names them
+// relative to the repository root: a renamed file is known by its new name.
+type Diff struct {
+	files map[string]*diffFile
+}
+
+type diffFile struct {
+	// added holds the lines the diff adds or changes.
+	added map[int]bool
+	// hunks are the ranges of lines the hunks show, context included: the
+	// lines a review comment can be attached to.
+	hunks [][2]int
+}
+
+// ParseDiff reads a unified diff.
+func ParseDiff(r io.Reader) (*Diff, error) {
+	d := &Diff{files: map[string]*diffFile{}}
+	var file *diffFile
+	var line, oldLeft, newLeft int
+	// git diffs name files a/old and b/new; other diffs name them as given.
+	git := false
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		text := sc.Text()
+		if oldLeft > 0 || newLeft > 0 {
+			// Inside a hunk; its header says how many lines follow.
+			switch {
+			case strings.HasPrefix(text, "+"):
+				file.added[line] = true
+				line++
+				newLeft--
+			case strings.HasPrefix(text, "-"):
+				oldLeft--
+			case strings.HasPrefix(text, " "), text == "":
+				line++
+				oldLeft--
+				newLeft--
+			case strings.HasPrefix(text, `\`):
+				// "\ No newline at end of file"
+			default:
+				return nil, fmt.Errorf("diff line %d: hunk ends early", n)
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(text, "diff --git "):
+			git = true
+			file = nil
+		case strings.HasPrefix(text, "+++ "):
+			name, err := diffFileName(strings.TrimPrefix(text, "+++ "))
+			if err != nil {
+				return nil, fmt.Errorf("diff line %d: %v", n, err)
+			}
+			// A deleted file's hunks are read into a file that isn't
+			// recorded.
+			file = &diffFile{added: map[int]bool{}}
+			if name != "/dev/null" {
+				if git {
+					name = strings.TrimPrefix(name, "b/")
+				}
+				d.files[name] = file
+			}
+		case strings.HasPrefix(text, "@@ "):
+			if file == nil {
+				return nil, fmt.Errorf("diff line %d: hunk outside a file", n)
+			}
+			var newStart int
+			var err error
+			oldLeft, newStart, newLeft, err = parseHunkHeader(text)
+			if err != nil {
+				return nil, fmt.Errorf("diff line %d: %v", n, err)
+			}
+			line = newStart
+			if newLeft > 0 {
+				file.hunks = append(file.hunks, [2]int{newStart, newStart + newLeft - 1})
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// diffFileName returns the file name in the rest of a "+++ " line: either
+// a name followed by an optional tab and timestamp, or a name quoted the way
+// git quotes names with unusual characters.
+func diffFileName(s string) (string, error) {
+	if strings.HasPrefix(s, `"`) {
+		end := 1
+		for end < len(s) && s[end] != '"' {
+			if s[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end >= len(s) {
+			return "", fmt.Errorf("unterminated file name %s", s)
+		}
+		name, err := strconv.Unquote(s[:end+1])
+		if err != nil {
+			return "", fmt.Errorf("malformed file name %s", s[:end+1])
+		}
+		return name, nil
+	}
+	if i := strings.IndexByte(s, '\t'); i >= 0 {
+		s = s[:i]
+	}
+	return s, nil
+}
+
+// parseHunkHeader parses "@@ -l,s +l,s @@", where a missing ",s" means 1,
+// and returns the number of old lines and the start and number of new ones.
+func parseHunkHeader(text string) (oldLines, newStart, newLines int, err error) {
+	fields := strings.Fields(text)
+	if len(fields) < 4 || fields[3] != "@@" || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0, 0, fmt.Errorf("malformed hunk header %q", text)
+	}
+	rng := func(s string) (start, lines int, err error) {
+		lines = 1
+		if i := strings.IndexByte(s, ','); i >= 0 {
+			if lines, err = strconv.Atoi(s[i+1:]); err != nil {
+				return 0, 0, err
+			}
+			s = s[:i]
+		}
+		start, err = strconv.Atoi(s)
+		return start, lines, err
+	}
+	if _, oldLines, err = rng(fields[1][1:]); err == nil {
+		newStart, newLines, err = rng(fields[2][1:])
+	}
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("malformed hunk header %q", text)
+	}
+	return oldLines, newStart, newLines, nil
+}
+
+// Changed reports whether the diff adds or changes any of the lines start
+// through end of path, named relative to the repository root.
+func (d *Diff) Changed(path string, start, end int) bool {
+	f := d.files[filepath.ToSlash(path)]
+	if f == nil {
+		return false
+	}
+	for line := start; line <= end; line++ {
+		if f.added[line] {
+			return true
+		}
+	}
+	return false
+}
+
+// InHunk reports whether line of path is shown by one of the diff's hunks,
+// changed or not.
+func (d *Diff) InHunk(path string, line int) bool {
+	f := d.files[filepath.ToSlash(path)]
+	if f == nil {
+		return false
+	}
+	for _, h := range f.hunks {
+		if h[0] <= line && line <= h[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the findings spanning a line the diff adds or changes.
+// root is the repository root the diff's paths are relative to.
+func (d *Diff) Filter(findings []Finding, root string) []Finding {
+	var out []Finding
+	for _, f := range findings {
+		end := max(f.End.Line, f.Position.Line)
+		if path, ok := repoPath(root, f.Position.Filename); ok && d.Changed(path, f.Position.Line, end) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// repoPath returns filename relative to the repository root, with forward
+// slashes, or false if it is outside root.
+func repoPath(root, filename string) (string, bool) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return "", false
+	}
+	if root, err = filepath.Abs(root); err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
@@ -0,0 +1,191 @@
+package codecheck
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestParseDiff checks the lines ParseDiff records for each file, in the
+// new revision, for the file headers and hunks git and diff -u write.
+func TestParseDiff(t *testing.T) {
+	type file struct {
+		added []int
+		hunks [][2]int
+	}
+	for _, tc := range []struct {
+		name string
+		diff string
+		want map[string]file
+		err  string
+	}{
+		{
+			name: "modified",
+			diff: `diff --git a/a.go b/a.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/a.go
+@@ -2,3 +2,4 @@ package a
+ x
+-y
++y2
++z
+ w
+`,
+			want: map[string]file{"a.go": {added: []int{3, 4}, hunks: [][2]int{{2, 5}}}},
+		},
+		{
+			name: "multiple hunks",
+			diff: `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1,2 +1,3 @@
++// Package a.
+ package a
+ 
+@@ -10,3 +11,2 @@ func f() {
+ 	x()
+-	y()
+ }
+@@ -20 +20 @@
+-old
++new
+`,
+			want: map[string]file{"a.go": {added: []int{1, 20}, hunks: [][2]int{{1, 3}, {11, 12}, {20, 20}}}},
+		},
+		{
+			name: "renamed",
+			diff: `diff --git a/old/name.go b/new/name.go
+similarity index 90%
+rename from old/name.go
+rename to new/name.go
+--- a/old/name.go
++++ b/new/name.go
+@@ -5,2 +5,2 @@
+-a
++b
+ c
+`,
+			want: map[string]file{"new/name.go": {added: []int{5}, hunks: [][2]int{{5, 6}}}},
+		},
+		{
+			name: "renamed without changes",
+			diff: `diff --git a/old.go b/new.go
+similarity index 100%
+rename from old.go
+rename to new.go
+`,
+			want: map[string]file{},
+		},
+		{
+			name: "quoted names",
+			diff: `diff --git "a/sp ace.go" "b/sp ace\tt\303\251.go"
+--- "a/sp ace.go"
++++ "b/sp ace\tt\303\251.go"
+@@ -1 +1 @@
+-a
++b
+`,
+			want: map[string]file{"sp ace\tté.go": {added: []int{1}, hunks: [][2]int{{1, 1}}}},
+		},
+		{
+			name: "deleted and added",
+			diff: `diff --git a/gone.go b/gone.go
+deleted file mode 100644
+--- a/gone.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package gone
+-
+diff --git a/new.go b/new.go
+new file mode 100644
+--- /dev/null
++++ b/new.go
+@@ -0,0 +1,2 @@
++package new
++
+`,
+			want: map[string]file{"new.go": {added: []int{1, 2}, hunks: [][2]int{{1, 2}}}},
+		},
+		{
+			name: "diff -u",
+			diff: `--- a.go.orig	2024-01-01 10:00:00.000000000 +0000
++++ b/a.go	2024-01-01 10:01:00.000000000 +0000
+@@ -1,2 +1,2 @@
+ package a
+-var x = 1
++var x = 2
+\ No newline at end of file
+`,
+			want: map[string]file{"b/a.go": {added: []int{2}, hunks: [][2]int{{1, 2}}}},
+		},
+		{
+			name: "hunk outside a file",
+			diff: "@@ -1 +1 @@\n-a\n+b\n",
+			err:  "diff line 1: hunk outside a file",
+		},
+		{
+			name: "hunk ends early",
+			diff: "--- a/a.go\n+++ b/a.go\n@@ -1,3 +1,3 @@\n a\ndiff --git a/b.go b/b.go\n",
+			err:  "diff line 5: hunk ends early",
+		},
+		{
+			name: "malformed hunk header",
+			diff: "--- a/a.go\n+++ b/a.go\n@@ -1,x +1 @@\n",
+			err:  `diff line 3: malformed hunk header "@@ -1,x +1 @@"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := ParseDiff(strings.NewReader(tc.diff))
+			if tc.err != "" {
+				if err == nil || err.Error() != tc.err {
+					t.Fatalf("got error %v, want %s", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]file{}
+			for name, f := range d.files {
+				var added []int
+				for line := range f.added {
+					added = append(added, line)
+				}
+				sort.Ints(added)
+				got[name] = file{added: added, hunks: f.hunks}
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// TestDiffFilter checks that findings are kept when any line they span is
+// added or changed, by the path of the new revision relative to root.
+func TestDiffFilter(t *testing.T) {
+	d, err := ParseDiff(strings.NewReader("diff --git a/old.go b/pkg/new.go\n--- a/old.go\n+++ b/pkg/new.go\n@@ -1,3 +1,3 @@\n a\n-b\n+c\n d\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	finding := func(file string, line, end int) Finding {
+		f := Finding{Rule: "r"}
+		f.Position.Filename, f.Position.Line = file, line
+		f.End.Filename, f.End.Line = file, end
+		return f
+	}
+	findings := []Finding{
+		finding("/repo/pkg/new.go", 1, 1),
+		finding("/repo/pkg/new.go", 2, 2),
+		finding("/repo/pkg/new.go", 1, 3),
+		finding("/repo/pkg/new.go", 3, 3),
+		finding("/repo/old.go", 2, 2),
+		finding("/elsewhere/pkg/new.go", 2, 2),
+	}
+	got := d.Filter(findings, "/repo")
+	if want := []Finding{findings[1], findings[2]}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
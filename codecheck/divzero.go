@@ -0,0 +1,93 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// DivByZeroDetector reports integer `/` and `%` whose divisor can be zero.
+// Divisors are reduced to a constant or to a linear function of a single
+// len or cap call; since len is never negative, `len(s) - 5` is zero exactly
+// when len(s) is 5. Divisions dominated by a condition excluding that value
+// are not reported.
+type DivByZeroDetector struct{}
+
+func (DivByZeroDetector) Name() string { return "div-by-zero" }
+
+func (DivByZeroDetector) Description() string {
+	return "Integer division or modulo by a value that can be zero"
+}
+
+func (DivByZeroDetector) DefaultSeverity() Severity { return SeverityWarning }
+
+func (DivByZeroDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: "Integer division or modulo by zero panics at run time. Divisors derived from a length, such as `len(s)` or `len(s) - 1`, are zero for some input, typically an empty slice that the code was never tested with.",
+		Example:   `avg := total / len(scores)`,
+		Fix: `if len(scores) == 0 {
+	return 0
+}
+avg := total / len(scores)`,
+	}
+}
+
+func (d DivByZeroDetector) Check(ctx *Context) []Finding {
+	var findings []Finding
+	forEachFuncDecl(ctx, func(body *ast.BlockStmt) {
+		ar := newArith(ctx.Info, body)
+		inspectStack(body, func(n ast.Node, stack []ast.Node) bool {
+			var divisor ast.Expr
+			var result ast.Expr
+			switch n := n.(type) {
+			case *ast.BinaryExpr:
+				if n.Op == token.QUO || n.Op == token.REM {
+					divisor, result = n.Y, n
+				}
+			case *ast.AssignStmt:
+				if (n.Tok == token.QUO_ASSIGN || n.Tok == token.REM_ASSIGN) && len(n.Rhs) == 1 {
+					divisor, result = n.Rhs[0], n.Lhs[0]
+				}
+			}
+			if divisor == nil || !isIntegerExpr(ctx.Info, result) {
+				return true
+			}
+			l, ok := ar.linearize(divisor)
+			if !ok {
+				return true
+			}
+			expr := types.ExprString(divisor)
+			if l.atom == "" {
+				if l.k == 0 {
+					findings = append(findings, ctx.NewFinding(d.Name(), SeverityError, n,
+						"division by zero: divisor `%s` is always zero", expr))
+				}
+				return true
+			}
+			v, ok := l.solveZero()
+			if !ok || ar.guarded(stack, l.arg, l.atom, v) {
+				return true
+			}
+			f := ctx.NewFinding(d.Name(), SeverityWarning, n,
+				"possible division by zero: divisor `%s` is zero when %s is %d", expr, l.atom, v)
+			f.Confidence = ConfidenceMedium
+			if fact, ok := ctx.argFactFor(l.arg, func(length int64) bool { return length == v }); ok {
+				f.Severity = SeverityError
+				f.Confidence = ConfidenceHigh
+				f.Related = append(f.Related, fact.related(ctx, l.arg))
+			}
+			findings = append(findings, f)
+			return true
+		})
+	})
+	return findings
+}
+
+func isIntegerExpr(info *types.Info, e ast.Expr) bool {
+	t := info.TypeOf(e)
+	if t == nil {
+		return false
+	}
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Info()&types.IsInteger != 0
+}
@@ -0,0 +1,316 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// ErrorCompareDetector reports errors compared with == or != (or a
+// `switch err` case) where errors.Is is needed. Comparing against an error
+// made on the spot, as in `err == errors.New("not found")`, is always false,
+// since every call makes a new error; comparing against the result of
+// another call or a local variable is rarely what was meant either.
+// Comparing against a package-level sentinel such as io.EOF works only
+// while nothing wraps the error, so those comparisons are notes with an
+// errors.Is fix, except where the error comes straight from a Read method
+// or the standard library, which return io.EOF itself, and warnings for
+// the os and io/fs sentinels, which os functions return wrapped in a
+// *PathError. Comparisons with nil and those in an Is method, which
+// errors.Is calls to do the comparing, are left alone.
+type ErrorCompareDetector struct{}
+
+func (ErrorCompareDetector) Name() string { return "error-compare" }
+
+func (ErrorCompareDetector) Description() string {
+	return "Error compared with == instead of errors.Is"
+}
+
+func (ErrorCompareDetector) DefaultSeverity() Severity { return SeverityWarning }
+
+func (ErrorCompareDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: `== compares errors by identity. An error made on the spot with errors.New or fmt.Errorf equals no other error, and a sentinel such as io.EOF or os.ErrNotExist no longer matches once something wraps it, as os functions and fmt.Errorf with %w do. errors.Is unwraps the error chain before comparing.`,
+		Example: `if err == os.ErrNotExist {
+	return nil
+}`,
+		Fix: `if errors.Is(err, os.ErrNotExist) {
+	return nil
+}`,
+	}
+}
+
+func (d ErrorCompareDetector) Check(ctx *Context) []Finding {
+	var findings []Finding
+	for _, f := range ctx.Files {
+		inspectStack(f, func(n ast.Node, stack []ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				// Is methods implement the comparison errors.Is makes.
+				return n.Recv == nil || n.Name.Name != "Is"
+			case *ast.BinaryExpr:
+				if n.Op != token.EQL && n.Op != token.NEQ {
+					return true
+				}
+				if fd, ok := d.compare(ctx, f, n, n.X, n.Y, n.Op, stack); ok {
+					findings = append(findings, fd)
+				}
+			case *ast.SwitchStmt:
+				if n.Tag == nil {
+					return true
+				}
+				for _, stmt := range n.Body.List {
+					for _, e := range stmt.(*ast.CaseClause).List {
+						if fd, ok := d.compare(ctx, f, e, n.Tag, e, token.EQL, stack); ok {
+							findings = append(findings, fd)
+						}
+					}
+				}
+			}
+			return true
+		})
+	}
+	return findings
+}
+
+// compare checks the comparison `x op y`, spanning n, where n is the
+// BinaryExpr itself or a case of `switch x`.
+func (d ErrorCompareDetector) compare(ctx *Context, file *ast.File, n ast.Node, x, y ast.Expr, op token.Token, stack []ast.Node) (Finding, bool) {
+	if !isErrorValue(ctx.Info, x) || !isErrorValue(ctx.Info, y) {
+		return Finding{}, false
+	}
+	// err is the error being tested and target what it is tested against.
+	err, target := x, y
+	if errorTarget(ctx.Info, x) > errorTarget(ctx.Info, y) {
+		err, target = y, x
+	}
+	kind := errorTarget(ctx.Info, target)
+	if kind == targetNone {
+		return Finding{}, false
+	}
+	cmp := "`" + ctx.sourceText(n) + "`"
+	if _, ok := n.(*ast.BinaryExpr); !ok {
+		cmp = "`switch " + ctx.sourceText(x) + "` case `" + ctx.sourceText(n) + "`"
+	}
+	is := "errors.Is(" + ctx.sourceText(err) + ", " + ctx.sourceText(target) + ")"
+	never := "false"
+	if op == token.NEQ {
+		never = "true"
+	}
+
+	switch kind {
+	case targetNew:
+		f := ctx.NewFinding(d.Name(), SeverityError, n,
+			"%s is always %s: `%s` makes a new error, which no other error equals",
+			cmp, never, ctx.sourceText(target))
+		f.Suggestion = "declare the error once as a package-level variable, `var ErrX = " + ctx.sourceText(target) + "`, return that, and test for it with `errors.Is(" + ctx.sourceText(err) + ", ErrX)`"
+		return f, true
+	case targetValue:
+		f := ctx.NewFinding(d.Name(), SeverityWarning, n,
+			"%s compares errors by identity against `%s`, which is not a package-level sentinel error; errors with the same message are not equal, and a wrapped error never is",
+			cmp, ctx.sourceText(target))
+		f.Confidence = ConfidenceLow
+		if _, ok := ast.Unparen(target).(*ast.CallExpr); ok {
+			f.Confidence = ConfidenceMedium
+		}
+		f.Suggestion = "compare against a package-level sentinel with `errors.Is`, or match the error's type with `errors.As`"
+		return f, true
+	}
+
+	// target is a sentinel.
+	pkg := sentinelVar(ctx.Info, target).Pkg().Path()
+	if pkg == "io" && returnsSentinelItself(ctx, err, n, stack) {
+		return Finding{}, false
+	}
+	var f Finding
+	if pkg == "os" || pkg == "io/fs" {
+		f = ctx.NewFinding(d.Name(), SeverityWarning, n,
+			"%s misses `%s` wrapped in a *PathError, which is how os functions return it; use `%s`",
+			cmp, ctx.sourceText(target), is)
+	} else {
+		f = ctx.NewFinding(d.Name(), SeverityNote, n,
+			"%s misses `%s` if the error has been wrapped, as with fmt.Errorf and %%w; use `%s`",
+			cmp, ctx.sourceText(target), is)
+		f.Confidence = ConfidenceLow
+	}
+	f.Suggestion = "use `" + is + "`, which also matches errors wrapping it"
+	if _, ok := n.(*ast.BinaryExpr); ok && packageAt(ctx, file, n.Pos(), "errors") {
+		if op == token.NEQ {
+			is = "!" + is
+		}
+		f.Fix = &SuggestedFix{
+			Message: "use " + is,
+			Safe:    true,
+			Edits:   []TextEdit{ctx.edit(n.Pos(), n.End(), is)},
+		}
+	}
+	return f, true
+}
+
+// Kinds of error an error is compared against, from least to most likely
+// to be the target of the comparison.
+const (
+	targetNone     = iota // a variable that is tested, like err
+	targetValue           // a local variable, field or call result
+	targetSentinel        // a package-level variable, like io.EOF
+	targetNew             // an error made on the spot, like errors.New("x")
+)
+
+// errorTarget classifies e as the target of an error comparison.
+func errorTarget(info *types.Info, e ast.Expr) int {
+	e = ast.Unparen(e)
+	switch e := e.(type) {
+	case *ast.CallExpr:
+		if fn := calleeFunc(info, e); fn != nil {
+			switch fn.FullName() {
+			case "errors.New", "fmt.Errorf":
+				return targetNew
+			}
+		}
+		return targetValue
+	case *ast.UnaryExpr:
+		if _, ok := ast.Unparen(e.X).(*ast.CompositeLit); ok && e.Op == token.AND {
+			return targetNew
+		}
+	case *ast.SelectorExpr:
+		if sentinelVar(info, e) != nil {
+			return targetSentinel
+		}
+		if s := info.Selections[e]; s != nil && s.Kind() == types.FieldVal {
+			return targetValue
+		}
+	case *ast.Ident:
+		if sentinelVar(info, e) != nil {
+			return targetSentinel
+		}
+		if v := identVar(info, e); v != nil && !v.IsField() && strings.HasPrefix(e.Name, "err") {
+			return targetNone
+		}
+		return targetValue
+	}
+	return targetNone
+}
+
+// sentinelVar returns the package-level variable e names, or nil.
+func sentinelVar(info *types.Info, e ast.Expr) *types.Var {
+	var id *ast.Ident
+	switch e := ast.Unparen(e).(type) {
+	case *ast.Ident:
+		id = e
+	case *ast.SelectorExpr:
+		id = e.Sel
+	default:
+		return nil
+	}
+	v, ok := info.Uses[id].(*types.Var)
+	if !ok || v.IsField() || v.Pkg() == nil || v.Parent() != v.Pkg().Scope() {
+		return nil
+	}
+	return v
+}
+
+// isErrorValue reports whether e is a (non-nil) value implementing error.
+func isErrorValue(info *types.Info, e ast.Expr) bool {
+	tv, ok := info.Types[e]
+	return ok && tv.Type != nil && !tv.IsNil() && types.Implements(tv.Type, errorType)
+}
+
+// readerSentinelMethods are the methods that, by the contract of io.Reader
+// and its relatives, return io.EOF itself rather than wrapped.
+var readerSentinelMethods = map[string]bool{
+	"Read": true, "ReadAt": true, "ReadByte": true, "ReadRune": true,
+	"ReadString": true, "ReadBytes": true, "ReadLine": true, "ReadSlice": true,
+	"Decode": true, "Token": true,
+}
+
+// returnsSentinelItself reports whether the error err, compared at n, was
+// last assigned from a call to the standard library or to a Read method,
+// which return the io sentinels unwrapped.
+func returnsSentinelItself(ctx *Context, err ast.Expr, n ast.Node, stack []ast.Node) bool {
+	v := exprVar(ctx.Info, err)
+	if v == nil {
+		call, ok := ast.Unparen(err).(*ast.CallExpr)
+		return ok && unwrappedSource(ctx, call)
+	}
+	var body ast.Node
+	for i := len(stack) - 1; i >= 0 && body == nil; i-- {
+		switch fn := stack[i].(type) {
+		case *ast.FuncDecl:
+			body = fn.Body
+		case *ast.FuncLit:
+			body = fn.Body
+		}
+	}
+	if body == nil {
+		return false
+	}
+	// The assignment to v nearest before n decides.
+	var last *ast.CallExpr
+	lastPos := token.NoPos
+	record := func(lhs []ast.Expr, rhs []ast.Expr, pos token.Pos) {
+		if pos >= n.Pos() || pos < lastPos {
+			return
+		}
+		for i, e := range lhs {
+			if exprVar(ctx.Info, e) != v {
+				continue
+			}
+			lastPos, last = pos, nil
+			if len(rhs) == 1 {
+				last, _ = ast.Unparen(rhs[0]).(*ast.CallExpr)
+			} else if i < len(rhs) {
+				last, _ = ast.Unparen(rhs[i]).(*ast.CallExpr)
+			}
+		}
+	}
+	ast.Inspect(body, func(m ast.Node) bool {
+		switch m := m.(type) {
+		case *ast.AssignStmt:
+			record(m.Lhs, m.Rhs, m.Pos())
+		case *ast.ValueSpec:
+			lhs := make([]ast.Expr, len(m.Names))
+			for i, name := range m.Names {
+				lhs[i] = name
+			}
+			record(lhs, m.Values, m.Pos())
+		}
+		return true
+	})
+	return last != nil && unwrappedSource(ctx, last)
+}
+
+// unwrappedSource reports whether call is to a Read method or to the
+// standard library, taken to be packages whose path starts without a dot
+// and outside the analyzed package's module.
+func unwrappedSource(ctx *Context, call *ast.CallExpr) bool {
+	fn := calleeFunc(ctx.Info, call)
+	if fn == nil || fn.Pkg() == nil {
+		return false
+	}
+	if readerSentinelMethods[fn.Name()] && fn.Type().(*types.Signature).Recv() != nil {
+		return true
+	}
+	first := func(path string) string {
+		first, _, _ := strings.Cut(path, "/")
+		return first
+	}
+	root := first(fn.Pkg().Path())
+	return !strings.Contains(root, ".") && (ctx.Pkg == nil || root != first(ctx.Pkg.Path()))
+}
+
+// packageAt reports whether the last element of the import path refers to
+// that package at pos in file, as `errors` does to the errors package when
+// it is imported and not shadowed.
+func packageAt(ctx *Context, file *ast.File, pos token.Pos, path string) bool {
+	scope := ctx.Info.Scopes[file]
+	if scope == nil {
+		return false
+	}
+	if inner := scope.Innermost(pos); inner != nil {
+		scope = inner
+	}
+	_, obj := scope.LookupParent(path[strings.LastIndex(path, "/")+1:], pos)
+	pn, ok := obj.(*types.PkgName)
+	return ok && pn.Imported().Path() == path
+}
@@ -0,0 +1,209 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// ErrorAlwaysIgnoredDetector reports functions of the package that return
+// an error every caller in the package drops, by assigning it to the blank
+// identifier or by calling the function as a statement, as in a `go` or
+// `defer` statement. Either the error is never worth returning or the
+// callers are all negligent; one finding per function lists the calls.
+//
+// Functions need at least two calls to be reported, and are not if they
+// are used other than by calling them, such as passed as a func value. So
+// that calls through an interface go uncounted, methods named like a
+// method of an interface type the package uses are not reported either.
+// The rule is an audit of the whole package rather than a lint of single
+// calls, so it only runs when the configuration enables it.
+type ErrorAlwaysIgnoredDetector struct{}
+
+func (ErrorAlwaysIgnoredDetector) Name() string { return "error-always-ignored" }
+
+func (ErrorAlwaysIgnoredDetector) Description() string {
+	return "Function whose error result every call site in the package ignores"
+}
+
+func (ErrorAlwaysIgnoredDetector) DefaultSeverity() Severity { return SeverityNote }
+
+func (ErrorAlwaysIgnoredDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: `When no caller of a function ever looks at the error it returns, either the error cannot happen and the signature is misleading, or every caller is missing a failure. Both are worth a decision: drop the error result, or handle it where the function is called.`,
+		Example: `func flush(w io.Writer) error { ... }
+
+flush(out)
+_ = flush(log)`,
+		Fix: `if err := flush(out); err != nil {
+	return err
+}`,
+	}
+}
+
+func (ErrorAlwaysIgnoredDetector) OptIn() bool { return true }
+
+func (d ErrorAlwaysIgnoredDetector) Check(ctx *Context) []Finding {
+	decls := map[*types.Func]*ast.FuncDecl{}
+	for _, f := range ctx.Files {
+		for _, decl := range f.Decls {
+			if fd, ok := decl.(*ast.FuncDecl); ok {
+				if fn, ok := ctx.Info.Defs[fd.Name].(*types.Func); ok && returnsError(fn) {
+					decls[fn] = fd
+				}
+			}
+		}
+	}
+	if len(decls) == 0 {
+		return nil
+	}
+
+	// ignored holds the calls of each function that drop its error, and
+	// checked the functions used in any other way.
+	ignored := map[*types.Func][]*ast.CallExpr{}
+	checked := map[*types.Func]bool{}
+	// seen holds the calls whose use has been classified by their
+	// statement.
+	seen := map[*ast.CallExpr]bool{}
+	callee := func(e ast.Expr) (*ast.CallExpr, *types.Func) {
+		call, ok := ast.Unparen(e).(*ast.CallExpr)
+		if !ok {
+			return nil, nil
+		}
+		fn := calleeFunc(ctx.Info, call)
+		if fn == nil || decls[fn.Origin()] == nil {
+			return nil, nil
+		}
+		return call, fn.Origin()
+	}
+	dropped := func(e ast.Expr) {
+		if call, fn := callee(e); call != nil {
+			seen[call] = true
+			ignored[fn] = append(ignored[fn], call)
+		}
+	}
+	assigned := func(lhs []ast.Expr, rhs []ast.Expr) {
+		if len(rhs) != 1 {
+			return
+		}
+		call, fn := callee(rhs[0])
+		if call == nil {
+			return
+		}
+		seen[call] = true
+		results := fn.Type().(*types.Signature).Results()
+		if results.Len() != len(lhs) {
+			checked[fn] = true
+			return
+		}
+		for i, e := range lhs {
+			id, ok := ast.Unparen(e).(*ast.Ident)
+			if types.Implements(results.At(i).Type(), errorType) && (!ok || id.Name != "_") {
+				checked[fn] = true
+				return
+			}
+		}
+		ignored[fn] = append(ignored[fn], call)
+	}
+	for _, f := range ctx.Files {
+		inspectStack(f, func(n ast.Node, stack []ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.ExprStmt:
+				dropped(n.X)
+			case *ast.GoStmt:
+				dropped(n.Call)
+			case *ast.DeferStmt:
+				dropped(n.Call)
+			case *ast.AssignStmt:
+				assigned(n.Lhs, n.Rhs)
+			case *ast.ValueSpec:
+				lhs := make([]ast.Expr, len(n.Names))
+				for i, name := range n.Names {
+					lhs[i] = name
+				}
+				assigned(lhs, n.Values)
+			case *ast.CallExpr:
+				if _, fn := callee(n); fn != nil && !seen[n] {
+					// The results are used in an expression.
+					checked[fn] = true
+				}
+			case *ast.Ident:
+				fn, ok := ctx.Info.Uses[n].(*types.Func)
+				if ok && decls[fn.Origin()] != nil && !isCallee(n, stack) {
+					checked[fn.Origin()] = true
+				}
+			}
+			return true
+		})
+	}
+
+	ifaceMethods := map[string]bool{}
+	for _, tv := range ctx.Info.Types {
+		if tv.Type == nil {
+			continue
+		}
+		if it, ok := tv.Type.Underlying().(*types.Interface); ok {
+			for i := range it.NumMethods() {
+				ifaceMethods[it.Method(i).Name()] = true
+			}
+		}
+	}
+
+	var findings []Finding
+	for fn, calls := range ignored {
+		if checked[fn] || len(calls) < 2 {
+			continue
+		}
+		recv := fn.Type().(*types.Signature).Recv()
+		if recv != nil && ifaceMethods[fn.Name()] {
+			continue
+		}
+		fd := decls[fn]
+		f := ctx.NewFinding(d.Name(), SeverityNote, fd.Name,
+			"every call of `%s` in the package ignores the error it returns (%s); handle the error, or stop returning one",
+			fn.Name(), plural(len(calls), "call"))
+		for _, call := range calls {
+			f.Related = append(f.Related, Related{Position: ctx.Fset.Position(call.Pos()), Message: "the error of `" + types.ExprString(call.Fun) + "` is ignored here"})
+		}
+		if fn.Exported() {
+			// Callers in other packages may handle it.
+			f.Confidence = ConfidenceMedium
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// returnsError reports whether fn has an error result.
+func returnsError(fn *types.Func) bool {
+	results := fn.Type().(*types.Signature).Results()
+	for i := range results.Len() {
+		if types.Implements(results.At(i).Type(), errorType) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCallee reports whether id, at the top of stack, names the function
+// being called, as f in `f(x)` or `x.f(y)`.
+func isCallee(id *ast.Ident, stack []ast.Node) bool {
+	var fun ast.Node = id
+	i := len(stack) - 2
+	if i >= 0 {
+		if sel, ok := stack[i].(*ast.SelectorExpr); ok && sel.Sel == id {
+			fun, i = sel, i-1
+		}
+	}
+	for i >= 0 {
+		p, ok := stack[i].(*ast.ParenExpr)
+		if !ok {
+			break
+		}
+		fun, i = p, i-1
+	}
+	if i < 0 {
+		return false
+	}
+	call, ok := stack[i].(*ast.CallExpr)
+	return ok && call.Fun == fun
+}
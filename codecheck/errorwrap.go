@@ -0,0 +1,187 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// ErrorWrapDetector reports `return err` in functions where err comes from
+// one of several different calls that can fail: passed on as it is, the
+// error doesn't say which of them failed, and wrapping it with
+// fmt.Errorf("...: %w", err) would. Functions with only one such call, and
+// errors that are not the result of a call at the return, such as
+// sentinels or errors built with errors.New or fmt.Errorf, are left alone.
+// Whether an error needs more context is a matter of style and of what its
+// callers already add, so the rule only runs when the configuration
+// enables it.
+type ErrorWrapDetector struct{}
+
+func (ErrorWrapDetector) Name() string { return "error-wrap" }
+
+func (ErrorWrapDetector) Description() string {
+	return "Error from one of several failing calls returned without context"
+}
+
+func (ErrorWrapDetector) DefaultSeverity() Severity { return SeverityNote }
+
+func (ErrorWrapDetector) OptIn() bool { return true }
+
+func (ErrorWrapDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: "An error returned as it is from a function that makes several calls that can fail reads the same whichever call failed. By the time it is logged several layers up, `permission denied` or `unexpected EOF` no longer says what was being done. Wrapping it with fmt.Errorf and %w adds that context while errors.Is and errors.As still see the original.",
+		Example: `cfg, err := readConfig(path)
+if err != nil {
+	return err
+}
+db, err := openDB(cfg.DSN)
+if err != nil {
+	return err
+}`,
+		Fix: `cfg, err := readConfig(path)
+if err != nil {
+	return fmt.Errorf("reading config: %w", err)
+}
+db, err := openDB(cfg.DSN)
+if err != nil {
+	return fmt.Errorf("opening database: %w", err)
+}`,
+	}
+}
+
+// errorConstructors build a new error rather than passing one on.
+var errorConstructors = map[string]bool{"errors.New": true, "errors.Join": true, "fmt.Errorf": true}
+
+// errorAssign is an assignment to an error variable: from the call, which
+// is nil if the value is not a call result that can fail.
+type errorAssign struct {
+	pos  token.Pos
+	call *ast.CallExpr
+}
+
+func (d ErrorWrapDetector) Check(ctx *Context) []Finding {
+	var findings []Finding
+	for _, f := range ctx.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			var ft *ast.FuncType
+			var body *ast.BlockStmt
+			name := "the function literal"
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				ft, body, name = n.Type, n.Body, "`"+n.Name.Name+"`"
+			case *ast.FuncLit:
+				ft, body = n.Type, n.Body
+			}
+			if body == nil || ft.Results == nil {
+				return true
+			}
+			last := ft.Results.List[len(ft.Results.List)-1]
+			if !isError(ctx.Info.TypeOf(last.Type)) {
+				return true
+			}
+			findings = append(findings, d.checkFunc(ctx, f, name, body)...)
+			return true
+		})
+	}
+	return findings
+}
+
+func (d ErrorWrapDetector) checkFunc(ctx *Context, file *ast.File, name string, body *ast.BlockStmt) []Finding {
+	assigns := map[*types.Var][]errorAssign{}
+	sources := map[string]bool{}
+	var returns []*ast.ReturnStmt
+	record := func(lhs []ast.Expr, rhs []ast.Expr, pos token.Pos) {
+		for i, e := range lhs {
+			v := exprVar(ctx.Info, e)
+			if v == nil || !isError(v.Type()) {
+				continue
+			}
+			var value ast.Expr
+			if len(rhs) == len(lhs) {
+				value = rhs[i]
+			} else if len(rhs) == 1 {
+				value = rhs[0]
+			}
+			a := errorAssign{pos: pos}
+			if call, ok := ast.Unparen(value).(*ast.CallExpr); ok {
+				fn := calleeFunc(ctx.Info, call)
+				if fn == nil || !errorConstructors[fn.FullName()] {
+					a.call = call
+					sources[types.ExprString(call.Fun)] = true
+				}
+			}
+			assigns[v] = append(assigns[v], a)
+		}
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.AssignStmt:
+			record(n.Lhs, n.Rhs, n.Pos())
+		case *ast.ValueSpec:
+			lhs := make([]ast.Expr, len(n.Names))
+			for i, name := range n.Names {
+				lhs[i] = name
+			}
+			record(lhs, n.Values, n.Pos())
+		case *ast.ReturnStmt:
+			returns = append(returns, n)
+		}
+		return true
+	})
+	if len(sources) < 2 {
+		return nil
+	}
+	calls := make([]string, 0, len(sources))
+	for s := range sources {
+		calls = append(calls, "`"+s+"`")
+	}
+	sort.Strings(calls)
+
+	var findings []Finding
+	for _, ret := range returns {
+		if len(ret.Results) == 0 {
+			continue
+		}
+		res := ret.Results[len(ret.Results)-1]
+		v := exprVar(ctx.Info, res)
+		if v == nil {
+			continue
+		}
+		// The assignment nearest before the return decides.
+		var from *ast.CallExpr
+		for _, a := range assigns[v] {
+			if a.pos < ret.Pos() {
+				from = a.call
+			}
+		}
+		if from == nil {
+			continue
+		}
+		callee := types.ExprString(from.Fun)
+		fd := ctx.NewFinding(d.Name(), SeverityNote, ret,
+			"`%s` passes on the error from `%s` without context; %s has %d calls that can fail (%s), and the error doesn't say which one did",
+			ctx.sourceText(ret), callee, name, len(calls), strings.Join(calls, ", "))
+		what := "call"
+		switch fun := ast.Unparen(from.Fun).(type) {
+		case *ast.Ident:
+			what = fun.Name
+		case *ast.SelectorExpr:
+			what = fun.Sel.Name
+		}
+		what = strings.ToLower(what[:1]) + what[1:]
+		wrapped := `fmt.Errorf("` + what + `: %w", ` + ctx.sourceText(res) + `)`
+		fd.Suggestion = "add what was being done, as in `" + wrapped + "`"
+		if packageAt(ctx, file, ret.Pos(), "fmt") {
+			fd.Fix = &SuggestedFix{
+				Message: "wrap the error with fmt.Errorf",
+				Edits:   []TextEdit{ctx.edit(res.Pos(), res.End(), wrapped)},
+			}
+		}
+		findings = append(findings, fd)
+	}
+	return findings
+}
@@ -0,0 +1,170 @@
+package codecheck
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Excludes reports whether the Analyzer leaves the file at path out of the
+// analysis, because it matches Options.Exclude or is generated code. The
+// start of the file is read to tell the latter.
+func (a *Analyzer) Excludes(path string) bool {
+	if a.excludedName(path) {
+		return true
+	}
+	src, err := os.ReadFile(path)
+	return err == nil && !a.opts.IncludeGenerated && isGenerated(src)
+}
+
+// excluded reports whether the file name, with contents src, is left out
+// of the analysis: its path matches one of Options.Exclude or, unless
+// Options.IncludeGenerated is set, it is generated code.
+func (a *Analyzer) excluded(name string, src []byte) bool {
+	return a.excludedName(name) || !a.opts.IncludeGenerated && isGenerated(src)
+}
+
+// excludedName reports whether the path of the file name matches one of
+// Options.Exclude. Paths are matched relative to Options.Dir, or to the
+// current directory if that is empty, with forward slashes. A pattern
+// without a slash matches the file's base name or any directory on its
+// path, as `*.pb.go` or `vendor` do; one with a slash matches the path
+// from the start, with `**` standing for any number of directories, and a
+// pattern matching a directory matches everything in it.
+func (a *Analyzer) excludedName(name string) bool {
+	if len(a.opts.Exclude) == 0 {
+		return false
+	}
+	rel := name
+	if base, err := filepath.Abs(a.opts.Dir); err == nil {
+		if abs, err := filepath.Abs(name); err == nil {
+			if r, err := filepath.Rel(base, abs); err == nil && !strings.HasPrefix(r, "..") {
+				rel = r
+			}
+		}
+	}
+	elems := strings.Split(filepath.ToSlash(rel), "/")
+	for _, pattern := range a.opts.Exclude {
+		pattern = strings.TrimSuffix(pattern, "/")
+		if !strings.Contains(pattern, "/") {
+			for _, e := range elems {
+				if ok, _ := path.Match(pattern, e); ok {
+					return true
+				}
+			}
+			continue
+		}
+		for n := 1; n <= len(elems); n++ {
+			if matchPath(strings.Split(pattern, "/"), elems[:n]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchPath reports whether the path elements elems match the pattern
+// elements pattern, where a "**" element matches any number of elements.
+func matchPath(pattern, elems []string) bool {
+	if len(pattern) == 0 {
+		return len(elems) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(elems); i++ {
+			if matchPath(pattern[1:], elems[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(elems) == 0 {
+		return false
+	}
+	ok, _ := path.Match(pattern[0], elems[0])
+	return ok && matchPath(pattern[1:], elems[1:])
+}
+
+// generatedComment is the comment marking generated files; see
+// https://go.dev/s/generatedcode.
+var generatedComment = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// isGenerated reports whether src has the generated-code comment before
+// its first line of code, looking no further than needed so that the file
+// need not be parsed.
+func isGenerated(src []byte) bool {
+	inComment := false
+	for len(src) > 0 {
+		line := src
+		if i := bytes.IndexByte(src, '\n'); i >= 0 {
+			line, src = src[:i], src[i+1:]
+		} else {
+			src = nil
+		}
+		line = bytes.TrimSpace(line)
+		switch {
+		case inComment:
+			inComment = !bytes.Contains(line, []byte("*/"))
+		case len(line) == 0:
+		case generatedComment.Match(line):
+			return true
+		case bytes.HasPrefix(line, []byte("//")):
+		case bytes.HasPrefix(line, []byte("/*")):
+			inComment = !bytes.Contains(line[2:], []byte("*/"))
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// parseFile is the packages.Config.ParseFile of an Analyzer. Excluded
+// files are parsed without comments and with their function bodies
+// dropped: their declarations are still needed to type-check the rest of
+// the package, but nothing in them is analyzed.
+func (a *Analyzer) parseFile(fset *token.FileSet, name string, src []byte) (*ast.File, error) {
+	if !a.excluded(name, src) {
+		return parser.ParseFile(fset, name, src, parser.AllErrors|parser.ParseComments)
+	}
+	a.log.Debug("skipped file", "file", name)
+	f, err := parser.ParseFile(fset, name, src, parser.AllErrors|parser.SkipObjectResolution)
+	if f != nil {
+		for _, decl := range f.Decls {
+			if fd, ok := decl.(*ast.FuncDecl); ok {
+				fd.Body = nil
+			}
+		}
+	}
+	return f, err
+}
+
+// analyzedFiles returns the files of a package loaded with parseFile that
+// are not excluded, with their contents, read from disk unless overlay
+// has them.
+func (a *Analyzer) analyzedFiles(fset *token.FileSet, syntax []*ast.File, overlay map[string][]byte) ([]*ast.File, map[string][]byte) {
+	var files []*ast.File
+	sources := map[string][]byte{}
+	for _, f := range syntax {
+		name := fset.Position(f.Pos()).Filename
+		src, ok := overlay[name]
+		if !ok {
+			var err error
+			if src, err = os.ReadFile(name); err != nil {
+				src = nil
+			}
+		}
+		if a.excluded(name, src) {
+			continue
+		}
+		files = append(files, f)
+		if src != nil {
+			sources[name] = src
+		}
+	}
+	return files, sources
+}
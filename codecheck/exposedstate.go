@@ -0,0 +1,142 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// ExposedStateDetector reports exported methods returning a slice or map
+// held in an unexported field of their receiver, as in `return s.items`
+// or `return s.items[:]`. The caller gets the same backing array or map as
+// the receiver, so changing the result changes the receiver's state behind
+// its methods' backs, or races with them. Returning a copy keeps the state
+// encapsulated. Some methods share on purpose, for speed, as
+// bytes.Buffer.Bytes does; the finding is of low confidence when the
+// field's name or the method's doc comment says so.
+type ExposedStateDetector struct{}
+
+func (ExposedStateDetector) Name() string { return "exposed-state" }
+
+func (ExposedStateDetector) Description() string {
+	return "Exported method returning an unexported slice or map field, exposing internal state"
+}
+
+func (ExposedStateDetector) DefaultSeverity() Severity { return SeverityNote }
+
+func (ExposedStateDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: "A slice or map returned from a field is not a copy: the caller and the struct share the backing array or map. A caller appending to, sorting or clearing the result changes the struct's unexported state without going through its methods, breaking its invariants or racing with its locking.",
+		Example: `func (r *Registry) Names() []string {
+	return r.names
+}`,
+		Fix: `func (r *Registry) Names() []string {
+	return slices.Clone(r.names)
+}`,
+	}
+}
+
+// sharingWords are words in a field name or method doc comment saying that
+// the result is shared on purpose.
+var sharingWords = []string{"alias", "shared", "sharing", "underlying", "view", "read-only", "readonly", "not be modified", "not modify"}
+
+func (d ExposedStateDetector) Check(ctx *Context) []Finding {
+	var findings []Finding
+	for _, f := range ctx.Files {
+		for _, decl := range f.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Recv == nil || fd.Body == nil || !fd.Name.IsExported() || len(fd.Recv.List[0].Names) == 0 {
+				continue
+			}
+			recv, _ := ctx.Info.Defs[fd.Recv.List[0].Names[0]].(*types.Var)
+			if recv == nil {
+				continue
+			}
+			doc := strings.ToLower(fd.Doc.Text())
+			ast.Inspect(fd.Body, func(n ast.Node) bool {
+				if _, ok := n.(*ast.FuncLit); ok {
+					return false
+				}
+				ret, ok := n.(*ast.ReturnStmt)
+				if !ok {
+					return true
+				}
+				for _, res := range ret.Results {
+					field, kind := exposedField(ctx.Info, recv, res)
+					if field == nil {
+						continue
+					}
+					finding := ctx.NewFinding(d.Name(), SeverityNote, res,
+						"`%s` returns %s field `%s` itself, not a copy; callers can change `%s`'s internal state through it",
+						fd.Name.Name, kind, field.Name(), typeString(ctx, recv.Type()))
+					finding.Related = append(finding.Related, Related{Position: ctx.Fset.Position(field.Pos()), Message: "`" + field.Name() + "` is declared here"})
+					for _, w := range sharingWords {
+						if strings.Contains(strings.ToLower(field.Name()), w) || strings.Contains(doc, w) {
+							finding.Confidence = ConfidenceLow
+							break
+						}
+					}
+					clone := "slices.Clone"
+					if kind == "map" {
+						clone = "maps.Clone"
+					}
+					finding.Suggestion = "return a copy, `" + clone + "(" + ctx.sourceText(res) + ")`, or document that callers must not modify the result"
+					findings = append(findings, finding)
+				}
+				return true
+			})
+		}
+	}
+	return findings
+}
+
+// exposedField returns the unexported slice or map field of recv that e
+// is, like `r.items`, `r.inner.items` or `r.items[i:]`, and "slice" or
+// "map", or nil if e is no such field.
+func exposedField(info *types.Info, recv *types.Var, e ast.Expr) (*types.Var, string) {
+	e = ast.Unparen(e)
+	if s, ok := e.(*ast.SliceExpr); ok && !s.Slice3 {
+		e = ast.Unparen(s.X)
+	}
+	sel, ok := e.(*ast.SelectorExpr)
+	if !ok {
+		return nil, ""
+	}
+	s := info.Selections[sel]
+	if s == nil || s.Kind() != types.FieldVal {
+		return nil, ""
+	}
+	field := s.Obj().(*types.Var)
+	if field.Exported() {
+		// Callers can reach it anyway.
+		return nil, ""
+	}
+	kind := ""
+	switch field.Type().Underlying().(type) {
+	case *types.Slice:
+		kind = "slice"
+	case *types.Map:
+		kind = "map"
+	default:
+		return nil, ""
+	}
+	// The field must be reached from the receiver through fields.
+	for x := ast.Unparen(sel.X); ; {
+		switch y := x.(type) {
+		case *ast.Ident:
+			if info.Uses[y] != recv {
+				return nil, ""
+			}
+			return field, kind
+		case *ast.SelectorExpr:
+			if s := info.Selections[y]; s == nil || s.Kind() != types.FieldVal {
+				return nil, ""
+			}
+			x = ast.Unparen(y.X)
+		case *ast.StarExpr:
+			x = ast.Unparen(y.X)
+		default:
+			return nil, ""
+		}
+	}
+}
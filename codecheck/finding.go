@@ -0,0 +1,147 @@
+package codecheck
+
+import (
+	"fmt"
+	"go/token"
+)
+
+// Severity ranks how serious a finding is.
+type Severity int
+
+const (
+	SeverityNote Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityNote:
+		return "note"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// ParseSeverity parses the String form of a Severity.
+func ParseSeverity(s string) (Severity, error) {
+	for _, sev := range []Severity{SeverityNote, SeverityWarning, SeverityError} {
+		if s == sev.String() {
+			return sev, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown severity %q", s)
+}
+
+// Confidence is how sure a detector is that a finding is a real problem:
+// high when the code definitely misbehaves as reported, lower the more the
+// detector had to guess, e.g. about which paths run or what a value holds.
+type Confidence int
+
+const (
+	ConfidenceLow Confidence = iota + 1
+	ConfidenceMedium
+	ConfidenceHigh
+)
+
+func (c Confidence) String() string {
+	switch c {
+	case ConfidenceLow:
+		return "low"
+	case ConfidenceMedium:
+		return "medium"
+	case ConfidenceHigh:
+		return "high"
+	}
+	return fmt.Sprintf("Confidence(%d)", int(c))
+}
+
+// ParseConfidence parses the String form of a Confidence.
+func ParseConfidence(s string) (Confidence, error) {
+	for _, c := range []Confidence{ConfidenceLow, ConfidenceMedium, ConfidenceHigh} {
+		if s == c.String() {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown confidence %q", s)
+}
+
+// Finding is a single problem reported by a detector.
+type Finding struct {
+	Rule     string
+	Severity Severity
+	// Confidence is how likely the finding is to be a real problem. The
+	// Analyzer reports findings a detector leaves at zero as high.
+	Confidence Confidence
+	Position   token.Position
+	End        token.Position
+	Message    string
+	// Suggestion describes how to fix the problem, if the detector has one.
+	Suggestion string
+	// Related points at other code involved in the finding, such as the
+	// call site that passes the value causing it.
+	Related []Related
+	// Fix is a mechanical rewrite that resolves the finding, if the
+	// detector has one; see ApplyFixes.
+	Fix *SuggestedFix
+	// Fingerprint identifies the finding independently of its line
+	// number; see fingerprint.go.
+	Fingerprint string
+	// Duplicates are the findings Dedupe collapsed into this one.
+	Duplicates []Finding
+}
+
+// Related is a secondary location of a finding.
+type Related struct {
+	Position token.Position
+	Message  string
+}
+
+// SuggestedFix is a set of edits that resolves a finding. Only fixes
+// marked Safe are applied by ApplyFixes; the others change behavior in a
+// way someone has to review, such as a placeholder for error handling.
+type SuggestedFix struct {
+	Message string
+	Safe    bool
+	Edits   []TextEdit
+}
+
+// TextEdit replaces the bytes [Start, End) of File with NewText.
+type TextEdit struct {
+	File       string
+	Start, End int
+	NewText    string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s (%s)", f.Position, f.Severity, f.Message, f.Rule)
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Severity) UnmarshalText(text []byte) error {
+	sev, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+	*s = sev
+	return nil
+}
+
+func (c Confidence) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+func (c *Confidence) UnmarshalText(text []byte) error {
+	conf, err := ParseConfidence(string(text))
+	if err != nil {
+		return err
+	}
+	*c = conf
+	return nil
+}
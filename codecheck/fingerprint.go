@@ -0,0 +1,75 @@
+package codecheck
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"go/ast"
+	"go/types"
+	"strconv"
+	"strings"
+)
+
+// fingerprint sets the Fingerprint of each finding, which must already be
+// sorted. The fingerprint is the first 16 bytes, hex-encoded, of the SHA-256
+// of these fields, each terminated by a newline:
+//
+//   - the rule id
+//   - the import path of the package
+//   - the enclosing function, as "Name" or "Recv.Name" ("" at file scope)
+//   - the source text of the flagged code with runs of white space
+//     collapsed to a single space
+//   - the number of earlier findings in the same function with identical
+//     values for all of the above
+//
+// Line numbers, columns and messages are deliberately left out, so adding
+// or removing unrelated code leaves fingerprints unchanged, and the same
+// code always produces the same fingerprint.
+func fingerprint(ctx *Context, findings []Finding) {
+	pkg := ""
+	if ctx.Pkg != nil {
+		pkg = ctx.Pkg.Path()
+	}
+	seen := map[string]int{}
+	for i := range findings {
+		f := &findings[i]
+		key := strings.Join([]string{f.Rule, pkg, enclosingFunc(ctx, f), findingText(ctx, f)}, "\n") + "\n"
+		n := seen[key]
+		seen[key]++
+		sum := sha256.Sum256([]byte(key + strconv.Itoa(n) + "\n"))
+		f.Fingerprint = hex.EncodeToString(sum[:16])
+	}
+}
+
+// findingText returns the normalized source text spanned by f.
+func findingText(ctx *Context, f *Finding) string {
+	src := ctx.sources[f.Position.Filename]
+	start, end := f.Position.Offset, f.End.Offset
+	if start < 0 || end > len(src) || start > end {
+		return ""
+	}
+	return strings.Join(strings.Fields(string(src[start:end])), " ")
+}
+
+// enclosingFunc names the top-level function declaration containing f.
+func enclosingFunc(ctx *Context, f *Finding) string {
+	for _, file := range ctx.Files {
+		if ctx.Fset.Position(file.Pos()).Filename != f.Position.Filename {
+			continue
+		}
+		for _, decl := range file.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			start, end := ctx.Fset.Position(fd.Pos()).Offset, ctx.Fset.Position(fd.End()).Offset
+			if f.Position.Offset < start || f.Position.Offset >= end {
+				continue
+			}
+			if fd.Recv != nil && len(fd.Recv.List) == 1 {
+				return types.ExprString(fd.Recv.List[0].Type) + "." + fd.Name.Name
+			}
+			return fd.Name.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,46 @@
+package codecheck
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestFingerprintPackages checks that the same code in two packages of the
+// same name gets different fingerprints, whether analyzed as packages or
+// as files, and that analyzing it again reproduces them.
+func TestFingerprintPackages(t *testing.T) {
+	a := newFixtureAnalyzer(t, 0, "ignored-error")
+	files := []string{
+		filepath.Join(fixtures, "fingerprint/cmd/a/main.go"),
+		filepath.Join(fixtures, "fingerprint/cmd/b/main.go"),
+	}
+	for _, tc := range []struct {
+		name    string
+		analyze func() ([]Finding, error)
+	}{
+		{"packages", func() ([]Finding, error) { return a.AnalyzePackages("./fingerprint/...") }},
+		{"files", func() ([]Finding, error) { return a.AnalyzeFiles(files...) }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			findings, err := tc.analyze()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(findings) != 2 {
+				t.Fatalf("got %d findings, want 2", len(findings))
+			}
+			if findings[0].Fingerprint == findings[1].Fingerprint {
+				t.Errorf("findings in %s and %s share fingerprint %s", findings[0].Position.Filename, findings[1].Position.Filename, findings[0].Fingerprint)
+			}
+			again, err := tc.analyze()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range findings {
+				if again[i].Fingerprint != findings[i].Fingerprint {
+					t.Errorf("%s: fingerprint %s changed to %s", findings[i].Position, findings[i].Fingerprint, again[i].Fingerprint)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package codecheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"os"
+	"sort"
+)
+
+// ApplyFixes applies the safe suggested fixes of findings to the files
+// they edit and returns the new contents of each changed file, formatted
+// with go/format, together with which findings were fixed. Files are read
+// from disk but not written. A fix whose edits overlap those of a fix
+// already taken is skipped, so the result never depends on edits
+// interfering with each other.
+func ApplyFixes(findings []Finding) (map[string][]byte, []bool, error) {
+	type edit struct {
+		TextEdit
+		finding int
+	}
+	byFile := map[string][]edit{}
+	fixed := make([]bool, len(findings))
+	for i, f := range findings {
+		if f.Fix == nil || !f.Fix.Safe || len(f.Fix.Edits) == 0 {
+			continue
+		}
+		ok := true
+		for _, e := range f.Fix.Edits {
+			for _, other := range byFile[e.File] {
+				if e.Start < other.End && other.Start < e.End || e.Start == other.Start {
+					ok = false
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+		for _, e := range f.Fix.Edits {
+			byFile[e.File] = append(byFile[e.File], edit{e, i})
+		}
+		fixed[i] = true
+	}
+	out := map[string][]byte{}
+	for file, edits := range byFile {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Apply from the end of the file backwards so earlier offsets
+		// stay valid.
+		sort.Slice(edits, func(i, j int) bool { return edits[i].Start > edits[j].Start })
+		for _, e := range edits {
+			if e.Start < 0 || e.End > len(src) || e.Start > e.End {
+				return nil, nil, fmt.Errorf("%s: fix for %s does not match the file; was it changed since the analysis?", file, findings[e.finding].Position)
+			}
+			src = append(src[:e.Start], append([]byte(e.NewText), src[e.End:]...)...)
+		}
+		formatted, err := format.Source(src)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: fixed source does not parse: %v", file, err)
+		}
+		out[file] = formatted
+	}
+	return out, fixed, nil
+}
+
+// edit returns a TextEdit replacing the source from pos to end.
+func (c *Context) edit(pos, end token.Pos, text string) TextEdit {
+	p, e := c.Fset.Position(pos), c.Fset.Position(end)
+	return TextEdit{File: p.Filename, Start: p.Offset, End: e.Offset, NewText: text}
+}
+
+// sourceText returns the source of n as written, or n reprinted if the
+// source is not available.
+func (c *Context) sourceText(n ast.Node) string {
+	p, e := c.Fset.Position(n.Pos()), c.Fset.Position(n.End())
+	if src := c.sources[p.Filename]; p.Offset >= 0 && e.Offset <= len(src) && p.Offset <= e.Offset {
+		return string(src[p.Offset:e.Offset])
+	}
+	return nodeString(n)
+}
+
+// zeroValue returns Go source for the zero value of t.
+func zeroValue(ctx *Context, t types.Type) (string, bool) {
+	if _, ok := t.(*types.TypeParam); ok {
+		return "*new(" + typeString(ctx, t) + ")", true
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false", true
+		case u.Info()&types.IsString != 0:
+			return `""`, true
+		case u.Info()&types.IsNumeric != 0:
+			return "0", true
+		case u.Kind() == types.UnsafePointer:
+			return "nil", true
+		}
+	case *types.Pointer, *types.Slice, *types.Map, *types.Chan, *types.Signature, *types.Interface:
+		return "nil", true
+	case *types.Struct, *types.Array:
+		return typeString(ctx, t) + "{}", true
+	}
+	return "", false
+}
@@ -0,0 +1,148 @@
+package codecheck
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestApplyFixesGolden applies the safe fixes found in testdata/fix/*.input
+// and compares the result with the matching .golden file. The inputs are
+// formatted badly on purpose, since the fixed source is run through
+// gofmt, and must be left unchanged on disk.
+func TestApplyFixesGolden(t *testing.T) {
+	inputs, err := filepath.Glob("testdata/fix/*.input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no inputs in testdata/fix")
+	}
+	for _, input := range inputs {
+		t.Run(filepath.Base(input), func(t *testing.T) {
+			before, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(strings.TrimSuffix(input, ".input") + ".golden")
+			if err != nil {
+				t.Fatal(err)
+			}
+			findings, err := New(Options{}).AnalyzeFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, fixed, err := ApplyFixes(findings)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := out[input]; !bytes.Equal(got, want) {
+				t.Errorf("fixed source differs from the golden file:\n%s", got)
+			}
+			for i, f := range findings {
+				safe := f.Fix != nil && f.Fix.Safe
+				if fixed[i] != safe {
+					t.Errorf("%s: fixed = %v, want %v for a fix with Safe = %v", f, fixed[i], safe, safe)
+				}
+			}
+			after, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(after, before) {
+				t.Error("ApplyFixes wrote to the input file")
+			}
+		})
+	}
+}
+
+// TestApplyFixesOverlap checks that a fix whose edits overlap or share a
+// start with those of an earlier fix is skipped, while both of two
+// adjacent fixes are applied.
+func TestApplyFixesOverlap(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.go")
+	src := "package a\n\nvar x = 1 + 2\n"
+	if err := os.WriteFile(file, []byte(src), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	one := strings.Index(src, "1")
+	fix := func(start, end int, text string) Finding {
+		return Finding{Fix: &SuggestedFix{Safe: true, Edits: []TextEdit{{File: file, Start: start, End: end, NewText: text}}}}
+	}
+	tests := []struct {
+		name      string
+		findings  []Finding
+		want      string
+		wantFixed []bool
+	}{
+		{
+			name:      "overlapping",
+			findings:  []Finding{fix(one, one+5, "3"), fix(one+4, one+5, "4")},
+			want:      "package a\n\nvar x = 3\n",
+			wantFixed: []bool{true, false},
+		},
+		{
+			name:      "same start",
+			findings:  []Finding{fix(one, one, "0 + "), fix(one, one+1, "5")},
+			want:      "package a\n\nvar x = 0 + 1 + 2\n",
+			wantFixed: []bool{true, false},
+		},
+		{
+			name:      "adjacent",
+			findings:  []Finding{fix(one, one+1, "3"), fix(one+1, one+1, "0")},
+			want:      "package a\n\nvar x = 30 + 2\n",
+			wantFixed: []bool{true, true},
+		},
+		{
+			name:      "unsafe",
+			findings:  []Finding{{Fix: &SuggestedFix{Edits: []TextEdit{{File: file, Start: one, End: one + 1, NewText: "3"}}}}},
+			wantFixed: []bool{false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, fixed, err := ApplyFixes(tt.findings)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(out[file]); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			for i := range fixed {
+				if fixed[i] != tt.wantFixed[i] {
+					t.Errorf("fixed = %v, want %v", fixed, tt.wantFixed)
+					break
+				}
+			}
+		})
+	}
+}
+
+// TestApplyFixesErrors checks that a fix beyond the end of the file, as
+// after the file shrank since the analysis, and a fix producing source
+// that doesn't parse are errors rather than written out.
+func TestApplyFixesErrors(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.go")
+	src := "package a\n\nvar x = 1\n"
+	if err := os.WriteFile(file, []byte(src), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		edit TextEdit
+		want string
+	}{
+		{"out of range", TextEdit{File: file, Start: len(src), End: len(src) + 10}, "does not match the file"},
+		{"does not parse", TextEdit{File: file, Start: len(src) - 2, End: len(src) - 1, NewText: "+"}, "does not parse"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ApplyFixes([]Finding{{Fix: &SuggestedFix{Safe: true, Edits: []TextEdit{tt.edit}}}})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
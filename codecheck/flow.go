@@ -0,0 +1,534 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// zeroFact records why a tracked variable may still hold its zero value at
+// some point in a function.
+type zeroFact struct {
+	decl     token.Pos // declaration, or the explicit nil assignment
+	explicit bool      // decl is an assignment of nil rather than a declaration
+	param    bool      // decl is a parameter, which callers may pass as zero
+	cond     string    // branch that left the variable unassigned, if any
+
+	// For if statements, the condition of that branch and the outcome
+	// that takes it.
+	condExpr  ast.Expr
+	condTruth bool
+}
+
+// flowState maps each tracked variable that may still be zero to the reason
+// why. A nil flowState means the current path is unreachable.
+type flowState map[*types.Var]*zeroFact
+
+func (s flowState) clone() flowState {
+	if s == nil {
+		return nil
+	}
+	c := make(flowState, len(s))
+	for v, f := range s {
+		c[v] = f
+	}
+	return c
+}
+
+// flowVisitor is driven by walkFlow. track selects which `var x T`
+// declarations are followed; visit is called with every simple statement
+// and expression evaluated along a path, before its effects are applied.
+// A visitor may delete variables from st once it has reported them.
+type flowVisitor interface {
+	track(v *types.Var) bool
+	visit(n ast.Node, st flowState)
+}
+
+type flowBranch struct {
+	st    flowState
+	label string
+	cond  ast.Expr // condition whose outcome truth selects this branch
+	truth bool
+}
+
+type flowTarget struct {
+	label     string
+	loop      bool
+	breaks    []flowBranch
+	continues []flowBranch
+}
+
+type flowWalker struct {
+	info    *types.Info
+	v       flowVisitor
+	escaped map[*types.Var]bool
+	targets []*flowTarget
+	label   string
+}
+
+// walkFlow follows every path through body, tracking variables declared
+// without a value until they are assigned. Branches of if, switch and select
+// statements are walked separately and merged afterwards; loops are walked
+// twice so that assignments in one iteration reach the next. Variables whose
+// address is taken or that are captured by a closure are never tracked.
+func walkFlow(info *types.Info, body *ast.BlockStmt, v flowVisitor) {
+	walkFlowParams(info, nil, body, v)
+}
+
+// walkFlowParams is walkFlow with the parameters named by params tracked
+// from the start, as values a caller may have passed as zero.
+func walkFlowParams(info *types.Info, params []*ast.Ident, body *ast.BlockStmt, v flowVisitor) {
+	w := &flowWalker{info: info, v: v, escaped: escapedVars(info, body)}
+	st := flowState{}
+	for _, name := range params {
+		if p := w.object(name); p != nil && !w.escaped[p] && v.track(p) {
+			st[p] = &zeroFact{decl: name.Pos(), param: true}
+		}
+	}
+	w.stmts(body.List, st)
+}
+
+func (w *flowWalker) stmts(list []ast.Stmt, st flowState) flowState {
+	for _, s := range list {
+		if st == nil {
+			return nil
+		}
+		st = w.stmt(s, st)
+	}
+	return st
+}
+
+func (w *flowWalker) stmt(s ast.Stmt, st flowState) flowState {
+	label := w.label
+	w.label = ""
+	switch s := s.(type) {
+	case *ast.BlockStmt:
+		return w.stmts(s.List, st)
+	case *ast.LabeledStmt:
+		w.label = s.Label.Name
+		return w.stmt(s.Stmt, st)
+	case *ast.DeclStmt:
+		w.v.visit(s, st)
+		w.decl(s, st)
+	case *ast.AssignStmt:
+		w.v.visit(s, st)
+		w.assign(s, st)
+	case *ast.ExprStmt:
+		w.v.visit(s, st)
+		if call, ok := ast.Unparen(s.X).(*ast.CallExpr); ok && isNoReturn(w.info, call) {
+			return nil
+		}
+	case *ast.IncDecStmt, *ast.SendStmt, *ast.GoStmt, *ast.DeferStmt:
+		w.v.visit(s, st)
+	case *ast.ReturnStmt:
+		w.v.visit(s, st)
+		return nil
+	case *ast.BranchStmt:
+		return w.branch(s, st)
+	case *ast.IfStmt:
+		return w.ifStmt(s, st)
+	case *ast.SwitchStmt:
+		return w.switchStmt(s, label, st)
+	case *ast.TypeSwitchStmt:
+		return w.typeSwitchStmt(s, label, st)
+	case *ast.SelectStmt:
+		return w.selectStmt(s, label, st)
+	case *ast.ForStmt:
+		return w.forStmt(s, label, st)
+	case *ast.RangeStmt:
+		return w.rangeStmt(s, label, st)
+	}
+	return st
+}
+
+func (w *flowWalker) decl(s *ast.DeclStmt, st flowState) {
+	gd, ok := s.Decl.(*ast.GenDecl)
+	if !ok || gd.Tok != token.VAR {
+		return
+	}
+	for _, spec := range gd.Specs {
+		vs := spec.(*ast.ValueSpec)
+		for _, name := range vs.Names {
+			v := w.object(name)
+			if v == nil {
+				continue
+			}
+			if len(vs.Values) == 0 && !w.escaped[v] && w.v.track(v) {
+				st[v] = &zeroFact{decl: name.Pos()}
+			} else {
+				delete(st, v)
+			}
+		}
+	}
+}
+
+func (w *flowWalker) assign(s *ast.AssignStmt, st flowState) {
+	for i, lhs := range s.Lhs {
+		id, ok := ast.Unparen(lhs).(*ast.Ident)
+		if !ok {
+			continue
+		}
+		v := w.object(id)
+		if v == nil {
+			continue
+		}
+		if s.Tok == token.ASSIGN && len(s.Lhs) == len(s.Rhs) && isNilExpr(w.info, s.Rhs[i]) &&
+			!w.escaped[v] && w.v.track(v) {
+			st[v] = &zeroFact{decl: s.Pos(), explicit: true}
+			continue
+		}
+		delete(st, v)
+	}
+}
+
+func (w *flowWalker) branch(s *ast.BranchStmt, st flowState) flowState {
+	switch s.Tok {
+	case token.BREAK, token.CONTINUE:
+		if t := w.target(s); t != nil {
+			if s.Tok == token.BREAK {
+				t.breaks = append(t.breaks, flowBranch{st: st})
+			} else {
+				t.continues = append(t.continues, flowBranch{st: st})
+			}
+		}
+		return nil
+	case token.GOTO:
+		return nil
+	}
+	return st
+}
+
+func (w *flowWalker) target(s *ast.BranchStmt) *flowTarget {
+	for i := len(w.targets) - 1; i >= 0; i-- {
+		t := w.targets[i]
+		switch {
+		case s.Label != nil:
+			if t.label == s.Label.Name {
+				return t
+			}
+		case s.Tok == token.BREAK || t.loop:
+			return t
+		}
+	}
+	return nil
+}
+
+func (w *flowWalker) push(label string, loop bool) *flowTarget {
+	t := &flowTarget{label: label, loop: loop}
+	w.targets = append(w.targets, t)
+	return t
+}
+
+func (w *flowWalker) pop() {
+	w.targets = w.targets[:len(w.targets)-1]
+}
+
+func (w *flowWalker) ifStmt(s *ast.IfStmt, st flowState) flowState {
+	if s.Init != nil {
+		if st = w.stmt(s.Init, st); st == nil {
+			return nil
+		}
+	}
+	w.cond(s.Cond, st)
+	cond := types.ExprString(s.Cond)
+	thenSt := w.stmts(s.Body.List, w.refine(s.Cond, true, st.clone()))
+	elseSt := w.refine(s.Cond, false, st.clone())
+	if s.Else != nil {
+		elseSt = w.stmt(s.Else, elseSt)
+	}
+	return mergeFlow(st,
+		flowBranch{thenSt, "`" + cond + "` is true", s.Cond, true},
+		flowBranch{elseSt, "`" + cond + "` is false", s.Cond, false})
+}
+
+func (w *flowWalker) switchStmt(s *ast.SwitchStmt, label string, st flowState) flowState {
+	if s.Init != nil {
+		if st = w.stmt(s.Init, st); st == nil {
+			return nil
+		}
+	}
+	if s.Tag != nil {
+		w.v.visit(s.Tag, st)
+	}
+	t := w.push(label, false)
+	defer w.pop()
+	var branches []flowBranch
+	hasDefault := false
+	for _, c := range s.Body.List {
+		cc := c.(*ast.CaseClause)
+		cst := st.clone()
+		for _, e := range cc.List {
+			w.cond(e, cst)
+		}
+		if s.Tag == nil && len(cc.List) == 1 {
+			cst = w.refine(cc.List[0], true, cst)
+		}
+		b := flowBranch{st: w.stmts(cc.Body, cst), label: caseLabel(cc)}
+		if s.Tag == nil && len(cc.List) == 1 {
+			b.cond, b.truth = cc.List[0], true
+		}
+		branches = append(branches, b)
+		hasDefault = hasDefault || cc.List == nil
+	}
+	if !hasDefault {
+		branches = append(branches, flowBranch{st: st.clone(), label: "no switch case matches"})
+	}
+	return mergeFlow(st, append(branches, t.breaks...)...)
+}
+
+func (w *flowWalker) typeSwitchStmt(s *ast.TypeSwitchStmt, label string, st flowState) flowState {
+	if s.Init != nil {
+		if st = w.stmt(s.Init, st); st == nil {
+			return nil
+		}
+	}
+	w.v.visit(s.Assign, st)
+	t := w.push(label, false)
+	defer w.pop()
+	var branches []flowBranch
+	hasDefault := false
+	for _, c := range s.Body.List {
+		cc := c.(*ast.CaseClause)
+		branches = append(branches, flowBranch{st: w.stmts(cc.Body, st.clone()), label: caseLabel(cc)})
+		hasDefault = hasDefault || cc.List == nil
+	}
+	if !hasDefault {
+		branches = append(branches, flowBranch{st: st.clone(), label: "no switch case matches"})
+	}
+	return mergeFlow(st, append(branches, t.breaks...)...)
+}
+
+func (w *flowWalker) selectStmt(s *ast.SelectStmt, label string, st flowState) flowState {
+	t := w.push(label, false)
+	defer w.pop()
+	var branches []flowBranch
+	for _, c := range s.Body.List {
+		cc := c.(*ast.CommClause)
+		cst := st.clone()
+		if cc.Comm != nil {
+			cst = w.stmt(cc.Comm, cst)
+		}
+		lbl := "the default select case runs"
+		if cc.Comm != nil {
+			lbl = "select case `" + nodeString(cc.Comm) + "` runs"
+		}
+		branches = append(branches, flowBranch{st: w.stmts(cc.Body, cst), label: lbl})
+	}
+	return mergeFlow(st, append(branches, t.breaks...)...)
+}
+
+func (w *flowWalker) forStmt(s *ast.ForStmt, label string, st flowState) flowState {
+	if s.Init != nil {
+		if st = w.stmt(s.Init, st); st == nil {
+			return nil
+		}
+	}
+	entry := ""
+	if s.Cond != nil {
+		entry = "`" + types.ExprString(s.Cond) + "` is false on entry to the loop"
+	}
+	return w.loop(s.Body, s.Cond, s.Post, label, entry, s.Cond != nil, st)
+}
+
+func (w *flowWalker) rangeStmt(s *ast.RangeStmt, label string, st flowState) flowState {
+	w.v.visit(s.X, st)
+	st = st.clone()
+	if s.Tok == token.ASSIGN {
+		for _, e := range []ast.Expr{s.Key, s.Value} {
+			if id, ok := e.(*ast.Ident); ok {
+				if v := w.object(id); v != nil {
+					delete(st, v)
+				}
+			}
+		}
+	}
+	entry := "`" + types.ExprString(s.X) + "` is empty"
+	return w.loop(s.Body, nil, nil, label, entry, true, st)
+}
+
+// loop walks a loop body twice, so that state produced at the end of one
+// iteration is seen by the next, and returns the state on loop exit. Loops
+// without a condition can only be left through a break.
+func (w *flowWalker) loop(body *ast.BlockStmt, cond ast.Expr, post ast.Stmt, label, entry string, exits bool, st flowState) flowState {
+	t := w.push(label, true)
+	defer w.pop()
+	head := st
+	for i := 0; i < 2; i++ {
+		t.breaks, t.continues = nil, nil
+		if cond != nil {
+			w.cond(cond, head)
+		}
+		out := w.stmts(body.List, w.refine(cond, true, head.clone()))
+		out = mergeFlow(head, append([]flowBranch{{st: out}}, t.continues...)...)
+		if out != nil && post != nil {
+			out = w.stmt(post, out)
+		}
+		head = mergeFlow(st, flowBranch{st: st.clone(), label: entry}, flowBranch{st: out})
+	}
+	branches := t.breaks
+	if exits {
+		branches = append(branches, flowBranch{st: w.refine(cond, false, head.clone())})
+	}
+	return mergeFlow(head, branches...)
+}
+
+// cond visits a condition as it is evaluated: the right operand of && or
+// || is visited with st refined by the left one's outcome that evaluates
+// it, so that `p != nil && p.X` doesn't count as a use of a nil p.
+func (w *flowWalker) cond(e ast.Expr, st flowState) {
+	if c, ok := ast.Unparen(e).(*ast.BinaryExpr); ok && (c.Op == token.LAND || c.Op == token.LOR) {
+		w.cond(c.X, st)
+		rest := w.refine(c.X, c.Op == token.LAND, st.clone())
+		if rest == nil {
+			return
+		}
+		before := rest.clone()
+		w.cond(c.Y, rest)
+		// Variables the visitor reported and dropped stay dropped.
+		for v := range before {
+			if _, ok := rest[v]; !ok {
+				delete(st, v)
+			}
+		}
+		return
+	}
+	w.v.visit(e, st)
+}
+
+// refine narrows st with what cond being truth implies about nil checks.
+func (w *flowWalker) refine(cond ast.Expr, truth bool, st flowState) flowState {
+	if st == nil || cond == nil {
+		return st
+	}
+	switch c := ast.Unparen(cond).(type) {
+	case *ast.UnaryExpr:
+		if c.Op == token.NOT {
+			return w.refine(c.X, !truth, st)
+		}
+	case *ast.BinaryExpr:
+		switch c.Op {
+		case token.LAND:
+			if truth {
+				st = w.refine(c.X, true, st)
+				st = w.refine(c.Y, true, st)
+			}
+		case token.LOR:
+			if !truth {
+				st = w.refine(c.X, false, st)
+				st = w.refine(c.Y, false, st)
+			}
+		case token.EQL, token.NEQ:
+			if (c.Op == token.EQL) == truth {
+				break
+			}
+			for _, pair := range [][2]ast.Expr{{c.X, c.Y}, {c.Y, c.X}} {
+				id, ok := ast.Unparen(pair[0]).(*ast.Ident)
+				if ok && isNilExpr(w.info, pair[1]) {
+					if v := w.object(id); v != nil {
+						delete(st, v)
+					}
+				}
+			}
+		}
+	}
+	return st
+}
+
+func (w *flowWalker) object(id *ast.Ident) *types.Var {
+	return identVar(w.info, id)
+}
+
+// mergeFlow joins the states at the end of each branch. A variable that may
+// be zero on any branch may be zero afterwards; if a branch left it
+// untouched, that branch's label is recorded as the reason.
+func mergeFlow(before flowState, branches ...flowBranch) flowState {
+	var out flowState
+	for _, b := range branches {
+		if b.st == nil {
+			continue
+		}
+		if out == nil {
+			out = flowState{}
+		}
+	}
+	if out == nil {
+		return nil
+	}
+	for _, b := range branches {
+		if b.st == nil {
+			continue
+		}
+		for v, f := range b.st {
+			if _, done := out[v]; done {
+				continue
+			}
+			if unchangedOnAll(v, before, branches) {
+				out[v] = f
+				continue
+			}
+			if f == before[v] && b.label != "" {
+				f = &zeroFact{decl: f.decl, explicit: f.explicit, param: f.param, cond: b.label, condExpr: b.cond, condTruth: b.truth}
+			}
+			out[v] = f
+		}
+	}
+	return out
+}
+
+func unchangedOnAll(v *types.Var, before flowState, branches []flowBranch) bool {
+	orig, ok := before[v]
+	if !ok {
+		return false
+	}
+	for _, b := range branches {
+		if b.st != nil && b.st[v] != orig {
+			return false
+		}
+	}
+	return true
+}
+
+func caseLabel(cc *ast.CaseClause) string {
+	if cc.List == nil {
+		return "the default switch case runs"
+	}
+	s := ""
+	for i, e := range cc.List {
+		if i > 0 {
+			s += ", "
+		}
+		s += types.ExprString(e)
+	}
+	return "switch case `" + s + "` runs"
+}
+
+// escapedVars returns the variables in body whose address is taken or that
+// are referenced from a function literal; their assignments cannot be
+// followed statement by statement.
+func escapedVars(info *types.Info, body *ast.BlockStmt) map[*types.Var]bool {
+	escaped := map[*types.Var]bool{}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.UnaryExpr:
+			if n.Op == token.AND {
+				if id, ok := ast.Unparen(n.X).(*ast.Ident); ok {
+					if v := identVar(info, id); v != nil {
+						escaped[v] = true
+					}
+				}
+			}
+		case *ast.FuncLit:
+			ast.Inspect(n.Body, func(n ast.Node) bool {
+				if id, ok := n.(*ast.Ident); ok {
+					if v := identVar(info, id); v != nil {
+						escaped[v] = true
+					}
+				}
+				return true
+			})
+			return false
+		}
+		return true
+	})
+	return escaped
+}
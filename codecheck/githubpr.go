@@ -0,0 +1,109 @@
+package codecheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// githubReview is the request body of GitHub's "create a review for a pull
+// request" endpoint.
+type githubReview struct {
+	Event    string          `json:"event"`
+	Body     string          `json:"body"`
+	Comments []githubComment `json:"comments"`
+}
+
+type githubComment struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Side string `json:"side"`
+	Body string `json:"body"`
+}
+
+// WriteGitHubReview writes findings as a pull request review for GitHub's
+// REST API (POST /repos/{owner}/{repo}/pulls/{pull_number}/reviews), with
+// one inline comment per line that has findings. Paths are made relative to
+// root, the repository root. GitHub rejects comments on lines the pull
+// request's diff doesn't show, so if diff is not nil, findings on other
+// lines, or outside root, are listed in the review's body instead.
+func WriteGitHubReview(w io.Writer, findings []Finding, root string, diff *Diff) error {
+	review := githubReview{Event: "COMMENT", Comments: []githubComment{}}
+	var counts [SeverityError + 1]int
+	var outside []string
+	type lineKey struct {
+		path string
+		line int
+	}
+	index := map[lineKey]int{}
+	for _, f := range findings {
+		counts[f.Severity]++
+		path, ok := repoPath(root, f.Position.Filename)
+		if !ok || diff != nil && !diff.InHunk(path, f.Position.Line) {
+			where := f.Position.String()
+			if ok {
+				where = fmt.Sprintf("%s:%d", path, f.Position.Line)
+			}
+			outside = append(outside, fmt.Sprintf("- `%s`: **%s** (`%s`): %s", where, f.Severity, f.Rule, f.Message))
+			continue
+		}
+		key := lineKey{path, f.Position.Line}
+		if i, ok := index[key]; ok {
+			review.Comments[i].Body += "\n\n---\n\n" + githubFindingText(f, root)
+			continue
+		}
+		index[key] = len(review.Comments)
+		review.Comments = append(review.Comments, githubComment{
+			Path: path,
+			Line: f.Position.Line,
+			Side: "RIGHT",
+			Body: githubFindingText(f, root),
+		})
+	}
+
+	var body strings.Builder
+	if len(findings) == 0 {
+		body.WriteString("codecheck found no problems.")
+	} else {
+		var parts []string
+		for sev := SeverityError; sev >= SeverityNote; sev-- {
+			if counts[sev] > 0 {
+				parts = append(parts, plural(counts[sev], sev.String()))
+			}
+		}
+		fmt.Fprintf(&body, "codecheck found %s.", strings.Join(parts, ", "))
+	}
+	if len(outside) > 0 {
+		body.WriteString("\n\nNot on lines shown in this diff:\n\n")
+		body.WriteString(strings.Join(outside, "\n"))
+	}
+	review.Body = body.String()
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(review)
+}
+
+// githubFindingText renders a finding as Markdown for a review comment.
+func githubFindingText(f Finding, root string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s** (`%s`", f.Severity, f.Rule)
+	if f.Confidence != ConfidenceHigh {
+		fmt.Fprintf(&b, ", %s confidence", f.Confidence)
+	}
+	fmt.Fprintf(&b, "): %s", f.Message)
+	for _, r := range f.Related {
+		where := fmt.Sprintf("line %d", r.Position.Line)
+		if r.Position.Filename != f.Position.Filename {
+			if path, ok := repoPath(root, r.Position.Filename); ok {
+				where = fmt.Sprintf("`%s:%d`", path, r.Position.Line)
+			}
+		}
+		fmt.Fprintf(&b, "\n- %s: %s", where, r.Message)
+	}
+	if f.Suggestion != "" {
+		fmt.Fprintf(&b, "\n\nSuggestion: %s", f.Suggestion)
+	}
+	return b.String()
+}
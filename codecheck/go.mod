@@ -0,0 +1,15 @@
+module github.com/shivansh-2003/github-code/codecheck
+
+go 1.26.0
+
+require (
+	github.com/fsnotify/fsnotify v1.10.1
+	golang.org/x/tools v0.50.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	golang.org/x/mod v0.41.0 // indirect
+	golang.org/x/sync v0.23.0 // indirect
+	golang.org/x/sys v0.48.0 // indirect
+)
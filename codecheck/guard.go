@@ -0,0 +1,560 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+)
+
+// linear is an integer expression of the form coef*atom + k, where atom is a
+// len or cap call (and so never negative). An empty atom means the
+// expression is the constant k.
+type linear struct {
+	atom string
+	arg  ast.Expr // the argument of the len or cap call
+	coef int64
+	k    int64
+}
+
+// solveZero returns the non-negative atom value at which l is zero.
+func (l linear) solveZero() (int64, bool) {
+	if l.atom == "" || l.coef == 0 || -l.k%l.coef != 0 {
+		return 0, false
+	}
+	v := -l.k / l.coef
+	return v, v >= 0
+}
+
+func (l linear) at(v int64) int64 { return l.coef*v + l.k }
+
+// arith linearizes integer expressions within one function, following
+// variables that are assigned exactly once.
+type arith struct {
+	info *types.Info
+	defs map[*types.Var]ast.Expr
+}
+
+func newArith(info *types.Info, body *ast.BlockStmt) *arith {
+	defs := map[*types.Var]ast.Expr{}
+	writes := map[*types.Var]int{}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range n.Lhs {
+				v := exprVar(info, lhs)
+				if v == nil {
+					continue
+				}
+				writes[v]++
+				if n.Tok == token.DEFINE && len(n.Lhs) == len(n.Rhs) {
+					defs[v] = n.Rhs[i]
+				}
+			}
+		case *ast.ValueSpec:
+			for i, name := range n.Names {
+				if v := identVar(info, name); v != nil {
+					writes[v]++
+					if len(n.Names) == len(n.Values) {
+						defs[v] = n.Values[i]
+					}
+				}
+			}
+		case *ast.IncDecStmt:
+			if v := exprVar(info, n.X); v != nil {
+				writes[v] += 2
+			}
+		case *ast.UnaryExpr:
+			if n.Op == token.AND {
+				if v := exprVar(info, n.X); v != nil {
+					writes[v] += 2
+				}
+			}
+		case *ast.RangeStmt:
+			for _, e := range []ast.Expr{n.Key, n.Value} {
+				if v := exprVar(info, e); v != nil {
+					writes[v] += 2
+				}
+			}
+		}
+		return true
+	})
+	for v := range defs {
+		if writes[v] != 1 {
+			delete(defs, v)
+		}
+	}
+	return &arith{info: info, defs: defs}
+}
+
+func (a *arith) linearize(e ast.Expr) (linear, bool) {
+	return a.lin(e, 0)
+}
+
+func (a *arith) lin(e ast.Expr, depth int) (linear, bool) {
+	if depth > 8 {
+		return linear{}, false
+	}
+	e = ast.Unparen(e)
+	if tv, ok := a.info.Types[e]; ok && tv.Value != nil {
+		v, exact := constant.Int64Val(constant.ToInt(tv.Value))
+		return linear{k: v}, exact
+	}
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		if e.Kind == token.INT {
+			v, exact := constant.Int64Val(constant.MakeFromLiteral(e.Value, e.Kind, 0))
+			return linear{k: v}, exact
+		}
+	case *ast.Ident:
+		if v := identVar(a.info, e); v != nil {
+			if def, ok := a.defs[v]; ok {
+				return a.lin(def, depth+1)
+			}
+		}
+	case *ast.CallExpr:
+		if isLenOrCap(a.info, e) {
+			return linear{atom: types.ExprString(e), arg: e.Args[0], coef: 1}, true
+		}
+	case *ast.UnaryExpr:
+		x, ok := a.lin(e.X, depth+1)
+		switch {
+		case !ok:
+		case e.Op == token.ADD:
+			return x, true
+		case e.Op == token.SUB:
+			return linear{atom: x.atom, arg: x.arg, coef: -x.coef, k: -x.k}, true
+		}
+	case *ast.BinaryExpr:
+		x, okx := a.lin(e.X, depth+1)
+		y, oky := a.lin(e.Y, depth+1)
+		if !okx || !oky {
+			break
+		}
+		switch e.Op {
+		case token.SUB:
+			y = linear{atom: y.atom, arg: y.arg, coef: -y.coef, k: -y.k}
+			fallthrough
+		case token.ADD:
+			if x.atom != "" && y.atom != "" && x.atom != y.atom {
+				break
+			}
+			atom, arg := x.atom, x.arg
+			if atom == "" {
+				atom, arg = y.atom, y.arg
+			}
+			l := linear{atom: atom, arg: arg, coef: x.coef + y.coef, k: x.k + y.k}
+			if l.coef == 0 {
+				l.atom = ""
+			}
+			return l, true
+		case token.MUL:
+			if x.atom == "" {
+				x, y = y, x
+			}
+			if y.atom == "" {
+				l := linear{atom: x.atom, arg: x.arg, coef: x.coef * y.k, k: x.k * y.k}
+				if l.coef == 0 {
+					l.atom = ""
+				}
+				return l, true
+			}
+		}
+	}
+	return linear{}, false
+}
+
+func isLenOrCap(info *types.Info, call *ast.CallExpr) bool {
+	id, ok := ast.Unparen(call.Fun).(*ast.Ident)
+	if !ok || len(call.Args) != 1 {
+		return false
+	}
+	if b, ok := info.Uses[id].(*types.Builtin); ok {
+		return b.Name() == "len" || b.Name() == "cap"
+	}
+	return false
+}
+
+// tribool is a three-valued truth value for conditions that can only be
+// partly evaluated.
+type tribool int
+
+const (
+	unknown tribool = iota
+	isTrue
+	isFalse
+)
+
+// evalCond evaluates cond assuming atom has value v. Comparisons of anything
+// other than atom and constants are unknown.
+func (a *arith) evalCond(cond ast.Expr, atom string, v int64) tribool {
+	switch c := ast.Unparen(cond).(type) {
+	case *ast.UnaryExpr:
+		if c.Op == token.NOT {
+			switch a.evalCond(c.X, atom, v) {
+			case isTrue:
+				return isFalse
+			case isFalse:
+				return isTrue
+			}
+		}
+	case *ast.BinaryExpr:
+		switch c.Op {
+		case token.LAND, token.LOR:
+			x, y := a.evalCond(c.X, atom, v), a.evalCond(c.Y, atom, v)
+			short, other := isFalse, isTrue
+			if c.Op == token.LOR {
+				short, other = isTrue, isFalse
+			}
+			if x == short || y == short {
+				return short
+			}
+			if x == other && y == other {
+				return other
+			}
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+			if x := a.emptyStringOperand(c); x != nil {
+				// s == "" tests len(s) == 0.
+				if "len("+types.ExprString(x)+")" != atom || c.Op != token.EQL && c.Op != token.NEQ {
+					break
+				}
+				if compare(c.Op, v, 0) {
+					return isTrue
+				}
+				return isFalse
+			}
+			x, okx := a.linearize(c.X)
+			y, oky := a.linearize(c.Y)
+			if !okx || !oky || x.atom != "" && x.atom != atom || y.atom != "" && y.atom != atom {
+				break
+			}
+			if compare(c.Op, x.at(v), y.at(v)) {
+				return isTrue
+			}
+			return isFalse
+		}
+	}
+	return unknown
+}
+
+// emptyStringOperand returns the other operand of a comparison with the
+// empty string constant, or nil.
+func (a *arith) emptyStringOperand(c *ast.BinaryExpr) ast.Expr {
+	empty := func(e ast.Expr) bool {
+		tv, ok := a.info.Types[e]
+		return ok && tv.Value != nil && tv.Value.Kind() == constant.String && constant.StringVal(tv.Value) == ""
+	}
+	switch {
+	case empty(c.Y) && !empty(c.X):
+		return c.X
+	case empty(c.X) && !empty(c.Y):
+		return c.Y
+	}
+	return nil
+}
+
+func compare(op token.Token, x, y int64) bool {
+	switch op {
+	case token.EQL:
+		return x == y
+	case token.NEQ:
+		return x != y
+	case token.LSS:
+		return x < y
+	case token.LEQ:
+		return x <= y
+	case token.GTR:
+		return x > y
+	}
+	return x >= y
+}
+
+// guarded reports whether a condition dominating the innermost node of stack
+// rules out atom, the len or cap of arg, having value v there: an
+// enclosing if or for whose condition must hold (or fail) to reach it, a
+// case of an enclosing switch, the left operand of an enclosing && (or ||)
+// that must hold (or fail) for the right one to be evaluated, an earlier
+// if or switch in an enclosing block that leaves the function or loop in
+// the cases it rules out, or, for a length of 0, an enclosing range over
+// arg, whose body runs only if arg has elements.
+// Conditions that mention atom but cannot be evaluated count as guards.
+// A guard stops counting once arg is assigned between it and the node.
+func (a *arith) guarded(stack []ast.Node, arg ast.Expr, atom string, v int64) bool {
+	excludes := func(cond ast.Expr, want tribool) bool {
+		got := a.evalCond(cond, atom, v)
+		if got == unknown {
+			return mentions(cond, atom)
+		}
+		return got != want
+	}
+	use := stack[len(stack)-1].Pos()
+	// holds reports whether a guard checked at from, in stack[i], still
+	// holds at the use: arg is not assigned in between, nor in a loop
+	// around the use that the guard doesn't check on every iteration.
+	holds := func(i int, from token.Pos, rechecked bool) bool {
+		if a.assigns(stack[i], arg, from, use) {
+			return false
+		}
+		for j := i; j < len(stack)-1; j++ {
+			switch stack[j].(type) {
+			case *ast.ForStmt, *ast.RangeStmt:
+				if (j > i || !rechecked) && a.assigns(stack[j], arg, stack[j].Pos(), stack[j].End()) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	for i := len(stack) - 2; i >= 0; i-- {
+		child := stack[i+1]
+		switch p := stack[i].(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+			return false
+		case *ast.IfStmt:
+			if child == p.Body && excludes(p.Cond, isTrue) && holds(i, p.Cond.End(), false) {
+				return true
+			}
+			if child == p.Else && excludes(p.Cond, isFalse) && holds(i, p.Cond.End(), false) {
+				return true
+			}
+		case *ast.ForStmt:
+			if child == p.Body && p.Cond != nil && excludes(p.Cond, isTrue) && holds(i, p.Body.Pos(), true) {
+				return true
+			}
+		case *ast.RangeStmt:
+			if child == p.Body && v == 0 && atom == "len("+types.ExprString(p.X)+")" && rangesOverElements(a.info, p.X) && holds(i, p.X.End(), false) {
+				return true
+			}
+		case *ast.BinaryExpr:
+			if child == p.Y && p.Op == token.LAND && excludes(p.X, isTrue) && holds(i, p.X.End(), false) {
+				return true
+			}
+			if child == p.Y && p.Op == token.LOR && excludes(p.X, isFalse) && holds(i, p.X.End(), false) {
+				return true
+			}
+		case *ast.BlockStmt:
+			for _, s := range p.List {
+				if s == child {
+					break
+				}
+				switch s := s.(type) {
+				case *ast.IfStmt:
+					if s.Else == nil && terminates(a.info, s.Body) && excludes(s.Cond, isFalse) && holds(i, s.End(), false) {
+						return true
+					}
+				case *ast.SwitchStmt:
+					if cond := switchExit(s, a.info); cond != nil && excludes(cond, isTrue) && holds(i, s.End(), false) {
+						return true
+					}
+				}
+			}
+		case *ast.CaseClause:
+			if i < 2 {
+				break
+			}
+			if body, ok := stack[i-1].(*ast.BlockStmt); ok {
+				if sw, ok := stack[i-2].(*ast.SwitchStmt); ok && body == sw.Body {
+					if cond := clauseCond(sw, p); cond != nil && excludes(cond, isTrue) && holds(i, p.Colon, false) {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// rangesOverElements reports whether a range over x runs its body once per
+// element of x, so that x has some in the body.
+func rangesOverElements(info *types.Info, x ast.Expr) bool {
+	t := info.TypeOf(x)
+	if t == nil {
+		return false
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Slice, *types.Array, *types.Map:
+		return true
+	case *types.Basic:
+		return u.Info()&types.IsString != 0
+	}
+	return false
+}
+
+// clauseCond returns the condition under which cc, a clause of sw, is
+// entered, built from the tag and case expressions, or nil for a default
+// clause with no other cases.
+func clauseCond(sw *ast.SwitchStmt, cc *ast.CaseClause) ast.Expr {
+	if cc.List == nil {
+		// default: none of the other cases matched.
+		var cond ast.Expr
+		for _, s := range sw.Body.List {
+			if other, ok := s.(*ast.CaseClause); ok && other.List != nil {
+				cond = both(cond, &ast.UnaryExpr{Op: token.NOT, X: &ast.ParenExpr{X: clauseCond(sw, other)}})
+			}
+		}
+		return cond
+	}
+	var cond ast.Expr
+	for _, e := range cc.List {
+		if sw.Tag != nil {
+			e = &ast.BinaryExpr{X: sw.Tag, Op: token.EQL, Y: e}
+		}
+		if cond == nil {
+			cond = e
+		} else {
+			cond = &ast.BinaryExpr{X: cond, Op: token.LOR, Y: e}
+		}
+	}
+	return cond
+}
+
+// switchExit returns the condition under which execution continues after
+// sw: a clause was entered whose body doesn't leave the function or loop,
+// or, without a default clause, none was. It returns nil if that is
+// always so.
+func switchExit(sw *ast.SwitchStmt, info *types.Info) ast.Expr {
+	var cond ast.Expr
+	hasDefault := false
+	for _, s := range sw.Body.List {
+		cc, ok := s.(*ast.CaseClause)
+		if !ok {
+			continue
+		}
+		if cc.List == nil {
+			hasDefault = true
+		}
+		body := &ast.BlockStmt{List: cc.Body}
+		if terminates(info, body) && !endsSwitch(body) {
+			continue
+		}
+		c := clauseCond(sw, cc)
+		if c == nil {
+			return nil
+		}
+		if cond == nil {
+			cond = c
+		} else {
+			cond = &ast.BinaryExpr{X: cond, Op: token.LOR, Y: c}
+		}
+	}
+	if !hasDefault {
+		var none ast.Expr
+		for _, s := range sw.Body.List {
+			if cc, ok := s.(*ast.CaseClause); ok && cc.List != nil {
+				none = both(none, &ast.UnaryExpr{Op: token.NOT, X: &ast.ParenExpr{X: clauseCond(sw, cc)}})
+			}
+		}
+		if none == nil {
+			return nil
+		}
+		if cond == nil {
+			return none
+		}
+		cond = &ast.BinaryExpr{X: cond, Op: token.LOR, Y: none}
+	}
+	return cond
+}
+
+// endsSwitch reports whether body ends with a break out of the switch it
+// is a clause of, which terminates counts as leaving a loop.
+func endsSwitch(body *ast.BlockStmt) bool {
+	b, ok := body.List[len(body.List)-1].(*ast.BranchStmt)
+	return ok && b.Tok == token.BREAK && b.Label == nil
+}
+
+// both returns x && y, or y if x is nil.
+func both(x, y ast.Expr) ast.Expr {
+	if x == nil {
+		return y
+	}
+	return &ast.BinaryExpr{X: x, Op: token.LAND, Y: y}
+}
+
+// assigns reports whether n assigns arg, or takes its address, at a
+// position in [from, to).
+func (a *arith) assigns(n ast.Node, arg ast.Expr, from, to token.Pos) bool {
+	v := exprVar(a.info, arg)
+	name := types.ExprString(arg)
+	is := func(e ast.Expr) bool {
+		if e == nil {
+			return false
+		}
+		if v != nil {
+			return exprVar(a.info, e) == v
+		}
+		return types.ExprString(e) == name
+	}
+	found := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		if found || n == nil || n.End() <= from || n.Pos() >= to {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				if is(lhs) && lhs.Pos() >= from {
+					found = true
+				}
+			}
+		case *ast.RangeStmt:
+			if n.Tok == token.ASSIGN && n.Pos() >= from && (is(n.Key) || is(n.Value)) {
+				found = true
+			}
+		case *ast.UnaryExpr:
+			if n.Op == token.AND && n.Pos() >= from && is(n.X) {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+func mentions(e ast.Expr, s string) bool {
+	found := false
+	ast.Inspect(e, func(n ast.Node) bool {
+		if x, ok := n.(ast.Expr); ok && types.ExprString(x) == s {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// terminates reports whether block always ends by leaving the enclosing
+// function or loop iteration.
+func terminates(info *types.Info, block *ast.BlockStmt) bool {
+	if len(block.List) == 0 {
+		return false
+	}
+	switch s := block.List[len(block.List)-1].(type) {
+	case *ast.ReturnStmt:
+		return true
+	case *ast.BranchStmt:
+		return s.Tok == token.BREAK || s.Tok == token.CONTINUE || s.Tok == token.GOTO
+	case *ast.ExprStmt:
+		call, ok := ast.Unparen(s.X).(*ast.CallExpr)
+		return ok && isNoReturn(info, call)
+	case *ast.BlockStmt:
+		return terminates(info, s)
+	}
+	return false
+}
+
+// inspectStack is ast.Inspect that also passes the path from root to n
+// (inclusive) to fn.
+func inspectStack(root ast.Node, fn func(n ast.Node, stack []ast.Node) bool) {
+	var stack []ast.Node
+	ast.Inspect(root, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return true
+		}
+		stack = append(stack, n)
+		if !fn(n, stack) {
+			stack = stack[:len(stack)-1]
+			return false
+		}
+		return true
+	})
+}
@@ -0,0 +1,287 @@
+package codecheck
+
+import (
+	"bytes"
+	"go/scanner"
+	"go/token"
+	"html"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// htmlContext is the number of source lines shown before and after the
+// line of each finding in HTML reports.
+const htmlContext = 3
+
+type htmlReport struct {
+	Version    string
+	Total      int
+	Severities []htmlCount
+	Rules      []htmlCount
+	Files      []htmlFile
+}
+
+type htmlCount struct {
+	Name     string
+	Severity string // for rules, the highest severity they were reported at
+	Count    int
+}
+
+type htmlFile struct {
+	Name     string
+	Findings []htmlFinding
+}
+
+type htmlFinding struct {
+	Finding
+	Message template.HTML
+	Related []htmlRelated
+	Snippet []htmlLine
+}
+
+type htmlRelated struct {
+	Position token.Position
+	Message  template.HTML
+}
+
+type htmlLine struct {
+	Number  int
+	Code    template.HTML
+	Flagged bool
+}
+
+// WriteHTML writes findings as a self-contained HTML page: a summary of
+// the counts per severity and per rule, then the findings grouped by file,
+// each with the surrounding source lines. The source is read from the
+// findings' file names; findings whose file cannot be read are listed
+// without a snippet.
+func WriteHTML(w io.Writer, findings []Finding) error {
+	report := htmlReport{Version: Version, Total: len(findings)}
+	bySev := map[Severity]int{}
+	byRule := map[string]*htmlCount{}
+	ruleSev := map[string]Severity{}
+	files := map[string]*htmlFile{}
+	var names []string
+	lines := map[string][]string{}
+	for _, f := range findings {
+		bySev[f.Severity]++
+		c := byRule[f.Rule]
+		if c == nil {
+			c = &htmlCount{Name: f.Rule}
+			byRule[f.Rule] = c
+			ruleSev[f.Rule] = f.Severity
+		}
+		c.Count++
+		ruleSev[f.Rule] = max(ruleSev[f.Rule], f.Severity)
+
+		name := f.Position.Filename
+		hf := files[name]
+		if hf == nil {
+			hf = &htmlFile{Name: name}
+			files[name] = hf
+			names = append(names, name)
+			if src, err := os.ReadFile(name); err == nil {
+				lines[name] = highlightGo(src)
+			}
+		}
+		hf.Findings = append(hf.Findings, htmlFinding{
+			Finding: f,
+			Message: codeSpans(f.Message),
+			Related: htmlRelatedOf(f.Related),
+			Snippet: snippet(lines[name], f.Position.Line, f.End.Line),
+		})
+	}
+	for _, sev := range []Severity{SeverityError, SeverityWarning, SeverityNote} {
+		if bySev[sev] > 0 {
+			report.Severities = append(report.Severities, htmlCount{Name: sev.String(), Severity: sev.String(), Count: bySev[sev]})
+		}
+	}
+	for rule, c := range byRule {
+		c.Severity = ruleSev[rule].String()
+		report.Rules = append(report.Rules, *c)
+	}
+	sort.Slice(report.Rules, func(i, j int) bool {
+		a, b := report.Rules[i], report.Rules[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Name < b.Name
+	})
+	sort.Strings(names)
+	for _, name := range names {
+		report.Files = append(report.Files, *files[name])
+	}
+	return htmlTemplate.Execute(w, report)
+}
+
+func htmlRelatedOf(related []Related) []htmlRelated {
+	var out []htmlRelated
+	for _, r := range related {
+		out = append(out, htmlRelated{Position: r.Position, Message: codeSpans(r.Message)})
+	}
+	return out
+}
+
+// codeSpans escapes a finding message and renders its `quoted` parts as
+// code.
+func codeSpans(msg string) template.HTML {
+	parts := strings.Split(msg, "`")
+	var b strings.Builder
+	for i, p := range parts {
+		p = html.EscapeString(p)
+		if i%2 == 1 && i < len(parts)-1 {
+			b.WriteString("<code>" + p + "</code>")
+		} else {
+			if i%2 == 1 {
+				b.WriteString("`")
+			}
+			b.WriteString(p)
+		}
+	}
+	return template.HTML(b.String())
+}
+
+// snippet returns the lines from first to last of a highlighted file, with
+// htmlContext lines around them.
+func snippet(lines []string, first, last int) []htmlLine {
+	if len(lines) == 0 || first < 1 {
+		return nil
+	}
+	if last < first {
+		last = first
+	}
+	from := max(first-htmlContext, 1)
+	to := min(last+htmlContext, len(lines))
+	var out []htmlLine
+	for n := from; n <= to; n++ {
+		out = append(out, htmlLine{
+			Number:  n,
+			Code:    template.HTML(lines[n-1]),
+			Flagged: n >= first && n <= last,
+		})
+	}
+	return out
+}
+
+// highlightGo returns the lines of src as escaped HTML, with keywords,
+// literals and comments wrapped in spans. Tokens spanning several lines,
+// like raw strings and block comments, are split at line ends.
+func highlightGo(src []byte) []string {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s scanner.Scanner
+	s.Init(file, src, nil, scanner.ScanComments)
+	var b bytes.Buffer
+	last := 0
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		class := ""
+		switch {
+		case tok.IsKeyword():
+			class = "kw"
+		case tok == token.STRING || tok == token.CHAR:
+			class = "str"
+		case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+			class = "num"
+		case tok == token.COMMENT:
+			class = "com"
+		}
+		if class == "" {
+			continue
+		}
+		off := file.Offset(pos)
+		text := lit
+		if tok.IsKeyword() {
+			text = tok.String()
+		}
+		if off < last {
+			continue
+		}
+		// The scanner drops carriage returns from comments and raw
+		// strings, so the token may be longer in src than text.
+		end := off
+		for i := 0; i < len(text) && end < len(src); end++ {
+			if src[end] == text[i] {
+				i++
+			} else if src[end] != '\r' {
+				break
+			}
+		}
+		b.WriteString(html.EscapeString(string(src[last:off])))
+		for i, part := range strings.Split(string(src[off:end]), "\n") {
+			if i > 0 {
+				b.WriteByte('\n')
+			}
+			if part = strings.TrimSuffix(part, "\r"); part != "" {
+				b.WriteString(`<span class="` + class + `">` + html.EscapeString(part) + `</span>`)
+			}
+		}
+		last = end
+	}
+	b.WriteString(html.EscapeString(string(src[last:])))
+	out := strings.ReplaceAll(b.String(), "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>codecheck report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #1f2328; }
+h1 { font-size: 1.6em; }
+h2 { font-size: 1.2em; margin-top: 2em; border-bottom: 1px solid #d0d7de; padding-bottom: .3em; }
+table.summary { border-collapse: collapse; margin: 1em 2em 1em 0; display: inline-table; vertical-align: top; }
+table.summary th, table.summary td { border: 1px solid #d0d7de; padding: .3em .8em; text-align: left; }
+table.summary td.count { text-align: right; }
+.finding { margin: 1em 0 2em; }
+.sev { display: inline-block; border-radius: 1em; padding: 0 .6em; color: #fff; font-size: .85em; font-weight: 600; }
+.sev.error { background: #cf222e; }
+.sev.warning { background: #bf8700; }
+.sev.note { background: #0969da; }
+.rule, .pos { color: #656d76; font-size: .9em; }
+.related, .suggestion { margin: .3em 0 0 1.5em; font-size: .95em; }
+code, pre { font-family: ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; font-size: .9em; }
+code { background: #eff1f3; padding: 0 .2em; border-radius: 3px; }
+pre { background: #f6f8fa; border: 1px solid #d0d7de; border-radius: 6px; padding: .5em 0; overflow-x: auto; margin: .5em 0 0; }
+pre .line { display: block; padding: 0 1em; white-space: pre; }
+pre .line.flagged { background: #ffebe9; }
+pre .num-col { display: inline-block; width: 3.5em; color: #8c959f; user-select: none; }
+.kw { color: #cf222e; }
+.str { color: #0a3069; }
+.num { color: #0550ae; }
+.com { color: #6e7781; font-style: italic; }
+</style>
+</head>
+<body>
+<h1>codecheck report</h1>
+<p>{{.Total}} finding{{if ne .Total 1}}s{{end}} in {{len .Files}} file{{if ne (len .Files) 1}}s{{end}}, codecheck {{.Version}}.</p>
+{{if .Total}}
+<table class="summary">
+<tr><th>Severity</th><th>Findings</th></tr>
+{{range .Severities}}<tr><td><span class="sev {{.Severity}}">{{.Name}}</span></td><td class="count">{{.Count}}</td></tr>
+{{end}}</table>
+<table class="summary">
+<tr><th>Rule</th><th>Findings</th></tr>
+{{range .Rules}}<tr><td><span class="sev {{.Severity}}">{{.Name}}</span></td><td class="count">{{.Count}}</td></tr>
+{{end}}</table>
+{{end}}
+{{range .Files}}
+<h2>{{.Name}}</h2>
+{{range .Findings}}<div class="finding">
+<div><span class="sev {{.Severity}}">{{.Severity}}</span> {{.Message}} <span class="rule">({{.Rule}}{{if ne .Confidence.String "high"}}, {{.Confidence}} confidence{{end}})</span></div>
+<div class="pos">{{.Position}}</div>
+{{range .Related}}<div class="related">note: {{.Message}} <span class="pos">{{.Position}}</span></div>
+{{end}}{{if .Suggestion}}<div class="suggestion">suggestion: {{.Suggestion}}</div>
+{{end}}{{if .Snippet}}<pre>{{range .Snippet}}<span class="line{{if .Flagged}} flagged{{end}}"><span class="num-col">{{.Number}}</span>{{.Code}}</span>{{end}}</pre>
+{{end}}</div>
+{{end}}{{end}}</body>
+</html>
+`))
@@ -0,0 +1,53 @@
+package codecheck
+
+import (
+	"bytes"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestHTMLEscaping checks that WriteHTML escapes the messages, suggestions
+// and source lines it includes, so that neither can inject markup.
+func TestHTMLEscaping(t *testing.T) {
+	src := "package p\n\n// <script>alert(1)</script> & co\nvar s = \"<script>\" + `&amp;`\n\nfunc f(a, b int) bool { return a < b && b > a }\n"
+	path := filepath.Join(t.TempDir(), "p.go")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f := Finding{
+		Rule:       "printf",
+		Severity:   SeverityWarning,
+		Position:   token.Position{Filename: path, Line: 4, Column: 9},
+		Message:    "`<script>` & <b>bold</b>",
+		Suggestion: "write <script> & friends",
+		Related:    []Related{{Position: token.Position{Filename: path, Line: 6, Column: 1}, Message: "<img src=x onerror=alert(1)>"}},
+	}
+	var b bytes.Buffer
+	if err := WriteHTML(&b, []Finding{f}); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, raw := range []string{"<script", "<b>", "<img", "&amp;`", "a < b"} {
+		if strings.Contains(out, raw) {
+			t.Errorf("output contains unescaped %q", raw)
+		}
+	}
+	for _, escaped := range []string{
+		"<code>&lt;script&gt;</code> &amp; &lt;b&gt;bold&lt;/b&gt;",
+		"write &lt;script&gt; &amp; friends",
+		"note: &lt;img src=x onerror=alert(1)&gt;",
+		"&lt;script&gt;alert(1)&lt;/script&gt; &amp; co",
+		"&amp;amp;",
+		"a &lt; b &amp;&amp; b &gt; a",
+	} {
+		if !strings.Contains(out, escaped) {
+			t.Errorf("output lacks %q", escaped)
+		}
+	}
+	if t.Failed() {
+		t.Log(out)
+	}
+}
@@ -0,0 +1,260 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// ignorePrefix starts a directive that suppresses findings, written
+// without a space after the slashes like other Go directives:
+//
+//	//codecheck:ignore sql-injection,div-by-zero optional reason
+//	//codecheck:ignore
+//
+// The first form suppresses the listed rules, the second every rule. A
+// directive on a line of its own applies to the statement starting on the
+// next line; one at the end of a line applies to the statement starting on
+// that line. For if, for, switch and select statements and function
+// declarations only the header is covered, not the body.
+const ignorePrefix = "//codecheck:ignore"
+
+// UnusedIgnoreRule is the rule id of the warning reported for an ignore
+// directive that suppresses nothing.
+const UnusedIgnoreRule = "unused-ignore"
+
+var unusedIgnoreRule = Rule{
+	ID:              UnusedIgnoreRule,
+	Description:     "Ignore directive that suppresses no finding",
+	DefaultSeverity: SeverityWarning,
+	Doc: RuleDoc{
+		Rationale: `A //codecheck:ignore directive that no longer matches a finding hides nothing today, but it will hide whatever the covered code is changed into tomorrow. Directives outlive the problems they were written for; removing the stale ones keeps every remaining directive meaningful. A directive naming a rule that doesn't exist is reported too, as it is usually a typo.`,
+		Example: `//codecheck:ignore ignored-error
+n := len(s)`,
+		Fix: `n := len(s)`,
+	},
+}
+
+type ignoreDirective struct {
+	comment    *ast.Comment
+	rules      []string // empty means every rule
+	file       string
+	start, end int // byte offsets of the covered code
+	used       bool
+}
+
+// covers reports whether d suppresses f.
+func (d *ignoreDirective) covers(f Finding) bool {
+	if f.Position.Filename != d.file || f.Position.Offset < d.start || f.Position.Offset >= d.end {
+		return false
+	}
+	if len(d.rules) == 0 {
+		return true
+	}
+	for _, r := range d.rules {
+		if r == f.Rule {
+			return true
+		}
+	}
+	return false
+}
+
+// applyIgnores drops the findings covered by an ignore directive in ctx's
+// files and returns the rest, together with a warning for each directive
+// that covered nothing, unless the unused-ignore rule is disabled.
+// Directives naming only rules that are disabled are not reported, as they
+// may be needed when the rule is turned back on.
+func (a *Analyzer) applyIgnores(ctx *Context, findings []Finding) []Finding {
+	directives := ignoreDirectives(ctx)
+	if len(directives) == 0 {
+		return findings
+	}
+	kept := findings[:0]
+	for _, f := range findings {
+		suppressed := false
+		for _, d := range directives {
+			if d.covers(f) {
+				d.used = true
+				suppressed = true
+			}
+		}
+		if !suppressed {
+			kept = append(kept, f)
+		}
+	}
+	if !*a.config.Rules[UnusedIgnoreRule].Enabled {
+		return kept
+	}
+	known := map[string]bool{}
+	for id := range a.config.Rules {
+		known[id] = true
+	}
+	enabled := map[string]bool{}
+	for _, d := range a.detectors {
+		enabled[d.Name()] = true
+	}
+	var unused []Finding
+	for _, d := range directives {
+		if d.used {
+			continue
+		}
+		var unknown []string
+		active := len(d.rules) == 0
+		for _, r := range d.rules {
+			if !known[r] {
+				unknown = append(unknown, r)
+			}
+			if enabled[r] {
+				active = true
+			}
+		}
+		switch {
+		case len(unknown) > 0:
+			unused = append(unused, ctx.NewFinding(UnusedIgnoreRule, SeverityWarning, d.comment,
+				"ignore directive names unknown rule %s", strings.Join(quoteAll(unknown), ", ")))
+		case active:
+			unused = append(unused, ctx.NewFinding(UnusedIgnoreRule, SeverityWarning, d.comment,
+				"ignore directive `%s` does not suppress any finding", d.comment.Text))
+		}
+	}
+	if sev, ok := a.severityOverride(UnusedIgnoreRule); ok {
+		for i := range unused {
+			unused[i].Severity = sev
+		}
+	}
+	fingerprint(ctx, unused)
+	return append(kept, unused...)
+}
+
+// ignoreDirectives finds the ignore directives in ctx's files and the code
+// each one covers.
+func ignoreDirectives(ctx *Context) []*ignoreDirective {
+	var directives []*ignoreDirective
+	for _, file := range ctx.Files {
+		tf := ctx.Fset.File(file.Pos())
+		if tf == nil {
+			continue
+		}
+		src := ctx.sources[tf.Name()]
+		for _, cg := range file.Comments {
+			for _, c := range cg.List {
+				rules, ok := parseIgnore(c.Text)
+				if !ok {
+					continue
+				}
+				d := &ignoreDirective{comment: c, rules: rules, file: tf.Name()}
+				line := tf.Line(c.Pos())
+				if startsLine(tf, src, c.Pos()) {
+					// The directive may be followed by more comment lines,
+					// for example an explanation.
+					line = tf.Line(cg.End()) + 1
+				}
+				d.start, d.end = coveredRange(tf, file, line)
+				directives = append(directives, d)
+			}
+		}
+	}
+	return directives
+}
+
+// parseIgnore returns the rules an ignore directive comment names.
+func parseIgnore(text string) ([]string, bool) {
+	rest, ok := strings.CutPrefix(text, ignorePrefix)
+	if !ok || rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return nil, false
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return nil, true
+	}
+	var rules []string
+	for _, r := range strings.Split(fields[0], ",") {
+		if r != "" {
+			rules = append(rules, r)
+		}
+	}
+	return rules, true
+}
+
+// startsLine reports whether only white space precedes pos on its line.
+func startsLine(tf *token.File, src []byte, pos token.Pos) bool {
+	off := tf.Offset(pos)
+	if src == nil || off > len(src) {
+		return true
+	}
+	for i := off - 1; i >= 0 && src[i] != '\n'; i-- {
+		if src[i] != ' ' && src[i] != '\t' {
+			return false
+		}
+	}
+	return true
+}
+
+// coveredRange returns the byte offsets of the outermost statement or
+// declaration starting on line, or of the whole line if there is none.
+func coveredRange(tf *token.File, file *ast.File, line int) (int, int) {
+	var start, end token.Pos
+	ast.Inspect(file, func(n ast.Node) bool {
+		if n == nil || start.IsValid() {
+			return false
+		}
+		switch n.(type) {
+		case ast.Stmt, ast.Decl, ast.Spec:
+		default:
+			return true
+		}
+		if tf.Line(n.End()) < line || tf.Line(n.Pos()) > line {
+			return false
+		}
+		if tf.Line(n.Pos()) != line {
+			return true
+		}
+		if l, ok := n.(*ast.LabeledStmt); ok {
+			n = l.Stmt
+		}
+		start, end = n.Pos(), headerEnd(n)
+		return false
+	})
+	if start.IsValid() {
+		return tf.Offset(start), tf.Offset(end)
+	}
+	if line < 1 || line > tf.LineCount() {
+		return 0, 0
+	}
+	lineStart := tf.Offset(tf.LineStart(line))
+	if line == tf.LineCount() {
+		return lineStart, tf.Size()
+	}
+	return lineStart, tf.Offset(tf.LineStart(line + 1))
+}
+
+// headerEnd returns the end of the part of n a directive covers: up to the
+// opening brace of the body for compound statements and functions, all of
+// n otherwise.
+func headerEnd(n ast.Node) token.Pos {
+	var body *ast.BlockStmt
+	switch n := n.(type) {
+	case *ast.IfStmt:
+		body = n.Body
+	case *ast.ForStmt:
+		body = n.Body
+	case *ast.RangeStmt:
+		body = n.Body
+	case *ast.SwitchStmt:
+		body = n.Body
+	case *ast.TypeSwitchStmt:
+		body = n.Body
+	case *ast.SelectStmt:
+		body = n.Body
+	case *ast.FuncDecl:
+		body = n.Body
+	case *ast.CaseClause:
+		return n.Colon
+	case *ast.CommClause:
+		return n.Colon
+	}
+	if body != nil {
+		return body.Lbrace
+	}
+	return n.End()
+}
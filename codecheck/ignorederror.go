@@ -0,0 +1,193 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// IgnoredErrorDetector reports calls whose error result is assigned to the
+// blank identifier, as in `db, _ := sql.Open(...)`. Results are matched by
+// type, so any result implementing error counts regardless of its name.
+type IgnoredErrorDetector struct {
+	// Allow lists functions whose errors may be discarded, by the name
+	// types.Func.FullName reports: "fmt.Fprintf", "(*bytes.Buffer).Write".
+	Allow []string
+}
+
+func (IgnoredErrorDetector) Name() string { return "ignored-error" }
+
+func (IgnoredErrorDetector) Description() string {
+	return "Error result discarded with the blank identifier"
+}
+
+func (IgnoredErrorDetector) DefaultSeverity() Severity { return SeverityWarning }
+
+func (IgnoredErrorDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: "Assigning an error to `_` throws away the only sign that the call failed; the other results are then used as if it had succeeded, and the failure surfaces later, somewhere unrelated, if at all.",
+		Example:   `db, _ := sql.Open("postgres", dsn)`,
+		Fix: `db, err := sql.Open("postgres", dsn)
+if err != nil {
+	return err
+}`,
+	}
+}
+
+var errorType = types.Universe.Lookup("error").Type().Underlying().(*types.Interface)
+
+func (d IgnoredErrorDetector) Check(ctx *Context) []Finding {
+	allowed := map[string]bool{}
+	for _, name := range d.Allow {
+		allowed[name] = true
+	}
+	var findings []Finding
+	check := func(lhs []*ast.Ident, rhs []ast.Expr, stmt ast.Node, stack []ast.Node) {
+		if len(rhs) != 1 {
+			return
+		}
+		call, ok := ast.Unparen(rhs[0]).(*ast.CallExpr)
+		if !ok {
+			return
+		}
+		results := callResults(ctx.Info, call)
+		if results == nil || results.Len() != len(lhs) {
+			return
+		}
+		name := types.ExprString(call.Fun)
+		if fn := calleeFunc(ctx.Info, call); fn != nil {
+			if allowed[fn.FullName()] {
+				return
+			}
+			name = fn.FullName()
+		}
+		for i, id := range lhs {
+			if id == nil || id.Name != "_" || !types.Implements(results.At(i).Type(), errorType) {
+				continue
+			}
+			f := ctx.NewFinding(d.Name(), SeverityWarning, id,
+				"error returned by %s is discarded in `%s`", name, types.ExprString(call))
+			if as, ok := stmt.(*ast.AssignStmt); ok {
+				f.Fix = handleErrorFix(ctx, as, id, name, stack)
+			}
+			findings = append(findings, f)
+		}
+	}
+	for _, f := range ctx.Files {
+		inspectStack(f, func(n ast.Node, stack []ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				lhs := make([]*ast.Ident, len(n.Lhs))
+				for i, e := range n.Lhs {
+					lhs[i], _ = ast.Unparen(e).(*ast.Ident)
+				}
+				check(lhs, n.Rhs, n, stack)
+			case *ast.ValueSpec:
+				check(n.Names, n.Values, n, stack)
+			}
+			return true
+		})
+	}
+	return findings
+}
+
+// handleErrorFix rewrites `x, _ := f()`, where blank is the `_` receiving
+// the error, to assign the error to err and return it:
+//
+//	x, err := f()
+//	if err != nil {
+//		// TODO: handle the error
+//		return ..., err
+//	}
+//
+// The fix is safe when the enclosing function's last result is an error;
+// otherwise it panics instead and is left for someone to review. stack is
+// the path from the file to as.
+func handleErrorFix(ctx *Context, as *ast.AssignStmt, blank *ast.Ident, callee string, stack []ast.Node) *SuggestedFix {
+	if as.Tok != token.DEFINE || len(stack) < 2 {
+		return nil
+	}
+	if _, ok := stack[len(stack)-2].(*ast.BlockStmt); !ok {
+		return nil
+	}
+	var sig *ast.FuncType
+	for i := len(stack) - 2; i >= 0 && sig == nil; i-- {
+		switch fn := stack[i].(type) {
+		case *ast.FuncDecl:
+			sig = fn.Type
+		case *ast.FuncLit:
+			sig = fn.Type
+		}
+	}
+	if sig == nil {
+		return nil
+	}
+	// err must be free for an error in the statement's scope; if it is
+	// already declared there, it must be an error so that := reuses it.
+	if ctx.Pkg != nil {
+		if scope := ctx.Pkg.Scope().Innermost(as.Pos()); scope != nil {
+			if obj := scope.Lookup("err"); obj != nil && !isError(obj.Type()) {
+				return nil
+			}
+		}
+	}
+	for _, lhs := range as.Lhs {
+		if id, ok := ast.Unparen(lhs).(*ast.Ident); ok && id != blank && id.Name == "err" {
+			return nil
+		}
+	}
+
+	fix := &SuggestedFix{Message: "check the error returned by " + callee}
+	body := "// TODO: handle the error\n"
+	var results []string
+	ok := sig.Results != nil && len(sig.Results.List) > 0
+	if ok {
+		for _, field := range sig.Results.List {
+			n := max(len(field.Names), 1)
+			for range n {
+				t := ctx.Info.TypeOf(field.Type)
+				if t == nil {
+					ok = false
+					break
+				}
+				results = append(results, "")
+				if z, zok := zeroValue(ctx, t); zok {
+					results[len(results)-1] = z
+				} else {
+					ok = false
+				}
+			}
+		}
+		last := sig.Results.List[len(sig.Results.List)-1]
+		ok = ok && isError(ctx.Info.TypeOf(last.Type))
+	}
+	if ok {
+		results[len(results)-1] = "err"
+		body += "return " + strings.Join(results, ", ")
+		fix.Message += " and return it"
+		fix.Safe = true
+	} else {
+		body += "panic(err)"
+		fix.Message += "; the function does not return an error, so decide how to handle it"
+	}
+	fix.Edits = []TextEdit{
+		ctx.edit(blank.Pos(), blank.End(), "err"),
+		ctx.edit(as.End(), as.End(), "\nif err != nil {\n"+body+"\n}"),
+	}
+	return fix
+}
+
+// callResults returns the result types of call, or nil if it is not a
+// function call with a known signature.
+func callResults(info *types.Info, call *ast.CallExpr) *types.Tuple {
+	tv, ok := info.Types[call.Fun]
+	if !ok || tv.IsType() {
+		return nil
+	}
+	sig, ok := tv.Type.Underlying().(*types.Signature)
+	if !ok {
+		return nil
+	}
+	return sig.Results()
+}
@@ -0,0 +1,290 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+)
+
+// IndexBoundsDetector reports slice and string index expressions that can
+// be out of range: a constant index or slice bound with no dominating
+// length check, and a loop variable used to index a different slice than
+// the one bounding the loop. When a call site passes an empty (or too
+// short) literal for the indexed parameter the finding is raised to an
+// error and points at that call site.
+type IndexBoundsDetector struct{}
+
+func (IndexBoundsDetector) Name() string { return "index-bounds" }
+
+func (IndexBoundsDetector) Description() string {
+	return "Slice or string index that can be out of range"
+}
+
+func (IndexBoundsDetector) DefaultSeverity() Severity { return SeverityWarning }
+
+func (IndexBoundsDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: "Indexing a slice or string past its length panics. A constant index such as `args[1]` assumes a length nothing has checked, and a loop bounded by one slice but indexing another assumes the two have the same length.",
+		Example:   `name := args[1]`,
+		Fix: `if len(args) < 2 {
+	return errors.New("usage: cmd name")
+}
+name := args[1]`,
+	}
+}
+
+func (d IndexBoundsDetector) Check(ctx *Context) []Finding {
+	var findings []Finding
+	for _, f := range ctx.Files {
+		for _, decl := range f.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Body == nil {
+				continue
+			}
+			ar := newArith(ctx.Info, fd.Body)
+			inspectStack(fd.Body, func(n ast.Node, stack []ast.Node) bool {
+				var finding *Finding
+				switch n := n.(type) {
+				case *ast.IndexExpr:
+					finding = d.checkIndex(ctx, ar, fd, n, stack)
+				case *ast.SliceExpr:
+					finding = d.checkSlice(ctx, ar, fd, n, stack)
+				}
+				if finding != nil {
+					findings = append(findings, *finding)
+				}
+				return true
+			})
+		}
+	}
+	return findings
+}
+
+func (d IndexBoundsDetector) checkIndex(ctx *Context, ar *arith, fd *ast.FuncDecl, n *ast.IndexExpr, stack []ast.Node) *Finding {
+	if !isSliceOrString(ctx.Info, n.X) {
+		return nil
+	}
+	if l, ok := ar.linearize(n.Index); ok && l.atom == "" {
+		// Indexing with c panics whenever len <= c.
+		return d.checkConst(ctx, ar, fd, n, n.X, l.k, stack)
+	}
+	return d.checkLoopIndex(ctx, n, stack)
+}
+
+func (d IndexBoundsDetector) checkSlice(ctx *Context, ar *arith, fd *ast.FuncDecl, n *ast.SliceExpr, stack []ast.Node) *Finding {
+	if n.Low == nil || !isSliceOrString(ctx.Info, n.X) {
+		return nil
+	}
+	l, ok := ar.linearize(n.Low)
+	if !ok || l.atom != "" || l.k < 1 {
+		return nil
+	}
+	// s[c:] panics whenever len < c.
+	return d.checkConst(ctx, ar, fd, n, n.X, l.k-1, stack)
+}
+
+// checkConst reports n, which indexes x with a constant and panics whenever
+// len(x) <= max, unless that is ruled out.
+func (d IndexBoundsDetector) checkConst(ctx *Context, ar *arith, fd *ast.FuncDecl, n, x ast.Expr, max int64, stack []ast.Node) *Finding {
+	if max < 0 {
+		return nil
+	}
+	if min, ok := minLen(ctx.Info, ar, x); ok && min > max {
+		return nil
+	}
+	atom := "len(" + types.ExprString(x) + ")"
+	if ar.guarded(stack, x, atom, max) || appendedBefore(ctx.Info, fd.Body, x, n.Pos()) {
+		return nil
+	}
+	f := ctx.NewFinding(d.Name(), SeverityWarning, n,
+		"`%s` panics when %s is %d: no length check guards this access", types.ExprString(n), atom, max)
+	f.Confidence = ConfidenceMedium
+	if fact, ok := ctx.argFactFor(x, func(l int64) bool { return l <= max }); ok {
+		f.Severity = SeverityError
+		f.Confidence = ConfidenceHigh
+		f.Related = append(f.Related, fact.related(ctx, x))
+	}
+	return &f
+}
+
+// checkLoopIndex reports x[i] where i is the variable of an enclosing loop
+// that is bounded by something other than len(x), or by `i <= len(x)`.
+func (d IndexBoundsDetector) checkLoopIndex(ctx *Context, n *ast.IndexExpr, stack []ast.Node) *Finding {
+	idx := exprVar(ctx.Info, n.Index)
+	if idx == nil {
+		return nil
+	}
+	x := types.ExprString(n.X)
+	for i := len(stack) - 2; i >= 0; i-- {
+		switch loop := stack[i].(type) {
+		case *ast.FuncLit:
+			return nil
+		case *ast.RangeStmt:
+			if exprVar(ctx.Info, loop.Key) != idx {
+				continue
+			}
+			over := types.ExprString(loop.X)
+			if over == x || !isSliceOrString(ctx.Info, loop.X) || mentionsLenOf(stack[:i], x) {
+				return nil
+			}
+			f := ctx.NewFinding(d.Name(), SeverityWarning, n,
+				"`%s` uses the index of a range over `%s`, which may be longer than `%s`", types.ExprString(n), over, x)
+			f.Confidence = ConfidenceLow
+			return &f
+		case *ast.ForStmt:
+			if !definesVar(ctx.Info, loop.Init, idx) {
+				continue
+			}
+			bin, ok := ast.Unparen(loop.Cond).(*ast.BinaryExpr)
+			if !ok || exprVar(ctx.Info, bin.X) != idx {
+				return nil
+			}
+			bound, ok := ast.Unparen(bin.Y).(*ast.CallExpr)
+			if !ok || !isLenOrCap(ctx.Info, bound) {
+				return nil
+			}
+			over := types.ExprString(bound.Args[0])
+			if over == x && bin.Op == token.LEQ {
+				f := ctx.NewFinding(d.Name(), SeverityError, n,
+					"`%s` is out of range on the last iteration: the loop runs while `%s`", types.ExprString(n), types.ExprString(bin))
+				return &f
+			}
+			if over == x || bin.Op != token.LSS && bin.Op != token.LEQ || mentionsLenOf(stack[:i], x) {
+				return nil
+			}
+			f := ctx.NewFinding(d.Name(), SeverityWarning, n,
+				"`%s` is indexed by a loop bounded by `%s`, which may be longer than `%s`", types.ExprString(n), types.ExprString(bin.Y), x)
+			f.Confidence = ConfidenceLow
+			return &f
+		}
+	}
+	return nil
+}
+
+// mentionsLenOf reports whether any if or for condition among the
+// enclosing nodes refers to len(x).
+func mentionsLenOf(stack []ast.Node, x string) bool {
+	atom := "len(" + x + ")"
+	for _, n := range stack {
+		var cond ast.Expr
+		switch n := n.(type) {
+		case *ast.IfStmt:
+			cond = n.Cond
+		case *ast.ForStmt:
+			cond = n.Cond
+		}
+		if cond != nil && mentions(cond, atom) {
+			return true
+		}
+	}
+	return false
+}
+
+func definesVar(info *types.Info, s ast.Stmt, v *types.Var) bool {
+	as, ok := s.(*ast.AssignStmt)
+	if !ok || as.Tok != token.DEFINE {
+		return false
+	}
+	for _, lhs := range as.Lhs {
+		if exprVar(info, lhs) == v {
+			return true
+		}
+	}
+	return false
+}
+
+func isSliceOrString(info *types.Info, e ast.Expr) bool {
+	t := info.TypeOf(e)
+	if t == nil {
+		return false
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Slice:
+		return true
+	case *types.Basic:
+		return u.Info()&types.IsString != 0
+	}
+	return false
+}
+
+// minLen returns a lower bound on the length of x when x is a variable
+// assigned once from a composite literal or a function known to return a
+// non-empty result.
+func minLen(info *types.Info, ar *arith, x ast.Expr) (int64, bool) {
+	if sel, ok := ast.Unparen(x).(*ast.SelectorExpr); ok {
+		if v, ok := info.Uses[sel.Sel].(*types.Var); ok && v.Pkg() != nil && v.Pkg().Path() == "os" && v.Name() == "Args" {
+			return 1, true
+		}
+	}
+	v := exprVar(info, x)
+	if v == nil {
+		return 0, false
+	}
+	switch def := ast.Unparen(ar.defs[v]).(type) {
+	case *ast.CompositeLit:
+		return int64(len(def.Elts)), true
+	case *ast.BasicLit:
+		if tv, ok := info.Types[def]; ok && tv.Value != nil && tv.Value.Kind() == constant.String {
+			return int64(len(constant.StringVal(tv.Value))), true
+		}
+	case *ast.CallExpr:
+		if fn := calleeFunc(info, def); fn != nil && fn.Pkg() != nil && fn.Pkg().Path() == "strings" {
+			switch fn.Name() {
+			case "Split", "SplitAfter", "SplitN", "SplitAfterN":
+				return 1, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// appendedBefore reports whether x is grown by `x = append(x, ...)` before
+// pos in body.
+func appendedBefore(info *types.Info, body *ast.BlockStmt, x ast.Expr, pos token.Pos) bool {
+	target := exprVar(info, x)
+	if target == nil {
+		return false
+	}
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		as, ok := n.(*ast.AssignStmt)
+		if !ok || found || as.Pos() >= pos {
+			return !found
+		}
+		for i, lhs := range as.Lhs {
+			if exprVar(info, lhs) == target && i < len(as.Rhs) {
+				if call, ok := ast.Unparen(as.Rhs[i]).(*ast.CallExpr); ok && isBuiltin(info, call, "append") {
+					found = true
+				}
+			}
+		}
+		return true
+	})
+	return found
+}
+
+func isBuiltin(info *types.Info, call *ast.CallExpr, name string) bool {
+	id, ok := ast.Unparen(call.Fun).(*ast.Ident)
+	if !ok {
+		return false
+	}
+	b, ok := info.Uses[id].(*types.Builtin)
+	return ok && b.Name() == name
+}
+
+// literalLen returns the length of e when it is a slice or string literal
+// (or nil).
+func literalLen(info *types.Info, e ast.Expr) (int64, bool) {
+	e = ast.Unparen(e)
+	if isNilExpr(info, e) {
+		return 0, true
+	}
+	if cl, ok := e.(*ast.CompositeLit); ok && isSliceOrString(info, cl) {
+		return int64(len(cl.Elts)), true
+	}
+	if tv, ok := info.Types[e]; ok && tv.Value != nil && tv.Value.Kind() == constant.String {
+		return int64(len(constant.StringVal(tv.Value))), true
+	}
+	return 0, false
+}
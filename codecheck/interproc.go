@@ -0,0 +1,90 @@
+package codecheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+)
+
+// argFact records that a call site passes a literal of known length for a
+// parameter of a function declared in the analyzed package: a slice
+// literal, nil, or a string literal, passed directly or through a local
+// variable assigned once from one.
+type argFact struct {
+	call   *ast.CallExpr
+	arg    ast.Expr // the literal itself
+	length int64
+}
+
+// related describes the fact as a secondary location for a finding about
+// the parameter x.
+func (f argFact) related(ctx *Context, x ast.Expr) Related {
+	what := fmt.Sprintf("a literal of length %d", f.length)
+	if f.length == 0 {
+		what = "an empty value"
+	}
+	return Related{
+		Position: ctx.Fset.Position(f.call.Pos()),
+		Message:  fmt.Sprintf("`%s` is called here with %s (`%s`) for `%s`", types.ExprString(f.call.Fun), what, types.ExprString(f.arg), types.ExprString(x)),
+	}
+}
+
+// argFacts maps parameters to what call sites pass for them. Only calls
+// made directly from a function in the package are followed, one level
+// deep: facts are not propagated from a callee's parameters onwards.
+func (c *Context) argFacts() map[*types.Var][]argFact {
+	c.argFactsOnce.Do(func() {
+		c.argFactsMap = map[*types.Var][]argFact{}
+		for _, f := range c.Files {
+			for _, decl := range f.Decls {
+				fd, ok := decl.(*ast.FuncDecl)
+				if !ok || fd.Body == nil {
+					continue
+				}
+				ar := newArith(c.Info, fd.Body)
+				ast.Inspect(fd.Body, func(n ast.Node) bool {
+					if call, ok := n.(*ast.CallExpr); ok {
+						c.recordArgFacts(ar, call)
+					}
+					return true
+				})
+			}
+		}
+	})
+	return c.argFactsMap
+}
+
+func (c *Context) recordArgFacts(ar *arith, call *ast.CallExpr) {
+	fn := calleeFunc(c.Info, call)
+	if fn == nil || fn.Pkg() != c.Pkg || call.Ellipsis.IsValid() {
+		return
+	}
+	params := fn.Type().(*types.Signature).Params()
+	for i, arg := range call.Args {
+		if i >= params.Len() {
+			break
+		}
+		if v := exprVar(c.Info, arg); v != nil && ar.defs[v] != nil {
+			arg = ar.defs[v]
+		}
+		if l, ok := literalLen(c.Info, arg); ok {
+			p := params.At(i)
+			c.argFactsMap[p] = append(c.argFactsMap[p], argFact{call: call, arg: arg, length: l})
+		}
+	}
+}
+
+// argFactFor returns the first call-site fact about parameter x whose
+// length satisfies match.
+func (c *Context) argFactFor(x ast.Expr, match func(length int64) bool) (argFact, bool) {
+	v := exprVar(c.Info, x)
+	if v == nil {
+		return argFact{}, false
+	}
+	for _, f := range c.argFacts()[v] {
+		if match(f.length) {
+			return f, true
+		}
+	}
+	return argFact{}, false
+}
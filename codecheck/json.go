@@ -0,0 +1,125 @@
+package codecheck
+
+import (
+	"encoding/json"
+	"go/token"
+	"io"
+)
+
+// jsonFinding is the form of a Finding in JSON reports.
+type jsonFinding struct {
+	Rule        string        `json:"rule"`
+	Severity    string        `json:"severity"`
+	Confidence  string        `json:"confidence,omitempty"`
+	File        string        `json:"file"`
+	StartLine   int           `json:"startLine"`
+	StartColumn int           `json:"startColumn"`
+	EndLine     int           `json:"endLine"`
+	EndColumn   int           `json:"endColumn"`
+	Message     string        `json:"message"`
+	Suggestion  string        `json:"suggestion,omitempty"`
+	Related     []jsonRelated `json:"related,omitempty"`
+	Fingerprint string        `json:"fingerprint"`
+	Duplicates  []jsonFinding `json:"duplicates,omitempty"`
+}
+
+type jsonRelated struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+}
+
+// WriteJSON writes findings as a JSON array. The findings a finding
+// collapses with Dedupe are in its "duplicates" array, in full.
+func WriteJSON(w io.Writer, findings []Finding) error {
+	out := make([]jsonFinding, len(findings))
+	for i, f := range findings {
+		out[i] = toJSON(f)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func toJSON(f Finding) jsonFinding {
+	j := jsonFinding{
+		Rule:        f.Rule,
+		Severity:    f.Severity.String(),
+		Confidence:  f.Confidence.String(),
+		File:        f.Position.Filename,
+		StartLine:   f.Position.Line,
+		StartColumn: f.Position.Column,
+		EndLine:     f.End.Line,
+		EndColumn:   f.End.Column,
+		Message:     f.Message,
+		Suggestion:  f.Suggestion,
+		Fingerprint: f.Fingerprint,
+	}
+	for _, r := range f.Related {
+		j.Related = append(j.Related, jsonRelated{
+			File:    r.Position.Filename,
+			Line:    r.Position.Line,
+			Column:  r.Position.Column,
+			Message: r.Message,
+		})
+	}
+	for _, d := range f.Duplicates {
+		j.Duplicates = append(j.Duplicates, toJSON(d))
+	}
+	return j
+}
+
+// ReadJSON reads findings written by WriteJSON.
+func ReadJSON(r io.Reader) ([]Finding, error) {
+	var in []jsonFinding
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, err
+	}
+	findings := make([]Finding, len(in))
+	for i, f := range in {
+		var err error
+		if findings[i], err = fromJSON(f); err != nil {
+			return nil, err
+		}
+	}
+	return findings, nil
+}
+
+func fromJSON(j jsonFinding) (Finding, error) {
+	sev, err := ParseSeverity(j.Severity)
+	if err != nil {
+		return Finding{}, err
+	}
+	// Reports from before confidence was recorded count as high.
+	conf := ConfidenceHigh
+	if j.Confidence != "" {
+		if conf, err = ParseConfidence(j.Confidence); err != nil {
+			return Finding{}, err
+		}
+	}
+	f := Finding{
+		Rule:        j.Rule,
+		Severity:    sev,
+		Confidence:  conf,
+		Position:    token.Position{Filename: j.File, Line: j.StartLine, Column: j.StartColumn},
+		End:         token.Position{Filename: j.File, Line: j.EndLine, Column: j.EndColumn},
+		Message:     j.Message,
+		Suggestion:  j.Suggestion,
+		Fingerprint: j.Fingerprint,
+	}
+	for _, r := range j.Related {
+		f.Related = append(f.Related, Related{
+			Position: token.Position{Filename: r.File, Line: r.Line, Column: r.Column},
+			Message:  r.Message,
+		})
+	}
+	for _, dj := range j.Duplicates {
+		d, err := fromJSON(dj)
+		if err != nil {
+			return Finding{}, err
+		}
+		f.Duplicates = append(f.Duplicates, d)
+	}
+	return f, nil
+}
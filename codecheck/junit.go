@@ -0,0 +1,110 @@
+package codecheck
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+// WriteJUnit writes findings as a JUnit XML report, for CI systems that
+// show test results: each file is a test suite and each finding in it a
+// failed test case, named after its rule and position, whose failure has
+// the finding's message and severity and whose text adds the related
+// locations and suggestion. files lists the files analyzed, so that those
+// without findings are reported as a suite with one passing test case;
+// files with findings need not be listed.
+func WriteJUnit(w io.Writer, files []string, findings []Finding) error {
+	byFile := map[string][]Finding{}
+	for _, name := range files {
+		byFile[name] = nil
+	}
+	for _, f := range findings {
+		byFile[f.Position.Filename] = append(byFile[f.Position.Filename], f)
+	}
+	names := make([]string, 0, len(byFile))
+	for name := range byFile {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	report := junitTestSuites{Name: "codecheck"}
+	for _, name := range names {
+		suite := junitTestSuite{Name: name}
+		for _, f := range byFile[name] {
+			var text strings.Builder
+			fmt.Fprintf(&text, "%s\n", f)
+			if f.Confidence != ConfidenceHigh {
+				fmt.Fprintf(&text, "confidence: %s\n", f.Confidence)
+			}
+			for _, r := range f.Related {
+				fmt.Fprintf(&text, "%s: note: %s\n", r.Position, r.Message)
+			}
+			if f.Suggestion != "" {
+				fmt.Fprintf(&text, "suggestion: %s\n", f.Suggestion)
+			}
+			suite.Cases = append(suite.Cases, junitTestCase{
+				Name:      fmt.Sprintf("%s at %d:%d", f.Rule, f.Position.Line, f.Position.Column),
+				Classname: name,
+				File:      name,
+				Line:      f.Position.Line,
+				Failure: &junitFailure{
+					Message: f.Message,
+					Type:    f.Severity.String(),
+					Text:    text.String(),
+				},
+			})
+			suite.Failures++
+		}
+		if len(suite.Cases) == 0 {
+			suite.Cases = []junitTestCase{{Name: "codecheck", Classname: name, File: name}}
+		}
+		suite.Tests = len(suite.Cases)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Suites = append(report.Suites, suite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
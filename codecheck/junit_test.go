@@ -0,0 +1,129 @@
+package codecheck
+
+import (
+	"bytes"
+	"encoding/xml"
+	"go/token"
+	"testing"
+)
+
+// TestJUnitCounts parses JUnit reports and checks that the tests and
+// failures counts of each suite and of the whole report match its test
+// cases and the findings, and that analyzed files without findings get a
+// passing test case.
+func TestJUnitCounts(t *testing.T) {
+	type testCase struct {
+		Name    string `xml:"name,attr"`
+		File    string `xml:"file,attr"`
+		Line    int    `xml:"line,attr"`
+		Failure *struct {
+			Message string `xml:"message,attr"`
+			Type    string `xml:"type,attr"`
+		} `xml:"failure"`
+	}
+	type testSuite struct {
+		Name     string     `xml:"name,attr"`
+		Tests    int        `xml:"tests,attr"`
+		Failures int        `xml:"failures,attr"`
+		Cases    []testCase `xml:"testcase"`
+	}
+	type report struct {
+		XMLName  xml.Name    `xml:"testsuites"`
+		Tests    int         `xml:"tests,attr"`
+		Failures int         `xml:"failures,attr"`
+		Suites   []testSuite `xml:"testsuite"`
+	}
+
+	finding := func(file string, line int, sev Severity, msg string) Finding {
+		pos := token.Position{Filename: file, Line: line, Column: 2}
+		return Finding{Rule: "nil-deref", Severity: sev, Position: pos, End: pos, Message: msg}
+	}
+	tests := []struct {
+		name         string
+		files        []string
+		findings     []Finding
+		wantTests    int
+		wantFailures int
+		wantSuites   map[string]int // failures per suite
+	}{
+		{name: "nothing analyzed"},
+		{
+			name:       "passing",
+			files:      []string{"a.go", "b.go"},
+			wantTests:  2,
+			wantSuites: map[string]int{"a.go": 0, "b.go": 0},
+		},
+		{
+			name:  "failing",
+			files: []string{"a.go", "c.go"},
+			findings: []Finding{
+				finding("a.go", 3, SeverityError, "`p` is nil & <dereferenced>"),
+				finding("a.go", 9, SeverityNote, "`q` may be nil"),
+				finding("b.go", 1, SeverityWarning, "`r` is nil"),
+			},
+			wantTests:    4,
+			wantFailures: 3,
+			wantSuites:   map[string]int{"a.go": 2, "b.go": 1, "c.go": 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteJUnit(&buf, tt.files, tt.findings); err != nil {
+				t.Fatal(err)
+			}
+			var r report
+			if err := xml.Unmarshal(buf.Bytes(), &r); err != nil {
+				t.Fatalf("%v in:\n%s", err, &buf)
+			}
+			if r.Tests != tt.wantTests || r.Failures != tt.wantFailures {
+				t.Errorf("got tests=%d failures=%d, want tests=%d failures=%d", r.Tests, r.Failures, tt.wantTests, tt.wantFailures)
+			}
+			if len(r.Suites) != len(tt.wantSuites) {
+				t.Fatalf("got %d suites, want %d:\n%s", len(r.Suites), len(tt.wantSuites), &buf)
+			}
+			total, failed := 0, 0
+			for _, s := range r.Suites {
+				want, ok := tt.wantSuites[s.Name]
+				if !ok {
+					t.Errorf("unexpected suite %s", s.Name)
+					continue
+				}
+				cases := 0
+				for _, c := range s.Cases {
+					if c.Failure != nil {
+						cases++
+					}
+				}
+				if s.Failures != want || cases != want {
+					t.Errorf("suite %s: failures=%d with %d failed cases, want %d", s.Name, s.Failures, cases, want)
+				}
+				if s.Tests != len(s.Cases) || s.Tests != max(want, 1) {
+					t.Errorf("suite %s: tests=%d with %d cases, want %d", s.Name, s.Tests, len(s.Cases), max(want, 1))
+				}
+				total += s.Tests
+				failed += s.Failures
+			}
+			if total != r.Tests || failed != r.Failures {
+				t.Errorf("suites add up to tests=%d failures=%d, but the report has tests=%d failures=%d", total, failed, r.Tests, r.Failures)
+			}
+			for i, f := range tt.findings {
+				var c *testCase
+				for j, s := range r.Suites {
+					for k := range s.Cases {
+						if s.Name == f.Position.Filename && s.Cases[k].Line == f.Position.Line {
+							c = &r.Suites[j].Cases[k]
+						}
+					}
+				}
+				if c == nil || c.Failure == nil {
+					t.Errorf("finding %d at %s has no failed test case", i, f.Position)
+					continue
+				}
+				if c.Failure.Message != f.Message || c.Failure.Type != f.Severity.String() {
+					t.Errorf("finding %d: got failure %q of type %s, want %q of type %s", i, c.Failure.Message, c.Failure.Type, f.Message, f.Severity)
+				}
+			}
+		})
+	}
+}
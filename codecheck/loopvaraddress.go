@@ -0,0 +1,242 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"go/version"
+)
+
+// LoopVarAddressDetector reports the address of a loop's iteration
+// variable taken to outlive the iteration. In code older than Go 1.22,
+// where a loop declares its variables once, `ptrs = append(ptrs, &v)` in
+// `for _, v := range xs` appends the same pointer on every iteration, so
+// that when the loop ends every element points at v holding the last
+// value; storing &v in a map, a field, an outer variable or a channel has
+// the same effect. The Go version is the file's, as for loop-var-capture.
+//
+// In any Go version, `return &v` from such a loop returns the address of
+// the copy of the element in v rather than of the element, which is
+// usually what a function looking an element up meant to return; those
+// findings are of medium confidence, as returning a copy may be intended.
+type LoopVarAddressDetector struct{}
+
+func (LoopVarAddressDetector) Name() string { return "loop-var-address" }
+
+func (LoopVarAddressDetector) Description() string {
+	return "Address of a loop variable stored beyond its iteration (before Go 1.22) or returned instead of the element's"
+}
+
+func (LoopVarAddressDetector) DefaultSeverity() Severity { return SeverityWarning }
+
+func (LoopVarAddressDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: "Before Go 1.22 a for loop declared its variables once, and every iteration assigned to the same variable, so &v is the same pointer on every iteration: pointers to it collected during the loop all point at one variable, which holds the last value when the loop is done. In every Go version v is a copy of the element, so returning &v from a range loop hands out a pointer to the copy, and changes through it never reach the slice.",
+		Example: `var ptrs []*Item
+for _, it := range items {
+	ptrs = append(ptrs, &it)
+}`,
+		Fix: `var ptrs []*Item
+for i := range items {
+	ptrs = append(ptrs, &items[i])
+}`,
+	}
+}
+
+func (d LoopVarAddressDetector) Check(ctx *Context) []Finding {
+	var findings []Finding
+	for _, f := range ctx.Files {
+		v := ctx.Info.FileVersions[f]
+		shared := version.IsValid(v) && version.Compare(v, "go1.22") < 0
+		// One copy per block serves every statement after it.
+		copied := map[ast.Node]map[*types.Var]bool{}
+		inspectStack(f, func(n ast.Node, stack []ast.Node) bool {
+			addr, ok := n.(*ast.UnaryExpr)
+			if !ok || addr.Op != token.AND {
+				return true
+			}
+			obj := addressedVar(ctx.Info, addr.X)
+			if obj == nil {
+				return true
+			}
+			loop, ok := loopVars(ctx.Info, stack)[obj]
+			if !ok {
+				return true
+			}
+			how, at := addressUse(ctx.Info, loop, stack)
+			switch {
+			case how == "returned":
+				if fd, ok := d.returned(ctx, addr, obj, loop); ok {
+					findings = append(findings, fd)
+				}
+			case how != "" && shared:
+				fd := ctx.NewFinding(d.Name(), SeverityWarning, addr,
+					"`%s` is %s, but before Go 1.22 loop variable `%s` is one variable shared by every iteration of `%s`: each iteration stores the same pointer, and after the loop they all point at `%s` holding the last value",
+					ctx.sourceText(addr), how, obj.Name(), loopHeader(ctx, loop), obj.Name())
+				fd.Related = append(fd.Related, Related{Position: ctx.Fset.Position(obj.Pos()), Message: "`" + obj.Name() + "` is declared once for the whole loop"})
+				or := ""
+				if _, ok := loop.(*ast.RangeStmt); ok {
+					or = ", or take the address of the element itself, as in `&s[i]`"
+				}
+				fd.Suggestion = "copy the variable first with `" + obj.Name() + " := " + obj.Name() + "`" + or + "; from Go 1.22 on, as set by the go directive in go.mod, each iteration has its own variable"
+				block := stack[len(stack)-2-at]
+				switch block.(type) {
+				case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause:
+					if copied[block][obj] {
+						break
+					}
+					if copied[block] == nil {
+						copied[block] = map[*types.Var]bool{}
+					}
+					copied[block][obj] = true
+					stmt := stack[len(stack)-1-at]
+					fd.Fix = &SuggestedFix{
+						Message: "copy " + obj.Name() + " before the statement",
+						Safe:    true,
+						Edits:   []TextEdit{ctx.edit(stmt.Pos(), stmt.Pos(), obj.Name()+" := "+obj.Name()+"\n")},
+					}
+				}
+				findings = append(findings, fd)
+			}
+			return true
+		})
+	}
+	return findings
+}
+
+// returned reports `return &v` for the value variable v of a range loop
+// over a slice or array, which returns the address of a copy.
+func (d LoopVarAddressDetector) returned(ctx *Context, addr *ast.UnaryExpr, obj *types.Var, loop ast.Stmt) (Finding, bool) {
+	rs, ok := loop.(*ast.RangeStmt)
+	if !ok {
+		return Finding{}, false
+	}
+	if id, ok := rs.Value.(*ast.Ident); !ok || ctx.Info.Defs[id] != obj {
+		return Finding{}, false
+	}
+	t := ctx.Info.TypeOf(rs.X)
+	if t == nil {
+		return Finding{}, false
+	}
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	switch t.Underlying().(type) {
+	case *types.Slice, *types.Array:
+	default:
+		return Finding{}, false
+	}
+	xs := ctx.sourceText(rs.X)
+	fd := ctx.NewFinding(d.Name(), SeverityWarning, addr,
+		"`%s` returns the address of loop variable `%s`, a copy of the element of `%s`, not of the element itself; changes made through the pointer don't reach `%s`",
+		ctx.sourceText(addr), obj.Name(), xs, xs)
+	fd.Confidence = ConfidenceMedium
+	key, _ := rs.Key.(*ast.Ident)
+	if key == nil || key.Name == "_" {
+		fd.Suggestion = "range over the indexes and return `&" + xs + "[i]`, or return `" + obj.Name() + "` by value if a copy is meant"
+		return fd, true
+	}
+	elem := "&" + xs + "[" + key.Name + "]"
+	fd.Suggestion = "return `" + elem + "`, or return `" + obj.Name() + "` by value if a copy is meant"
+	if _, ok := ast.Unparen(addr.X).(*ast.Ident); ok {
+		switch ast.Unparen(rs.X).(type) {
+		case *ast.Ident, *ast.SelectorExpr:
+			fd.Fix = &SuggestedFix{
+				Message: "return " + elem,
+				Edits:   []TextEdit{ctx.edit(addr.Pos(), addr.End(), elem)},
+			}
+		}
+	}
+	return fd, true
+}
+
+// addressedVar returns the variable whose storage e is, for e a variable
+// or a field of a struct variable reached without going through a
+// pointer, like `v` or `v.inner.f`, or nil.
+func addressedVar(info *types.Info, e ast.Expr) *types.Var {
+	for {
+		switch x := ast.Unparen(e).(type) {
+		case *ast.Ident:
+			return identVar(info, x)
+		case *ast.SelectorExpr:
+			s := info.Selections[x]
+			if s == nil || s.Kind() != types.FieldVal || s.Indirect() {
+				return nil
+			}
+			if _, ok := info.TypeOf(x.X).Underlying().(*types.Pointer); ok {
+				return nil
+			}
+			e = x.X
+		default:
+			return nil
+		}
+	}
+}
+
+// addressUse tells how the address at the top of stack, inside loop,
+// leaves the iteration: "appended to a slice", "stored in ...", "sent on a
+// channel" or "returned", or "" if it doesn't as far as can be told. at is
+// how many nodes below the top of stack the statement using it is.
+// Composite literals holding the address are followed to where they go.
+func addressUse(info *types.Info, loop ast.Stmt, stack []ast.Node) (how string, at int) {
+	i := len(stack) - 2
+up:
+	for ; i >= 0; i-- {
+		switch p := stack[i].(type) {
+		case *ast.ParenExpr, *ast.CompositeLit, *ast.KeyValueExpr:
+		case *ast.UnaryExpr:
+			if _, ok := ast.Unparen(p.X).(*ast.CompositeLit); !ok || p.Op != token.AND {
+				break up
+			}
+		default:
+			break up
+		}
+	}
+	if i < 0 {
+		return "", 0
+	}
+	child := stack[i+1]
+	switch p := stack[i].(type) {
+	case *ast.CallExpr:
+		if id, ok := ast.Unparen(p.Fun).(*ast.Ident); ok && len(p.Args) > 1 && child != p.Args[0] {
+			if b, ok := info.Uses[id].(*types.Builtin); ok && b.Name() == "append" {
+				return "appended to a slice", statementBelow(stack, i)
+			}
+		}
+	case *ast.SendStmt:
+		if child == p.Value {
+			return "sent on a channel", len(stack) - 1 - i
+		}
+	case *ast.ReturnStmt:
+		return "returned", len(stack) - 1 - i
+	case *ast.AssignStmt:
+		if p.Tok != token.ASSIGN || len(p.Lhs) != len(p.Rhs) {
+			return "", 0
+		}
+		for j, rhs := range p.Rhs {
+			if rhs != child {
+				continue
+			}
+			switch lhs := ast.Unparen(p.Lhs[j]).(type) {
+			case *ast.IndexExpr, *ast.SelectorExpr, *ast.StarExpr:
+				return "stored in `" + types.ExprString(lhs) + "`", len(stack) - 1 - i
+			case *ast.Ident:
+				if v := identVar(info, lhs); v != nil && (v.Pos() < loop.Pos() || v.Pos() >= loop.End()) {
+					return "assigned to `" + lhs.Name + "`, declared outside the loop", len(stack) - 1 - i
+				}
+			}
+		}
+	}
+	return "", 0
+}
+
+// statementBelow returns how many nodes below the top of stack the
+// statement containing stack[i] is.
+func statementBelow(stack []ast.Node, i int) int {
+	for ; i >= 0; i-- {
+		if _, ok := stack[i].(ast.Stmt); ok {
+			return len(stack) - 1 - i
+		}
+	}
+	return 0
+}
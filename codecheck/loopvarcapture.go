@@ -0,0 +1,146 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"go/version"
+)
+
+// LoopVarCaptureDetector reports function literals started with `go` or
+// deferred in a loop that refer to the loop's iteration variable, in code
+// older than Go 1.22. Until then a loop declared its variables once for
+// the whole loop, so `go func() { use(i) }()` sees whatever value i holds
+// when the goroutine gets to it, usually the last one, and a deferred
+// closure always sees the last. Closures that copy the variable, with
+// `i := i` or by taking it as an argument, are not reported. The Go version
+// is the one each file is compiled as, from its module's go directive or a
+// build constraint; files of Go 1.22 or later, which get a new variable on
+// every iteration, and files whose version is unknown are left alone.
+type LoopVarCaptureDetector struct{}
+
+func (LoopVarCaptureDetector) Name() string { return "loop-var-capture" }
+
+func (LoopVarCaptureDetector) Description() string {
+	return "Goroutine or deferred closure capturing a loop variable shared by all iterations (before Go 1.22)"
+}
+
+func (LoopVarCaptureDetector) DefaultSeverity() Severity { return SeverityWarning }
+
+func (LoopVarCaptureDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: "Before Go 1.22 a for loop declared its variables once, and every iteration assigned to the same variable. A goroutine or deferred function literal referring to it reads the variable when it runs, not when it was created, so all of them usually see the last value. Go 1.22 gives each iteration its own variable; until a module's go directive says 1.22 or later, the closure has to copy it.",
+		Example: `for _, job := range jobs {
+	go func() {
+		run(job)
+	}()
+}`,
+		Fix: `for _, job := range jobs {
+	job := job
+	go func() {
+		run(job)
+	}()
+}`,
+	}
+}
+
+func (d LoopVarCaptureDetector) Check(ctx *Context) []Finding {
+	var findings []Finding
+	for _, f := range ctx.Files {
+		v := ctx.Info.FileVersions[f]
+		if !version.IsValid(v) || version.Compare(v, "go1.22") >= 0 {
+			continue
+		}
+		inspectStack(f, func(n ast.Node, stack []ast.Node) bool {
+			var call *ast.CallExpr
+			var keyword, what, verb string
+			switch n := n.(type) {
+			case *ast.GoStmt:
+				call, keyword, what, verb = n.Call, "go", "goroutine", "started"
+			case *ast.DeferStmt:
+				call, keyword, what, verb = n.Call, "defer", "deferred call", "deferred"
+			default:
+				return true
+			}
+			lit, ok := ast.Unparen(call.Fun).(*ast.FuncLit)
+			if !ok {
+				return true
+			}
+			vars := loopVars(ctx.Info, stack)
+			if len(vars) == 0 {
+				return true
+			}
+			// Report each variable at its first use in the closure.
+			seen := map[*types.Var]bool{}
+			var copies string
+			var found []Finding
+			ast.Inspect(lit.Body, func(m ast.Node) bool {
+				id, ok := m.(*ast.Ident)
+				if !ok {
+					return true
+				}
+				obj := identVar(ctx.Info, id)
+				loop, ok := vars[obj]
+				if !ok || seen[obj] {
+					return true
+				}
+				seen[obj] = true
+				fd := ctx.NewFinding(d.Name(), SeverityWarning, id,
+					"the %s reads loop variable `%s`, which before Go 1.22 is one variable shared by every iteration of `%s`; by the time it runs `%s` may hold a later iteration's value",
+					what, id.Name, loopHeader(ctx, loop), id.Name)
+				fd.Related = append(fd.Related,
+					Related{Position: ctx.Fset.Position(n.Pos()), Message: "the function literal is " + verb + " here"},
+					Related{Position: ctx.Fset.Position(obj.Pos()), Message: "`" + id.Name + "` is declared once for the whole loop"})
+				fd.Suggestion = "copy the variable for the closure with `" + id.Name + " := " + id.Name + "` before the `" + keyword + "` statement, or pass it as an argument; from Go 1.22 on, as set by the go directive in go.mod, each iteration has its own variable"
+				copies += id.Name + " := " + id.Name + "\n"
+				found = append(found, fd)
+				return true
+			})
+			if len(found) == 0 {
+				return true
+			}
+			switch stack[len(stack)-2].(type) {
+			case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause:
+				// One fix copies every variable the closure captures.
+				found[0].Fix = &SuggestedFix{
+					Message: "copy the loop variables before the statement",
+					Safe:    true,
+					Edits:   []TextEdit{ctx.edit(n.Pos(), n.Pos(), copies)},
+				}
+			}
+			findings = append(findings, found...)
+			return true
+		})
+	}
+	return findings
+}
+
+// loopVars returns the iteration variables of the loops around the top of
+// stack in the same function, with the loop declaring each.
+func loopVars(info *types.Info, stack []ast.Node) map[*types.Var]ast.Stmt {
+	vars := map[*types.Var]ast.Stmt{}
+	add := func(loop ast.Stmt, exprs ...ast.Expr) {
+		for _, e := range exprs {
+			if id, ok := e.(*ast.Ident); ok {
+				if v, ok := info.Defs[id].(*types.Var); ok {
+					vars[v] = loop
+				}
+			}
+		}
+	}
+	for i := len(stack) - 2; i >= 0; i-- {
+		switch n := stack[i].(type) {
+		case *ast.FuncLit, *ast.FuncDecl:
+			return vars
+		case *ast.RangeStmt:
+			if n.Tok == token.DEFINE {
+				add(n, n.Key, n.Value)
+			}
+		case *ast.ForStmt:
+			if as, ok := n.Init.(*ast.AssignStmt); ok && as.Tok == token.DEFINE {
+				add(n, as.Lhs...)
+			}
+		}
+	}
+	return vars
+}
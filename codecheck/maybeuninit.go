@@ -0,0 +1,172 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// MaybeUninitializedDetector reports uses of variables declared with
+// `var x T` that rely on x having been assigned, on a path where it still
+// holds its zero value: sending on, receiving from, ranging over or
+// closing a nil channel, calling a nil function and calling a method of a
+// nil interface. Pointer dereferences are reported by NilDerefDetector and
+// writes to nil maps by NilMapWriteDetector. Channel operations in select cases are left alone,
+// since a nil channel is the usual way of disabling a case.
+type MaybeUninitializedDetector struct{}
+
+func (MaybeUninitializedDetector) Name() string { return "maybe-uninitialized" }
+
+func (MaybeUninitializedDetector) Description() string {
+	return "Use of a channel, function or interface variable that is still nil on some path"
+}
+
+func (MaybeUninitializedDetector) DefaultSeverity() Severity { return SeverityError }
+
+func (MaybeUninitializedDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: "A channel, func or interface variable declared with `var` is nil until assigned. Closing a nil channel or calling a nil func or a method of a nil interface panics, and sending on or receiving from a nil channel blocks forever, so a path that leaves the variable unassigned is a latent crash or deadlock.",
+		Example: `var done chan struct{}
+if wait {
+	done = make(chan struct{})
+}
+close(done)`,
+		Fix: `done := make(chan struct{})
+close(done)`,
+	}
+}
+
+func (d MaybeUninitializedDetector) Check(ctx *Context) []Finding {
+	v := &uninitVisitor{ctx: ctx, seen: map[ast.Node]bool{}}
+	forEachFunc(ctx, func(body *ast.BlockStmt) {
+		v.ar = newArith(ctx.Info, body)
+		v.selectOps, v.rangeOver = chanContexts(body)
+		walkFlow(ctx.Info, body, v)
+	})
+	return v.findings
+}
+
+type uninitVisitor struct {
+	ctx      *Context
+	ar       *arith
+	seen     map[ast.Node]bool
+	findings []Finding
+
+	// selectOps holds the channel operations of select cases and rangeOver
+	// the operands of range statements in the current function.
+	selectOps map[ast.Node]bool
+	rangeOver map[ast.Expr]bool
+}
+
+func (v *uninitVisitor) track(obj *types.Var) bool {
+	if _, ok := obj.Type().(*types.TypeParam); ok {
+		return false
+	}
+	switch obj.Type().Underlying().(type) {
+	case *types.Chan, *types.Signature, *types.Interface:
+		return true
+	}
+	return false
+}
+
+func (v *uninitVisitor) visit(n ast.Node, st flowState) {
+	if e, ok := n.(ast.Expr); ok && v.rangeOver[e] {
+		if _, ok := v.ctx.Info.TypeOf(e).Underlying().(*types.Chan); ok {
+			v.check(n, e, st, SeverityWarning, "ranging over nil channel `%s` blocks forever")
+		}
+		return
+	}
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.SendStmt:
+			if !v.selectOps[n] {
+				v.check(n, n.Chan, st, SeverityWarning, "sending on nil channel `%s` blocks forever")
+			}
+		case *ast.UnaryExpr:
+			if n.Op == token.ARROW && !v.selectOps[n] {
+				v.check(n, n.X, st, SeverityWarning, "receiving from nil channel `%s` blocks forever")
+			}
+		case *ast.CallExpr:
+			v.checkCall(n, st)
+		}
+		return true
+	})
+}
+
+func (v *uninitVisitor) checkCall(call *ast.CallExpr, st flowState) {
+	if isBuiltin(v.ctx.Info, call, "close") && len(call.Args) == 1 {
+		v.check(call, call.Args[0], st, SeverityError, "closing nil channel `%s` panics")
+		return
+	}
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		if _, ok := v.ctx.Info.TypeOf(fun).Underlying().(*types.Signature); ok {
+			v.check(call, fun, st, SeverityError, "calling nil function `%s` panics")
+		}
+	case *ast.SelectorExpr:
+		s := v.ctx.Info.Selections[fun]
+		if s == nil || s.Kind() != types.MethodVal || !types.IsInterface(s.Recv()) {
+			return
+		}
+		v.check(call, fun.X, st, SeverityError, "calling method `"+fun.Sel.Name+"` on nil interface `%s` panics")
+	}
+}
+
+// check reports n if x is a tracked variable that may still be nil in st.
+// hazard describes what goes wrong, with a %s for the variable name.
+func (v *uninitVisitor) check(n ast.Node, x ast.Expr, st flowState, sev Severity, hazard string) {
+	obj := exprVar(v.ctx.Info, x)
+	if obj == nil {
+		return
+	}
+	fact, ok := st[obj]
+	if !ok {
+		return
+	}
+	if !v.seen[n] {
+		v.seen[n] = true
+		f := v.ctx.NewFinding(MaybeUninitializedDetector{}.Name(), sev, n,
+			hazard+": %s", obj.Name(), describeZero(v.ctx, fact, "nil"))
+		msg := "`" + obj.Name() + "` is declared here without a value"
+		if fact.explicit {
+			msg = "`" + obj.Name() + "` is assigned nil here"
+		}
+		f.Related = append(f.Related, Related{Position: v.ctx.Fset.Position(fact.decl), Message: msg})
+		calls := callsTakingBranch(v.ctx, v.ar, fact)
+		f.Related = append(f.Related, calls...)
+		f.Confidence = zeroConfidence(fact, calls)
+		v.findings = append(v.findings, f)
+	}
+	// The nil path has panicked or blocked here.
+	delete(st, obj)
+}
+
+// chanContexts returns the channel operations that are select cases and
+// the expressions ranged over in body.
+func chanContexts(body *ast.BlockStmt) (map[ast.Node]bool, map[ast.Expr]bool) {
+	selectOps := map[ast.Node]bool{}
+	rangeOver := map[ast.Expr]bool{}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CommClause:
+			var op ast.Expr
+			switch c := n.Comm.(type) {
+			case *ast.SendStmt:
+				selectOps[c] = true
+			case *ast.ExprStmt:
+				op = c.X
+			case *ast.AssignStmt:
+				op = c.Rhs[0]
+			}
+			if op != nil {
+				selectOps[ast.Unparen(op)] = true
+			}
+		case *ast.RangeStmt:
+			rangeOver[n.X] = true
+		}
+		return true
+	})
+	return selectOps, rangeOver
+}
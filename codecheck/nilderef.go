@@ -0,0 +1,170 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/types"
+	"strconv"
+)
+
+// NilDerefDetector reports dereferences of pointer variables that are still
+// nil on some path reaching the dereference. A pointer declared with
+// `var p *T` is followed through every branch of the function until it is
+// assigned; `*p` and `p.Field` on a path where it was never assigned are
+// flagged together with the branch that left it nil.
+type NilDerefDetector struct{}
+
+func (NilDerefDetector) Name() string { return "nil-deref" }
+
+func (NilDerefDetector) Description() string {
+	return "Dereference of a pointer that is nil on some path"
+}
+
+func (NilDerefDetector) DefaultSeverity() Severity { return SeverityError }
+
+func (NilDerefDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: "A pointer declared with `var p *T` is nil until something assigns it. If one branch of the function assigns it and another doesn't, dereferencing it afterwards panics with a nil pointer dereference on the branch that didn't, often an error path or a rare case that tests never take.",
+		Example: `var cfg *Config
+if path != "" {
+	cfg = load(path)
+}
+fmt.Println(cfg.Name)`,
+		Fix: `cfg := defaultConfig()
+if path != "" {
+	cfg = load(path)
+}
+fmt.Println(cfg.Name)`,
+	}
+}
+
+func (d NilDerefDetector) Check(ctx *Context) []Finding {
+	v := &nilDerefVisitor{ctx: ctx, seen: map[ast.Node]bool{}}
+	forEachFunc(ctx, func(body *ast.BlockStmt) {
+		v.ar = newArith(ctx.Info, body)
+		walkFlow(ctx.Info, body, v)
+	})
+	return v.findings
+}
+
+type nilDerefVisitor struct {
+	ctx      *Context
+	ar       *arith
+	seen     map[ast.Node]bool
+	findings []Finding
+}
+
+func (v *nilDerefVisitor) track(obj *types.Var) bool {
+	_, ok := obj.Type().Underlying().(*types.Pointer)
+	return ok
+}
+
+func (v *nilDerefVisitor) visit(n ast.Node, st flowState) {
+	ast.Inspect(n, func(n ast.Node) bool {
+		var x ast.Expr
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.StarExpr:
+			x = n.X
+		case *ast.SelectorExpr:
+			if isImplicitDeref(v.ctx.Info, n) {
+				x = n.X
+			}
+		}
+		if x == nil {
+			return true
+		}
+		obj := exprVar(v.ctx.Info, x)
+		if obj == nil {
+			return true
+		}
+		if fact, ok := st[obj]; ok {
+			if !v.seen[n] {
+				v.seen[n] = true
+				f := v.ctx.NewFinding(NilDerefDetector{}.Name(), SeverityError, n,
+					"nil dereference of `%s`: %s", obj.Name(), describeZero(v.ctx, fact, "nil"))
+				calls := callsTakingBranch(v.ctx, v.ar, fact)
+				f.Related = append(f.Related, calls...)
+				f.Confidence = zeroConfidence(fact, calls)
+				v.findings = append(v.findings, f)
+			}
+			// The nil path has already panicked here.
+			delete(st, obj)
+		}
+		return true
+	})
+}
+
+// isImplicitDeref reports whether sel reads through a pointer: a field
+// access, or a value-receiver method, on a pointer operand.
+func isImplicitDeref(info *types.Info, sel *ast.SelectorExpr) bool {
+	s := info.Selections[sel]
+	if s == nil {
+		return false
+	}
+	if _, ok := s.Recv().Underlying().(*types.Pointer); !ok {
+		return false
+	}
+	switch s.Kind() {
+	case types.FieldVal:
+		return true
+	case types.MethodVal:
+		recv := s.Obj().Type().(*types.Signature).Recv()
+		_, ptrRecv := recv.Type().Underlying().(*types.Pointer)
+		return !ptrRecv
+	}
+	return false
+}
+
+// describeZero explains, for a finding message, why a variable still holds
+// its zero value (called what, e.g. "nil").
+func describeZero(ctx *Context, fact *zeroFact, what string) string {
+	var origin string
+	if fact.explicit {
+		origin = "assigned " + what + " at line " + strconv.Itoa(ctx.Fset.Position(fact.decl).Line)
+	} else {
+		origin = "declared at line " + strconv.Itoa(ctx.Fset.Position(fact.decl).Line)
+	}
+	if fact.cond != "" {
+		return "it is still " + what + " when " + fact.cond + " (" + origin + ")"
+	}
+	return "it is " + what + " on every path to this use (" + origin + ")"
+}
+
+// zeroConfidence is the confidence of a finding about a variable that is
+// still zero as fact describes: high if it is zero on every path or calls
+// are known to take the branch that leaves it so, medium if that depends on
+// a condition that may never hold.
+func zeroConfidence(fact *zeroFact, calls []Related) Confidence {
+	if fact.cond == "" || len(calls) > 0 {
+		return ConfidenceHigh
+	}
+	return ConfidenceMedium
+}
+
+// callsTakingBranch returns the call sites whose literal arguments make
+// the branch condition recorded in fact come out the way that leaves the
+// variable unassigned.
+func callsTakingBranch(ctx *Context, ar *arith, fact *zeroFact) []Related {
+	if fact.condExpr == nil {
+		return nil
+	}
+	want := isFalse
+	if fact.condTruth {
+		want = isTrue
+	}
+	var related []Related
+	ast.Inspect(fact.condExpr, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || !isLenOrCap(ctx.Info, call) {
+			return true
+		}
+		x := call.Args[0]
+		atom := types.ExprString(call)
+		if f, ok := ctx.argFactFor(x, func(l int64) bool { return ar.evalCond(fact.condExpr, atom, l) == want }); ok {
+			related = append(related, f.related(ctx, x))
+		}
+		return false
+	})
+	return related
+}
@@ -0,0 +1,296 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// NilMapWriteDetector reports writes to maps that may be nil, `m[k] = v`,
+// `m[k] += v` and `m[k]++`, which panic. Reading from, ranging over and
+// deleting from a nil map are fine and not reported. Three kinds of map are
+// followed: local variables declared `var m map[K]V` or assigned nil, on
+// paths where they haven't since been given a map; map parameters written
+// without a nil check, if a call in the package passes nil for them or the
+// function is exported, so that callers elsewhere may; and unexported
+// struct fields that nothing in the package ever assigns.
+type NilMapWriteDetector struct{}
+
+func (NilMapWriteDetector) Name() string { return "nil-map-write" }
+
+func (NilMapWriteDetector) Description() string {
+	return "Write to a map that is nil on some path or is never made"
+}
+
+func (NilMapWriteDetector) DefaultSeverity() Severity { return SeverityError }
+
+func (NilMapWriteDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: "Reading a nil map returns zero values, but writing to one panics. A map declared with `var`, a struct field nothing initializes, or a parameter a caller may pass as nil is nil until made with make or a literal.",
+		Example: `var counts map[string]int
+counts[word]++`,
+		Fix: `counts := make(map[string]int)
+counts[word]++`,
+	}
+}
+
+func (d NilMapWriteDetector) Check(ctx *Context) []Finding {
+	v := &nilMapVisitor{ctx: ctx, seen: map[ast.Node]bool{}, unmade: unmadeMapFields(ctx), nilArgs: map[*types.Var]argFact{}}
+	for _, f := range ctx.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			var params []*ast.Ident
+			var body *ast.BlockStmt
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				if n.Body == nil {
+					return true
+				}
+				body = n.Body
+				params = v.nilableParams(n)
+			case *ast.FuncLit:
+				body = n.Body
+			default:
+				return true
+			}
+			v.ar = newArith(ctx.Info, body)
+			walkFlowParams(ctx.Info, params, body, v)
+			return true
+		})
+	}
+	return v.findings
+}
+
+type nilMapVisitor struct {
+	ctx      *Context
+	ar       *arith
+	seen     map[ast.Node]bool
+	findings []Finding
+
+	// unmade holds the map fields that are never assigned, and nilArgs a
+	// call passing nil for each parameter that has one.
+	unmade  map[*types.Var]bool
+	nilArgs map[*types.Var]argFact
+}
+
+func (v *nilMapVisitor) track(obj *types.Var) bool {
+	if _, ok := obj.Type().(*types.TypeParam); ok {
+		return false
+	}
+	_, ok := obj.Type().Underlying().(*types.Map)
+	return ok
+}
+
+// nilableParams returns the map parameters of fd that may be passed nil:
+// those a call in the package passes nil for, and all of them if callers
+// outside the package can call fd.
+func (v *nilMapVisitor) nilableParams(fd *ast.FuncDecl) []*ast.Ident {
+	fn, ok := v.ctx.Info.Defs[fd.Name].(*types.Func)
+	if !ok {
+		return nil
+	}
+	exported := fn.Exported()
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+		t := recv.Type()
+		if p, ok := t.(*types.Pointer); ok {
+			t = p.Elem()
+		}
+		named, ok := t.(*types.Named)
+		exported = exported && ok && named.Obj().Exported()
+	}
+	var params []*ast.Ident
+	for _, field := range fd.Type.Params.List {
+		for _, name := range field.Names {
+			p := identVar(v.ctx.Info, name)
+			if p == nil || !v.track(p) {
+				continue
+			}
+			for _, f := range v.ctx.argFacts()[p] {
+				if isNilExpr(v.ctx.Info, f.arg) {
+					v.nilArgs[p] = f
+					break
+				}
+			}
+			if _, ok := v.nilArgs[p]; ok || exported {
+				params = append(params, name)
+			}
+		}
+	}
+	return params
+}
+
+func (v *nilMapVisitor) visit(n ast.Node, st flowState) {
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				v.checkWrite(n, lhs, st)
+			}
+		case *ast.IncDecStmt:
+			v.checkWrite(n, n.X, st)
+		}
+		return true
+	})
+}
+
+// checkWrite reports the statement n if it writes to the map element lhs
+// of a map that may be nil in st or is a field that is never assigned.
+func (v *nilMapVisitor) checkWrite(n ast.Node, lhs ast.Expr, st flowState) {
+	ix, ok := ast.Unparen(lhs).(*ast.IndexExpr)
+	if !ok {
+		return
+	}
+	t := v.ctx.Info.TypeOf(ix.X)
+	if t == nil {
+		return
+	}
+	if _, ok := t.Underlying().(*types.Map); !ok {
+		return
+	}
+	if sel, ok := ast.Unparen(ix.X).(*ast.SelectorExpr); ok {
+		if field, ok := v.ctx.Info.Uses[sel.Sel].(*types.Var); ok && v.unmade[field.Origin()] && !v.seen[n] {
+			v.seen[n] = true
+			v.reportField(n, sel, field)
+		}
+		return
+	}
+	obj := exprVar(v.ctx.Info, ix.X)
+	if obj == nil {
+		return
+	}
+	fact, ok := st[obj]
+	if !ok {
+		return
+	}
+	if !v.seen[n] {
+		v.seen[n] = true
+		if fact.param {
+			v.reportParam(n, obj)
+		} else {
+			v.reportVar(n, obj, fact)
+		}
+	}
+	// The nil path has panicked here.
+	delete(st, obj)
+}
+
+func (v *nilMapVisitor) reportVar(n ast.Node, obj *types.Var, fact *zeroFact) {
+	f := v.ctx.NewFinding(NilMapWriteDetector{}.Name(), SeverityError, n,
+		"writing to nil map `%s` panics: %s", obj.Name(), describeZero(v.ctx, fact, "nil"))
+	msg := "`" + obj.Name() + "` is declared here without a value"
+	if fact.explicit {
+		msg = "`" + obj.Name() + "` is assigned nil here"
+	}
+	f.Related = append(f.Related, Related{Position: v.ctx.Fset.Position(fact.decl), Message: msg})
+	calls := callsTakingBranch(v.ctx, v.ar, fact)
+	f.Related = append(f.Related, calls...)
+	f.Confidence = zeroConfidence(fact, calls)
+	f.Suggestion = "make the map before writing to it: `" + obj.Name() + " = make(" + typeString(v.ctx, obj.Type()) + ")`"
+	v.findings = append(v.findings, f)
+}
+
+func (v *nilMapVisitor) reportParam(n ast.Node, obj *types.Var) {
+	f := v.ctx.NewFinding(NilMapWriteDetector{}.Name(), SeverityError, n,
+		"writing to map parameter `%s` panics if the caller passes nil; it is neither checked for nil nor replaced by a new map before this write", obj.Name())
+	if arg, ok := v.nilArgs[obj]; ok {
+		f.Related = append(f.Related, arg.related(v.ctx, ast.NewIdent(obj.Name())))
+		f.Confidence = ConfidenceMedium
+	} else {
+		// Only callers outside the package might pass nil, and the
+		// function may well document that they must not.
+		f.Confidence = ConfidenceLow
+	}
+	f.Suggestion = "check `" + obj.Name() + " == nil` first, or document that callers must pass a map made with make"
+	v.findings = append(v.findings, f)
+}
+
+func (v *nilMapVisitor) reportField(n ast.Node, sel *ast.SelectorExpr, field *types.Var) {
+	f := v.ctx.NewFinding(NilMapWriteDetector{}.Name(), SeverityError, n,
+		"writing to map field `%s` panics: nothing in package %s assigns the field, so it is always nil",
+		types.ExprString(sel), v.ctx.Pkg.Name())
+	f.Related = append(f.Related, Related{Position: v.ctx.Fset.Position(field.Pos()), Message: "`" + field.Name() + "` is declared here"})
+	// Only the files analyzed together are searched for assignments.
+	f.Confidence = ConfidenceMedium
+	f.Suggestion = "make the map where the struct is created, e.g. `" + field.Name() + ": make(" + typeString(v.ctx, field.Type()) + ")`"
+	v.findings = append(v.findings, f)
+}
+
+// unmadeMapFields returns the unexported map fields of the package's
+// struct types that are never assigned: not by an assignment to the field,
+// nor a composite literal, nor through a pointer to it. Other packages
+// can't assign unexported fields, and neither can encoding/json and the
+// like, so writes to such a field always panic.
+func unmadeMapFields(ctx *Context) map[*types.Var]bool {
+	fields := map[*types.Var]bool{}
+	for id, obj := range ctx.Info.Defs {
+		v, ok := obj.(*types.Var)
+		if !ok || !v.IsField() || v.Embedded() || id.IsExported() {
+			continue
+		}
+		if _, ok := v.Type().(*types.TypeParam); ok {
+			continue
+		}
+		if _, ok := v.Type().Underlying().(*types.Map); ok {
+			fields[v] = true
+		}
+	}
+	if len(fields) == 0 {
+		return fields
+	}
+	assigned := func(e ast.Expr) {
+		if sel, ok := ast.Unparen(e).(*ast.SelectorExpr); ok {
+			if v, ok := ctx.Info.Uses[sel.Sel].(*types.Var); ok {
+				delete(fields, v.Origin())
+			}
+		}
+	}
+	for _, f := range ctx.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				for _, lhs := range n.Lhs {
+					assigned(lhs)
+				}
+			case *ast.RangeStmt:
+				if n.Tok == token.ASSIGN {
+					for _, e := range []ast.Expr{n.Key, n.Value} {
+						if e != nil {
+							assigned(e)
+						}
+					}
+				}
+			case *ast.UnaryExpr:
+				if n.Op == token.AND {
+					assigned(n.X)
+				}
+			case *ast.CompositeLit:
+				t := ctx.Info.TypeOf(n)
+				if t == nil {
+					break
+				}
+				st, ok := t.Underlying().(*types.Struct)
+				if !ok {
+					break
+				}
+				for _, elt := range n.Elts {
+					kv, ok := elt.(*ast.KeyValueExpr)
+					if !ok {
+						// Positional literals set every field.
+						for i := range st.NumFields() {
+							delete(fields, st.Field(i).Origin())
+						}
+						break
+					}
+					if key, ok := kv.Key.(*ast.Ident); ok {
+						if v, ok := ctx.Info.Uses[key].(*types.Var); ok {
+							delete(fields, v.Origin())
+						}
+					}
+				}
+			}
+			return true
+		})
+	}
+	return fields
+}
@@ -0,0 +1,357 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// NonTerminatingLoopDetector reports `for cond` loops that, once entered,
+// can never end: nothing leaves the loop (no break, return, goto or call
+// that doesn't return), and either no variable in cond is modified by the
+// loop, as in `for i := 0; i < len(s); {` without `i++`, or cond compares
+// `a < b` and the loop only ever grows b while leaving a alone. Conditions
+// that call functions or read fields, and variables that are global,
+// referenced by a function literal or have their address taken, are not
+// analyzed, since something else may change them.
+type NonTerminatingLoopDetector struct{}
+
+func (NonTerminatingLoopDetector) Name() string { return "non-terminating-loop" }
+
+func (NonTerminatingLoopDetector) Description() string {
+	return "Loop whose condition can never become false"
+}
+
+func (NonTerminatingLoopDetector) DefaultSeverity() Severity { return SeverityWarning }
+
+func (NonTerminatingLoopDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: `A loop whose condition depends only on variables the loop never changes, and that has no break or return, either never runs or never ends. It is usually a forgotten increment, or one applied to the wrong variable.`,
+		Example: `for i := 0; i < len(s); {
+	process(s[i])
+}`,
+		Fix: `for i := 0; i < len(s); i++ {
+	process(s[i])
+}`,
+	}
+}
+
+func (d NonTerminatingLoopDetector) Check(ctx *Context) []Finding {
+	var findings []Finding
+	forEachFuncDecl(ctx, func(body *ast.BlockStmt) {
+		escaped := escapedVars(ctx.Info, body)
+		ast.Inspect(body, func(n ast.Node) bool {
+			loop, ok := n.(*ast.ForStmt)
+			if !ok || loop.Cond == nil {
+				return true
+			}
+			if f := d.checkLoop(ctx, loop, escaped); f != nil {
+				findings = append(findings, *f)
+			}
+			return true
+		})
+	})
+	return findings
+}
+
+// A direction is how a loop changes a value: not at all, only upwards,
+// only downwards, or in a way that isn't known.
+type direction int
+
+const (
+	unchanged direction = iota
+	grows
+	shrinks
+	changes
+)
+
+func (d direction) join(e direction) direction {
+	switch {
+	case d == unchanged:
+		return e
+	case e == unchanged || d == e:
+		return d
+	}
+	return changes
+}
+
+// loopWrites returns how the statements in n change each variable they
+// assign to.
+func loopWrites(info *types.Info, n ast.Node) map[*types.Var]direction {
+	writes := map[*types.Var]direction{}
+	write := func(lhs ast.Expr, dir direction) {
+		if v := exprVar(info, lhs); v != nil {
+			writes[v] = writes[v].join(dir)
+		}
+	}
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IncDecStmt:
+			if n.Tok == token.INC {
+				write(n.X, grows)
+			} else {
+				write(n.X, shrinks)
+			}
+		case *ast.AssignStmt:
+			for i, lhs := range n.Lhs {
+				dir := changes
+				if len(n.Lhs) == len(n.Rhs) {
+					dir = assignDirection(info, n.Tok, lhs, n.Rhs[i])
+				}
+				write(lhs, dir)
+			}
+		case *ast.RangeStmt:
+			if n.Tok == token.ASSIGN {
+				for _, e := range []ast.Expr{n.Key, n.Value} {
+					if e != nil {
+						write(e, changes)
+					}
+				}
+			}
+		}
+		return true
+	})
+	return writes
+}
+
+// assignDirection returns how `lhs tok rhs` changes lhs: `+= c` and
+// `= append(lhs, ...)` grow it (the latter its length), `-= c` shrinks it.
+func assignDirection(info *types.Info, tok token.Token, lhs, rhs ast.Expr) direction {
+	sign := func(e ast.Expr) int {
+		if tv, ok := info.Types[e]; ok && tv.Value != nil {
+			switch tv.Value.Kind() {
+			case constant.Int, constant.Float:
+				return constant.Sign(tv.Value)
+			}
+		}
+		return 0
+	}
+	switch tok {
+	case token.ADD_ASSIGN:
+		if s := sign(rhs); s > 0 {
+			return grows
+		} else if s < 0 {
+			return shrinks
+		}
+	case token.SUB_ASSIGN:
+		if s := sign(rhs); s > 0 {
+			return shrinks
+		} else if s < 0 {
+			return grows
+		}
+	case token.ASSIGN:
+		if call, ok := ast.Unparen(rhs).(*ast.CallExpr); ok && isBuiltin(info, call, "append") &&
+			len(call.Args) > 1 && sameExpr(info, call.Args[0], lhs) {
+			return grows
+		}
+	}
+	return changes
+}
+
+// condVars returns the variables cond reads, directly or as the length of
+// a slice or string, or false if cond depends on anything else that could
+// change: a call, a field or element, a receive, a global or escaped
+// variable.
+func condVars(info *types.Info, cond ast.Expr, escaped map[*types.Var]bool, pkg *types.Package) ([]*types.Var, bool) {
+	var vars []*types.Var
+	ok := true
+	ast.Inspect(cond, func(n ast.Node) bool {
+		if !ok || n == nil {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.Ident:
+			switch obj := info.Uses[n].(type) {
+			case *types.Var:
+				if obj.Parent() == nil || obj.Parent() == pkg.Scope() || escaped[obj] {
+					ok = false
+				} else {
+					vars = append(vars, obj)
+				}
+			case *types.Const, *types.Nil, *types.Builtin, *types.TypeName:
+			default:
+				ok = false
+			}
+		case *ast.CallExpr:
+			if tv, found := info.Types[n.Fun]; found && tv.IsType() {
+				return true // a conversion
+			}
+			// The length of a map or channel changes without an
+			// assignment to it.
+			ok = isLenOrCap(info, n) && isSliceOrString(info, n.Args[0])
+		case *ast.BinaryExpr, *ast.ParenExpr, *ast.BasicLit:
+		case *ast.UnaryExpr:
+			ok = n.Op != token.ARROW
+		default:
+			ok = false
+		}
+		return ok
+	})
+	return vars, ok && len(vars) > 0
+}
+
+// leavesLoop reports whether body may leave the loop other than by its
+// condition becoming false.
+func leavesLoop(info *types.Info, body *ast.BlockStmt) bool {
+	// inner holds the labels of statements in body; breaking to one of
+	// them stays in the loop.
+	inner := map[string]bool{}
+	ast.Inspect(body, func(n ast.Node) bool {
+		if l, ok := n.(*ast.LabeledStmt); ok {
+			inner[l.Label.Name] = true
+		}
+		return true
+	})
+	leaves := false
+	var visit func(n ast.Node, breakable bool) bool
+	visit = func(n ast.Node, breakable bool) bool {
+		if leaves {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			leaves = true
+		case *ast.BranchStmt:
+			switch {
+			case n.Tok == token.GOTO:
+				leaves = true
+			case n.Tok == token.BREAK && n.Label == nil:
+				leaves = !breakable
+			case n.Label != nil && !inner[n.Label.Name]:
+				leaves = true
+			}
+		case *ast.CallExpr:
+			leaves = isNoReturn(info, n) || stopsGoroutine(info, n)
+		case *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+			// An unlabeled break in here leaves only this statement.
+			ast.Inspect(n, func(m ast.Node) bool {
+				if m == n {
+					return true
+				}
+				return visit(m, true)
+			})
+			return false
+		}
+		return !leaves
+	}
+	ast.Inspect(body, func(n ast.Node) bool { return visit(n, false) })
+	return leaves
+}
+
+// stopsGoroutine reports whether call ends the goroutine without
+// returning, like testing's Fatal and FailNow or runtime.Goexit.
+func stopsGoroutine(info *types.Info, call *ast.CallExpr) bool {
+	fn := calleeFunc(info, call)
+	if fn == nil {
+		return false
+	}
+	name := fn.FullName()
+	if name == "runtime.Goexit" {
+		return true
+	}
+	for _, prefix := range []string{"(*testing.common).", "(testing.TB)."} {
+		if m, ok := strings.CutPrefix(name, prefix); ok {
+			return strings.HasPrefix(m, "Fatal") || strings.HasPrefix(m, "Skip") || m == "FailNow"
+		}
+	}
+	return false
+}
+
+func (d NonTerminatingLoopDetector) checkLoop(ctx *Context, loop *ast.ForStmt, escaped map[*types.Var]bool) *Finding {
+	if tv, ok := ctx.Info.Types[loop.Cond]; ok && tv.Value != nil {
+		return nil // `for true`, deliberately endless
+	}
+	vars, ok := condVars(ctx.Info, loop.Cond, escaped, ctx.Pkg)
+	if !ok || leavesLoop(ctx.Info, loop.Body) {
+		return nil
+	}
+	writes := loopWrites(ctx.Info, loop.Body)
+	if loop.Post != nil {
+		for v, dir := range loopWrites(ctx.Info, loop.Post) {
+			writes[v] = writes[v].join(dir)
+		}
+	}
+	cond := types.ExprString(loop.Cond)
+	var names []string
+	written := false
+	seen := map[*types.Var]bool{}
+	for _, v := range vars {
+		if !seen[v] {
+			seen[v] = true
+			names = append(names, "`"+v.Name()+"`")
+		}
+		written = written || writes[v] != unchanged
+	}
+	if !written {
+		f := ctx.NewFinding(d.Name(), SeverityWarning, loop.Cond,
+			"loop condition `%s` never changes: the loop modifies none of %s and has no break or return, so once entered it never ends",
+			cond, strings.Join(names, ", "))
+		f.Suggestion = "update the loop variable in the loop, or leave it with break or return"
+		if loop.Post == nil && loop.Init != nil {
+			for _, v := range vars {
+				if definesVar(ctx.Info, loop.Init, v) {
+					f.Suggestion = "add a post statement such as `" + v.Name() + "++` that moves `" + v.Name() + "` towards the end of the loop"
+					break
+				}
+			}
+		}
+		return &f
+	}
+
+	// For `a < b`, the loop ends only if a grows or b shrinks.
+	bin, ok := ast.Unparen(loop.Cond).(*ast.BinaryExpr)
+	if !ok {
+		return nil
+	}
+	lo, hi := bin.X, bin.Y
+	switch bin.Op {
+	case token.LSS, token.LEQ:
+	case token.GTR, token.GEQ:
+		lo, hi = hi, lo
+	default:
+		return nil
+	}
+	dirLo, okLo := termDirection(ctx.Info, lo, writes)
+	dirHi, okHi := termDirection(ctx.Info, hi, writes)
+	if !okLo || !okHi || dirLo == grows || dirLo == changes || dirHi != grows {
+		return nil
+	}
+	f := ctx.NewFinding(d.Name(), SeverityWarning, loop.Cond,
+		"loop condition `%s` never becomes false: `%s` only grows in the loop while `%s` never catches up, so once entered the loop never ends",
+		cond, types.ExprString(hi), types.ExprString(lo))
+	f.Suggestion = "check the loop's updates: `" + types.ExprString(lo) + "` has to catch up with `" + types.ExprString(hi) + "` for the loop to end"
+	f.Confidence = ConfidenceMedium
+	return &f
+}
+
+// termDirection returns how the loop moves e, which must be a constant,
+// a variable or len(x) of a variable, possibly plus or minus a constant.
+func termDirection(info *types.Info, e ast.Expr, writes map[*types.Var]direction) (direction, bool) {
+	e = ast.Unparen(e)
+	if tv, ok := info.Types[e]; ok && tv.Value != nil {
+		return unchanged, true
+	}
+	switch e := e.(type) {
+	case *ast.Ident:
+		if v := identVar(info, e); v != nil {
+			return writes[v], true
+		}
+	case *ast.CallExpr:
+		if isBuiltin(info, e, "len") && len(e.Args) == 1 {
+			if v := exprVar(info, e.Args[0]); v != nil {
+				return writes[v], true
+			}
+		}
+	case *ast.BinaryExpr:
+		if e.Op == token.ADD || e.Op == token.SUB {
+			if tv, ok := info.Types[e.Y]; ok && tv.Value != nil {
+				return termDirection(info, e.X, writes)
+			}
+		}
+	}
+	return changes, false
+}
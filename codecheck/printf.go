@@ -0,0 +1,489 @@
+package codecheck
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"strings"
+	"unicode/utf8"
+)
+
+// PrintfDetector reports calls to printf-like functions whose format string
+// doesn't match their arguments: a verb applied to an argument of the wrong
+// type (`%d` with a string), a verb with no argument left to format, and
+// arguments that no verb formats. Besides the fmt, log and testing
+// functions, it checks functions of the package marked with a
+// `//codecheck:printf` line in their doc comment, functions that forward
+// their format and arguments to another printf-like function, and the
+// functions listed in Funcs. Only constant format strings are checked.
+type PrintfDetector struct {
+	// Funcs lists further printf-like functions, by the name
+	// types.Func.FullName reports: "example.com/log.Infof",
+	// "(*example.com/log.Logger).Debugf". Their last two parameters must
+	// be the format string and a ...any of arguments.
+	Funcs []string
+}
+
+func (PrintfDetector) Name() string { return "printf" }
+
+func (PrintfDetector) Description() string {
+	return "Printf-style format string that doesn't match its arguments"
+}
+
+func (PrintfDetector) DefaultSeverity() Severity { return SeverityWarning }
+
+func (PrintfDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: "A format string whose verbs don't match the arguments prints garbage such as `%!d(string=x)` or `%!s(MISSING)`, usually in a log line or error message that is only read when something has already gone wrong.",
+		Example:   `log.Printf("user %d logged in", name)`,
+		Fix:       `log.Printf("user %s logged in", name)`,
+	}
+}
+
+// printfDirective marks a function as printf-like in its doc comment.
+const printfDirective = "//codecheck:printf"
+
+var printfFuncs = []string{
+	"fmt.Appendf", "fmt.Errorf", "fmt.Fprintf", "fmt.Printf", "fmt.Sprintf",
+	"log.Fatalf", "log.Panicf", "log.Printf",
+	"(*log.Logger).Fatalf", "(*log.Logger).Panicf", "(*log.Logger).Printf",
+	"(*testing.common).Errorf", "(*testing.common).Fatalf", "(*testing.common).Logf", "(*testing.common).Skipf",
+	"(testing.TB).Errorf", "(testing.TB).Fatalf", "(testing.TB).Logf", "(testing.TB).Skipf",
+}
+
+func (d PrintfDetector) Check(ctx *Context) []Finding {
+	known := map[string]bool{}
+	for _, name := range printfFuncs {
+		known[name] = true
+	}
+	for _, name := range d.Funcs {
+		known[name] = true
+	}
+	// Functions of this package are printf-like when marked so, or when
+	// they pass their format and arguments on to one that is; the latter
+	// is repeated until nothing changes so that wrappers of wrappers count.
+	local := map[*types.Func]bool{}
+	var wrappers []*ast.FuncDecl
+	for _, f := range ctx.Files {
+		for _, decl := range f.Decls {
+			fd, ok := decl.(*ast.FuncDecl)
+			if !ok || fd.Body == nil {
+				continue
+			}
+			fn, _ := ctx.Info.Defs[fd.Name].(*types.Func)
+			if fn == nil || printfFormatIndex(fn) < 0 {
+				continue
+			}
+			if hasDirective(fd.Doc, printfDirective) {
+				local[fn] = true
+			} else {
+				wrappers = append(wrappers, fd)
+			}
+		}
+	}
+	isPrintf := func(fn *types.Func) bool {
+		return fn != nil && (local[fn] || known[fn.FullName()]) && printfFormatIndex(fn) >= 0
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, fd := range wrappers {
+			fn := ctx.Info.Defs[fd.Name].(*types.Func)
+			if !local[fn] && forwardsPrintf(ctx, fd, fn, isPrintf) {
+				local[fn] = true
+				changed = true
+			}
+		}
+	}
+
+	var findings []Finding
+	for _, f := range ctx.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			if fn := calleeFunc(ctx.Info, call); isPrintf(fn) {
+				findings = append(findings, d.checkCall(ctx, call, fn)...)
+			}
+			return true
+		})
+	}
+	return findings
+}
+
+// printfFormatIndex returns the index of fn's format parameter, the string
+// parameter before a final ...any, or -1 if fn doesn't have one.
+func printfFormatIndex(fn *types.Func) int {
+	sig := fn.Type().(*types.Signature)
+	params := sig.Params()
+	if !sig.Variadic() || params.Len() < 2 {
+		return -1
+	}
+	if s, ok := params.At(params.Len() - 1).Type().(*types.Slice); !ok || !isEmptyInterface(s.Elem()) {
+		return -1
+	}
+	if b, ok := params.At(params.Len() - 2).Type().Underlying().(*types.Basic); !ok || b.Info()&types.IsString == 0 {
+		return -1
+	}
+	return params.Len() - 2
+}
+
+func isEmptyInterface(t types.Type) bool {
+	it, ok := t.Underlying().(*types.Interface)
+	return ok && it.Empty()
+}
+
+// forwardsPrintf reports whether the body of fd, declaring fn, calls a
+// printf-like function with fn's own format and `args...`.
+func forwardsPrintf(ctx *Context, fd *ast.FuncDecl, fn *types.Func, isPrintf func(*types.Func) bool) bool {
+	params := fn.Type().(*types.Signature).Params()
+	format, args := params.At(params.Len()-2), params.At(params.Len()-1)
+	found := false
+	ast.Inspect(fd.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || found || !call.Ellipsis.IsValid() {
+			return !found
+		}
+		callee := calleeFunc(ctx.Info, call)
+		if callee == fn || !isPrintf(callee) {
+			return true
+		}
+		i := printfFormatIndex(callee)
+		if len(call.Args) == i+2 && exprVar(ctx.Info, call.Args[i]) == format && exprVar(ctx.Info, call.Args[i+1]) == args {
+			found = true
+		}
+		return true
+	})
+	return found
+}
+
+// hasDirective reports whether doc contains the comment line directive.
+func hasDirective(doc *ast.CommentGroup, directive string) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if text := c.Text; text == directive || strings.HasPrefix(text, directive+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// printfVerb is one formatting directive of a format string.
+type printfVerb struct {
+	text string // the directive as written, e.g. "%-8.2f"
+	verb rune
+	// args are the indexes of the arguments the directive consumes, in
+	// order: those of a `*` width and precision, then the formatted value.
+	// stars is how many of them are for `*`.
+	args  []int
+	stars int
+}
+
+// parsePrintf splits format into its directives, numbering arguments as
+// fmt does, starting from 0. used is the number of arguments the format
+// reads, or -1 if it uses explicit argument indexes such as `%[2]d`; bad
+// is the rest of the format from a directive that doesn't parse.
+func parsePrintf(format string) (verbs []printfVerb, used int, bad string) {
+	arg := 0
+	indexed := false
+	for i := 0; i < len(format); {
+		if format[i] != '%' {
+			i++
+			continue
+		}
+		start := i
+		i++
+		v := printfVerb{}
+		for i < len(format) && strings.IndexByte("+-# 0", format[i]) >= 0 {
+			i++
+		}
+		// index parses an explicit argument index, if present.
+		index := func() bool {
+			if i >= len(format) || format[i] != '[' {
+				return true
+			}
+			end := strings.IndexByte(format[i:], ']')
+			n := 0
+			if end < 2 {
+				return false
+			}
+			for _, c := range format[i+1 : i+end] {
+				if c < '0' || c > '9' {
+					return false
+				}
+				n = n*10 + int(c-'0')
+			}
+			if n == 0 {
+				return false
+			}
+			arg = n - 1
+			indexed = true
+			i += end + 1
+			return true
+		}
+		// number parses a width or precision: digits, or `*` reading an
+		// argument.
+		number := func() {
+			if i < len(format) && format[i] == '*' {
+				v.args = append(v.args, arg)
+				v.stars++
+				arg++
+				i++
+				return
+			}
+			for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+				i++
+			}
+		}
+		ok := index()
+		number()
+		if ok && i < len(format) && format[i] == '.' {
+			i++
+			ok = index()
+			number()
+		}
+		if ok {
+			ok = index()
+		}
+		if !ok || i >= len(format) {
+			return nil, 0, format[start:]
+		}
+		r, size := utf8.DecodeRuneInString(format[i:])
+		i += size
+		v.verb = r
+		v.text = format[start:i]
+		if r != '%' {
+			v.args = append(v.args, arg)
+			arg++
+		}
+		used = max(used, arg)
+		verbs = append(verbs, v)
+	}
+	if indexed {
+		used = -1
+	}
+	return verbs, used, ""
+}
+
+// printfArgKind is a set of argument kinds a verb accepts.
+type printfArgKind int
+
+const (
+	argBool printfArgKind = 1 << iota
+	argInt
+	argRune
+	argFloat
+	argComplex
+	argString
+	argPointer
+	argError
+	argAny
+)
+
+var printfVerbs = map[rune]printfArgKind{
+	'v': argAny,
+	'T': argAny,
+	't': argBool,
+	'b': argInt | argFloat | argComplex | argPointer,
+	'c': argRune | argInt,
+	'd': argInt | argPointer,
+	'o': argInt | argPointer,
+	'O': argInt | argPointer,
+	'q': argRune | argInt | argString,
+	'x': argRune | argInt | argFloat | argComplex | argString | argPointer,
+	'X': argRune | argInt | argFloat | argComplex | argString | argPointer,
+	'U': argRune | argInt,
+	'e': argFloat | argComplex,
+	'E': argFloat | argComplex,
+	'f': argFloat | argComplex,
+	'F': argFloat | argComplex,
+	'g': argFloat | argComplex,
+	'G': argFloat | argComplex,
+	's': argString,
+	'p': argPointer,
+	'w': argError,
+}
+
+// printfKindNames describes what a verb expects, for messages.
+var printfKindNames = []struct {
+	kind printfArgKind
+	name string
+}{
+	{argBool, "a bool"},
+	{argInt | argRune, "an integer"},
+	{argFloat | argComplex, "a floating-point or complex number"},
+	{argString, "a string or []byte"},
+	{argPointer, "a pointer"},
+	{argError, "an error"},
+}
+
+func (d PrintfDetector) checkCall(ctx *Context, call *ast.CallExpr, fn *types.Func) []Finding {
+	fi := printfFormatIndex(fn)
+	if len(call.Args) <= fi {
+		return nil
+	}
+	tv, ok := ctx.Info.Types[call.Args[fi]]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return nil
+	}
+	format := constant.StringVal(tv.Value)
+	args := call.Args[fi+1:]
+	name := fn.FullName()
+	var findings []Finding
+	verbs, used, bad := parsePrintf(format)
+	if bad != "" {
+		return append(findings, ctx.NewFinding(d.Name(), SeverityWarning, call.Args[fi],
+			"format of %s has an incomplete or malformed directive `%s`", name, bad))
+	}
+	// With `args...` the number of arguments is not known.
+	spread := call.Ellipsis.IsValid()
+	for _, v := range verbs {
+		if v.verb == '%' {
+			continue
+		}
+		kind, ok := printfVerbs[v.verb]
+		if !ok {
+			findings = append(findings, ctx.NewFinding(d.Name(), SeverityWarning, call.Args[fi],
+				"format of %s has unknown verb `%s`", name, v.text))
+			continue
+		}
+		if v.verb == 'w' && strings.HasPrefix(name, "fmt.") && name != "fmt.Errorf" {
+			findings = append(findings, ctx.NewFinding(d.Name(), SeverityWarning, call.Args[fi],
+				"%s does not support the error-wrapping verb `%%w`; use `%%v`", name))
+			continue
+		}
+		for j, ai := range v.args {
+			if ai >= len(args) {
+				if !spread {
+					findings = append(findings, ctx.NewFinding(d.Name(), SeverityWarning, call.Args[fi],
+						"`%s` in the format of %s reads argument %d, but the call has %s",
+						v.text, name, ai+1, plural(len(args), "argument")))
+				}
+				break
+			}
+			if spread && ai == len(args)-1 {
+				break // the slice, not one of its elements
+			}
+			want := kind
+			if j < v.stars {
+				want = argInt
+			}
+			t := ctx.Info.TypeOf(args[ai])
+			if t == nil || printfMatches(t, want, map[types.Type]bool{}) {
+				continue
+			}
+			what := "an argument"
+			for _, k := range printfKindNames {
+				if k.kind&want != 0 {
+					what = k.name
+					break
+				}
+			}
+			if j < v.stars {
+				what = "an int width or precision"
+			}
+			findings = append(findings, ctx.NewFinding(d.Name(), SeverityWarning, args[ai],
+				"`%s` in the format of %s expects %s, but argument %d `%s` is %s",
+				v.text, name, what, ai+1, ctx.sourceText(args[ai]), typeString(ctx, t)))
+		}
+	}
+	if used >= 0 && !spread && len(args) > used {
+		f := ctx.NewFinding(d.Name(), SeverityWarning, args[used],
+			"%s has %s but its format uses %s; `%s` is not formatted",
+			name, plural(len(args), "argument"), plural(used, "argument"), ctx.sourceText(args[used]))
+		if used == 0 {
+			f.Suggestion = "add a verb such as `%v` to the format, or call the non-formatting variant"
+		}
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// printfMatches reports whether a value of type t can be formatted by a
+// verb accepting kind: values implementing fmt.Formatter, interfaces and
+// type parameters always match, errors and fmt.Stringers match string
+// verbs, and slices, arrays, maps and structs match if their elements do
+// (slices and maps also match %p). seen guards against recursive types.
+func printfMatches(t types.Type, kind printfArgKind, seen map[types.Type]bool) bool {
+	if kind&argAny != 0 || seen[t] {
+		return true
+	}
+	seen[t] = true
+	if hasMethod(t, "Format") {
+		return true
+	}
+	if kind&argError != 0 {
+		return types.Implements(t, errorType)
+	}
+	if kind&argString != 0 && (types.Implements(t, errorType) || hasMethod(t, "String")) {
+		return true
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Interface, *types.TypeParam:
+		return true
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return kind&argBool != 0
+		case u.Info()&types.IsInteger != 0:
+			return kind&(argInt|argRune) != 0
+		case u.Info()&types.IsFloat != 0:
+			return kind&argFloat != 0
+		case u.Info()&types.IsComplex != 0:
+			return kind&argComplex != 0
+		case u.Info()&types.IsString != 0:
+			return kind&argString != 0
+		case u.Kind() == types.UnsafePointer:
+			return kind&argPointer != 0
+		}
+		return false
+	case *types.Slice:
+		if b, ok := u.Elem().Underlying().(*types.Basic); ok && b.Kind() == types.Byte && kind&argString != 0 {
+			return true
+		}
+		return kind == argPointer || printfMatches(u.Elem(), kind, seen)
+	case *types.Array:
+		return printfMatches(u.Elem(), kind, seen)
+	case *types.Map:
+		return kind == argPointer || printfMatches(u.Key(), kind, seen) && printfMatches(u.Elem(), kind, seen)
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if !printfMatches(u.Field(i).Type(), kind, seen) {
+				return false
+			}
+		}
+		return true
+	case *types.Pointer:
+		if kind&argPointer != 0 {
+			return true
+		}
+		// fmt prints a pointer to a composite value as &{...}.
+		switch u.Elem().Underlying().(type) {
+		case *types.Struct, *types.Array, *types.Slice, *types.Map:
+			return printfMatches(u.Elem(), kind, seen)
+		}
+		return false
+	case *types.Chan, *types.Signature:
+		return kind&argPointer != 0
+	}
+	return false
+}
+
+// hasMethod reports whether t has a method of the given name.
+func hasMethod(t types.Type, name string) bool {
+	if obj, _, _ := types.LookupFieldOrMethod(t, false, nil, name); obj != nil {
+		_, ok := obj.(*types.Func)
+		return ok
+	}
+	return false
+}
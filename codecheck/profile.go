@@ -0,0 +1,63 @@
+package codecheck
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Profile accumulates the time each detector takes, over every analysis
+// made by the Analyzers it is passed to in Options.Profile. Detectors
+// running in parallel each add their own time, so the totals can exceed
+// the wall-clock time of a run. Findings served from the cache cost no
+// detector time and are not counted. The zero Profile is ready to use, and
+// a Profile is safe for concurrent use.
+type Profile struct {
+	mu    sync.Mutex
+	rules map[string]*RuleProfile
+}
+
+// RuleProfile is one detector's share of a Profile.
+type RuleProfile struct {
+	Rule string
+	// Duration is the time spent in the detector's Check method.
+	Duration time.Duration
+	// Findings counts the findings it returned, before ignore directives,
+	// baselines and confidence filtering.
+	Findings int
+	// Files counts the files it was run over.
+	Files int
+}
+
+func (p *Profile) add(rule string, d time.Duration, findings, files int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.rules == nil {
+		p.rules = map[string]*RuleProfile{}
+	}
+	r := p.rules[rule]
+	if r == nil {
+		r = &RuleProfile{Rule: rule}
+		p.rules[rule] = r
+	}
+	r.Duration += d
+	r.Findings += findings
+	r.Files += files
+}
+
+// Rules returns the totals of every detector that has run, slowest first.
+func (p *Profile) Rules() []RuleProfile {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	rules := make([]RuleProfile, 0, len(p.rules))
+	for _, r := range p.rules {
+		rules = append(rules, *r)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Duration != rules[j].Duration {
+			return rules[i].Duration > rules[j].Duration
+		}
+		return rules[i].Rule < rules[j].Rule
+	})
+	return rules
+}
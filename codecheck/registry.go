@@ -0,0 +1,63 @@
+package codecheck
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var (
+	registryMu sync.Mutex
+	registered []Detector
+)
+
+// Register adds a custom detector to the ones every Analyzer created
+// afterwards runs, alongside the built-in detectors. Custom detectors are
+// configured, ignored and reported like the built-in ones, by their rule id.
+//
+// To stay clear of the built-in rules and of other organizations'
+// detectors, the rule id must be namespaced as "namespace/rule", such as
+// "acme/no-println": lower-case letters, digits and '-', '_' and '.', with
+// one or more slashes. Register panics if the id is malformed or already
+// registered, so it is best called from an init function.
+func Register(d Detector) {
+	id := d.Name()
+	if err := checkRuleID(id); err != nil {
+		panic("codecheck: Register: " + err.Error())
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, r := range registered {
+		if r.Name() == id {
+			panic("codecheck: Register: rule " + id + " is already registered")
+		}
+	}
+	registered = append(registered, d)
+}
+
+// checkRuleID reports whether id is a valid rule id for a custom detector.
+func checkRuleID(id string) error {
+	parts := strings.Split(id, "/")
+	if len(parts) < 2 {
+		return fmt.Errorf("rule id %q is not namespaced; use a form like \"acme/%s\"", id, id)
+	}
+	for _, p := range parts {
+		if p == "" {
+			return fmt.Errorf("rule id %q has an empty element", id)
+		}
+		for _, c := range p {
+			if !('a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.') {
+				return fmt.Errorf("rule id %q contains %q; use lower-case letters, digits, '-', '_' and '.'", id, c)
+			}
+		}
+	}
+	return nil
+}
+
+// allDetectors returns the built-in detectors followed by the registered
+// ones.
+func allDetectors(opts Options) []Detector {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return append(builtinDetectors(opts), registered...)
+}
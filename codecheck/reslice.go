@@ -0,0 +1,511 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"strconv"
+)
+
+// ResliceBoundsDetector reports slice expressions whose constant high or
+// max bound is past the capacity of the slice, or the length of the
+// string, being sliced: `s[:5]` where `s := make([]int, 3)` panics, and so
+// does `s = append(s[:4], x)`. Slicing up to the capacity is allowed, so
+// it is the capacity that is tracked, from slice literals,
+// `make(T, len, cap)`, string constants and slices of those, following
+// each local variable through the function: across the branches of if,
+// switch and select statements the largest capacity is kept, and a
+// variable assigned in a loop is forgotten at the loop. A capacity known
+// only as an upper bound, from a merge of branches or a slice with a
+// bound that isn't constant, gives medium confidence. Variables captured
+// by a closure or whose address is taken are not followed, and nor are
+// functions using goto.
+type ResliceBoundsDetector struct{}
+
+func (ResliceBoundsDetector) Name() string { return "reslice-bounds" }
+
+func (ResliceBoundsDetector) Description() string {
+	return "Slice expression bound past the known capacity of the slice"
+}
+
+func (ResliceBoundsDetector) DefaultSeverity() Severity { return SeverityError }
+
+func (ResliceBoundsDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: "A slice can be re-sliced up to its capacity, not beyond: `s[:n]` panics when n is greater than cap(s), and a string can't be sliced past its length. When the capacity is fixed by a literal or a make call a few lines up, a constant bound larger than it is a panic waiting for that line to run, often left over from a change to the make call.",
+		Example: `buf := make([]byte, 0, 4)
+buf = append(buf[:8], data...)`,
+		Fix: `buf := make([]byte, 0, 8)
+buf = append(buf[:8], data...)`,
+	}
+}
+
+func (d ResliceBoundsDetector) Check(ctx *Context) []Finding {
+	var findings []Finding
+	for _, f := range ctx.Files {
+		untracked := map[*types.Var]bool{}
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.UnaryExpr:
+				if n.Op == token.AND {
+					if v := exprVar(ctx.Info, n.X); v != nil {
+						untracked[v] = true
+					}
+				}
+			case *ast.FuncLit:
+				ast.Inspect(n.Body, func(m ast.Node) bool {
+					if id, ok := m.(*ast.Ident); ok {
+						if v, ok := ctx.Info.Uses[id].(*types.Var); ok && (v.Pos() < n.Pos() || v.Pos() >= n.End()) {
+							untracked[v] = true
+						}
+					}
+					return true
+				})
+			}
+			return true
+		})
+		ast.Inspect(f, func(n ast.Node) bool {
+			var body *ast.BlockStmt
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				body = n.Body
+			case *ast.FuncLit:
+				body = n.Body
+			}
+			if body == nil || usesGoto(body) {
+				return true
+			}
+			r := &reslicer{ctx: ctx, d: d, ar: newArith(ctx.Info, body), untracked: untracked}
+			r.stmts(body.List, capState{})
+			findings = append(findings, r.findings...)
+			return true
+		})
+	}
+	return findings
+}
+
+// usesGoto reports whether body, outside function literals, has a goto.
+func usesGoto(body *ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.BranchStmt:
+			found = found || n.Tok == token.GOTO
+		}
+		return !found
+	})
+	return found
+}
+
+// capFact is what is known of the capacity of a slice, or the length of a
+// string: at most n, exactly n if exact, as set by the expression from.
+type capFact struct {
+	n     int64
+	exact bool
+	from  ast.Expr
+}
+
+// capState maps the local variables whose capacity is known at a point of
+// a function to it.
+type capState map[*types.Var]capFact
+
+func (s capState) clone() capState {
+	c := make(capState, len(s))
+	for v, f := range s {
+		c[v] = f
+	}
+	return c
+}
+
+// mergeCaps returns what is known after paths arriving with a and with b join:
+// the larger of the two capacities, for the variables known on both.
+func mergeCaps(a, b capState) capState {
+	m := capState{}
+	for v, fa := range a {
+		fb, ok := b[v]
+		if !ok {
+			continue
+		}
+		f := fa
+		if fb.n > fa.n {
+			f = fb
+		}
+		f.exact = fa.exact && fb.exact && fa.n == fb.n
+		m[v] = f
+	}
+	return m
+}
+
+// A reslicer walks the statements of one function body, tracking
+// capacities and reporting slice expressions exceeding them.
+type reslicer struct {
+	ctx       *Context
+	d         ResliceBoundsDetector
+	ar        *arith
+	untracked map[*types.Var]bool
+	findings  []Finding
+}
+
+// stmts walks list from st and returns the state after it, and whether it
+// ends in a return or a call that never returns.
+func (r *reslicer) stmts(list []ast.Stmt, st capState) (capState, bool) {
+	for _, s := range list {
+		var done bool
+		if st, done = r.stmt(s, st); done {
+			return st, true
+		}
+	}
+	return st, false
+}
+
+func (r *reslicer) stmt(s ast.Stmt, st capState) (capState, bool) {
+	switch s := s.(type) {
+	case *ast.AssignStmt:
+		for _, e := range s.Rhs {
+			r.check(e, st)
+		}
+		for _, e := range s.Lhs {
+			r.check(e, st)
+		}
+		// The right-hand sides are evaluated before any is assigned.
+		facts := make([]*capFact, len(s.Lhs))
+		if len(s.Lhs) == len(s.Rhs) && (s.Tok == token.ASSIGN || s.Tok == token.DEFINE) {
+			for i, rhs := range s.Rhs {
+				if f, ok := r.capOf(rhs, st); ok {
+					f.from = rhs
+					facts[i] = &f
+				}
+			}
+		}
+		for i, lhs := range s.Lhs {
+			v := exprVar(r.ctx.Info, lhs)
+			if v == nil {
+				continue
+			}
+			delete(st, v)
+			if facts[i] != nil && !r.untracked[v] {
+				st[v] = *facts[i]
+			}
+		}
+	case *ast.DeclStmt:
+		gd, ok := s.Decl.(*ast.GenDecl)
+		if !ok {
+			break
+		}
+		for _, spec := range gd.Specs {
+			vs, ok := spec.(*ast.ValueSpec)
+			if !ok {
+				continue
+			}
+			for _, e := range vs.Values {
+				r.check(e, st)
+			}
+			for i, name := range vs.Names {
+				v := identVar(r.ctx.Info, name)
+				if v == nil || len(vs.Values) != len(vs.Names) || r.untracked[v] {
+					continue
+				}
+				if f, ok := r.capOf(vs.Values[i], st); ok {
+					f.from = vs.Values[i]
+					st[v] = f
+				}
+			}
+		}
+	case *ast.ExprStmt:
+		r.check(s.X, st)
+		if call, ok := ast.Unparen(s.X).(*ast.CallExpr); ok && isNoReturn(r.ctx.Info, call) {
+			return st, true
+		}
+	case *ast.ReturnStmt:
+		for _, e := range s.Results {
+			r.check(e, st)
+		}
+		return st, true
+	case *ast.SendStmt:
+		r.check(s.Chan, st)
+		r.check(s.Value, st)
+	case *ast.IncDecStmt:
+		r.check(s.X, st)
+	case *ast.GoStmt:
+		r.check(s.Call, st)
+	case *ast.DeferStmt:
+		r.check(s.Call, st)
+	case *ast.LabeledStmt:
+		return r.stmt(s.Stmt, st)
+	case *ast.BlockStmt:
+		return r.stmts(s.List, st)
+	case *ast.IfStmt:
+		if s.Init != nil {
+			st, _ = r.stmt(s.Init, st)
+		}
+		r.check(s.Cond, st)
+		then, thenDone := r.stmts(s.Body.List, st.clone())
+		els, elsDone := st, false
+		if s.Else != nil {
+			els, elsDone = r.stmt(s.Else, st.clone())
+		}
+		switch {
+		case thenDone && elsDone:
+			return capState{}, true
+		case thenDone:
+			return els, false
+		case elsDone:
+			return then, false
+		}
+		return mergeCaps(then, els), false
+	case *ast.ForStmt:
+		if s.Init != nil {
+			st, _ = r.stmt(s.Init, st)
+		}
+		r.forget(st, s.Body, s.Post)
+		r.check(s.Cond, st)
+		body, _ := r.stmts(s.Body.List, st.clone())
+		if s.Post != nil {
+			r.stmt(s.Post, body)
+		}
+	case *ast.RangeStmt:
+		r.check(s.X, st)
+		r.forget(st, s)
+		r.stmts(s.Body.List, st.clone())
+	case *ast.SwitchStmt:
+		if s.Init != nil {
+			st, _ = r.stmt(s.Init, st)
+		}
+		if s.Tag != nil {
+			r.check(s.Tag, st)
+		}
+		return r.clauses(s.Body, st)
+	case *ast.TypeSwitchStmt:
+		if s.Init != nil {
+			st, _ = r.stmt(s.Init, st)
+		}
+		st, _ = r.stmt(s.Assign, st)
+		return r.clauses(s.Body, st)
+	case *ast.SelectStmt:
+		return r.clauses(s.Body, st)
+	}
+	return st, false
+}
+
+// clauses walks the case clauses of a switch or select statement from st
+// and merges the states they end in, and st itself when no clause is
+// the default.
+func (r *reslicer) clauses(body *ast.BlockStmt, st capState) (capState, bool) {
+	var out capState
+	done, hasDefault := true, false
+	join := func(c capState, cdone bool) {
+		if cdone {
+			return
+		}
+		if done {
+			out = c
+		} else {
+			out = mergeCaps(out, c)
+		}
+		done = false
+	}
+	for _, c := range body.List {
+		cst := st.clone()
+		var list []ast.Stmt
+		switch c := c.(type) {
+		case *ast.CaseClause:
+			for _, e := range c.List {
+				r.check(e, cst)
+			}
+			hasDefault = hasDefault || c.List == nil
+			list = c.Body
+		case *ast.CommClause:
+			if c.Comm != nil {
+				cst, _ = r.stmt(c.Comm, cst)
+			}
+			hasDefault = hasDefault || c.Comm == nil
+			list = c.Body
+		}
+		join(r.stmts(list, cst))
+	}
+	if !hasDefault {
+		join(st, false)
+	}
+	if done {
+		return capState{}, true
+	}
+	return out, false
+}
+
+// forget removes from st the variables assigned in nodes, which a loop may
+// have changed by the time any iteration starts.
+func (r *reslicer) forget(st capState, nodes ...ast.Node) {
+	for _, n := range nodes {
+		if n == nil {
+			continue
+		}
+		ast.Inspect(n, func(m ast.Node) bool {
+			switch m := m.(type) {
+			case *ast.FuncLit:
+				return false
+			case *ast.AssignStmt:
+				for _, lhs := range m.Lhs {
+					if v := exprVar(r.ctx.Info, lhs); v != nil {
+						delete(st, v)
+					}
+				}
+			case *ast.RangeStmt:
+				for _, e := range []ast.Expr{m.Key, m.Value} {
+					if v := exprVar(r.ctx.Info, e); v != nil {
+						delete(st, v)
+					}
+				}
+			}
+			return true
+		})
+	}
+}
+
+// capOf returns what is known of the capacity of the slice, or length of
+// the string, e evaluates to.
+func (r *reslicer) capOf(e ast.Expr, st capState) (capFact, bool) {
+	info := r.ctx.Info
+	e = ast.Unparen(e)
+	if tv, ok := info.Types[e]; ok && tv.Value != nil && tv.Value.Kind() == constant.String {
+		return capFact{n: int64(len(constant.StringVal(tv.Value))), exact: true}, true
+	}
+	switch e := e.(type) {
+	case *ast.Ident:
+		if v := identVar(info, e); v != nil {
+			f, ok := st[v]
+			return f, ok
+		}
+	case *ast.CompositeLit:
+		if _, ok := info.TypeOf(e).Underlying().(*types.Slice); !ok {
+			break
+		}
+		for _, elt := range e.Elts {
+			if _, ok := elt.(*ast.KeyValueExpr); ok {
+				return capFact{}, false
+			}
+		}
+		return capFact{n: int64(len(e.Elts)), exact: true}, true
+	case *ast.CallExpr:
+		if !isBuiltin(info, e, "make") || len(e.Args) < 2 {
+			break
+		}
+		if _, ok := info.TypeOf(e).Underlying().(*types.Slice); !ok {
+			break
+		}
+		if n, ok := r.constant(e.Args[len(e.Args)-1]); ok {
+			return capFact{n: n, exact: true}, true
+		}
+	case *ast.SliceExpr:
+		if !isSliceOrString(info, e.X) {
+			break
+		}
+		base, ok := r.capOf(e.X, st)
+		if !ok {
+			break
+		}
+		lo, exact := int64(0), base.exact
+		if e.Low != nil {
+			if lo, ok = r.constant(e.Low); !ok {
+				// Still no more than the capacity of e.X.
+				lo, exact = 0, false
+			}
+		}
+		n := base.n
+		if e.Max != nil {
+			if m, ok := r.constant(e.Max); ok {
+				n = m
+			} else {
+				exact = false
+			}
+		} else if isString(info.TypeOf(e.X)) && e.High != nil {
+			if h, ok := r.constant(e.High); ok {
+				n = h
+			} else {
+				exact = false
+			}
+		}
+		if n-lo < 0 {
+			break
+		}
+		return capFact{n: n - lo, exact: exact}, true
+	}
+	return capFact{}, false
+}
+
+// constant returns the value of the integer expression e if it is a
+// constant, directly or through variables the function assigns once.
+func (r *reslicer) constant(e ast.Expr) (int64, bool) {
+	l, ok := r.ar.linearize(e)
+	return l.k, ok && l.atom == ""
+}
+
+// check reports the slice expressions in e whose bounds exceed what st
+// knows of the capacity of the slices they slice. Function literals are
+// walked on their own.
+func (r *reslicer) check(e ast.Expr, st capState) {
+	if e == nil {
+		return
+	}
+	ast.Inspect(e, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.SliceExpr:
+			r.checkSlice(n, st)
+		}
+		return true
+	})
+}
+
+func (r *reslicer) checkSlice(s *ast.SliceExpr, st capState) {
+	if !isSliceOrString(r.ctx.Info, s.X) {
+		return
+	}
+	base, ok := r.capOf(s.X, st)
+	if !ok {
+		return
+	}
+	var n int64
+	for _, bound := range []ast.Expr{s.Max, s.High} {
+		if bound != nil {
+			if b, ok := r.constant(bound); ok && b > base.n {
+				n = b
+				break
+			}
+		}
+	}
+	if n == 0 {
+		return
+	}
+	what := "capacity"
+	if isString(r.ctx.Info.TypeOf(s.X)) {
+		what = "length"
+	}
+	known := "is"
+	if !base.exact {
+		known = "is at most"
+	}
+	x := r.ctx.sourceText(s.X)
+	fd := r.ctx.NewFinding(r.d.Name(), SeverityError, s,
+		"`%s` panics: the bound %d is past the %s of `%s`, which %s %d",
+		r.ctx.sourceText(s), n, what, x, known, base.n)
+	if !base.exact {
+		fd.Confidence = ConfidenceMedium
+	}
+	from := base.from
+	if from == nil {
+		from = s.X
+	}
+	if from != s.X {
+		fd.Related = append(fd.Related, Related{Position: r.ctx.Fset.Position(from.Pos()), Message: "`" + x + "` gets its " + what + " from `" + r.ctx.sourceText(from) + "` here"})
+	}
+	if what == "capacity" {
+		fd.Suggestion = "make the slice with a capacity of at least " + strconv.FormatInt(n, 10) + ", or check `cap(" + x + ")` before slicing"
+	} else {
+		fd.Suggestion = "check `len(" + x + ")` before slicing"
+	}
+	r.findings = append(r.findings, fd)
+}
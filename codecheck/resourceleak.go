@@ -0,0 +1,229 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// ResourceLeakDetector reports values implementing io.Closer, such as
+// *sql.DB, *sql.Rows and *os.File, that a function obtains from a call and
+// stores in a local variable but never closes. A Close call anywhere in the
+// function, including a deferred one or one inside a closure, counts as
+// closing the value; values passed to other functions or stored elsewhere
+// are assumed to be closed by their new owner. A value returned to the
+// caller is reported as a note suggesting the function document that the
+// caller must close it, unless its doc comment already mentions Close.
+type ResourceLeakDetector struct{}
+
+func (ResourceLeakDetector) Name() string { return "resource-leak" }
+
+func (ResourceLeakDetector) Description() string {
+	return "io.Closer obtained in a function and never closed"
+}
+
+func (ResourceLeakDetector) DefaultSeverity() Severity { return SeverityWarning }
+
+func (ResourceLeakDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: `Files, rows and connections hold operating system or database resources until they are closed. A value that is never closed leaks them for as long as the process runs, which ends in errors such as too many open files or an exhausted connection pool.`,
+		Example: `f, err := os.Open(path)
+if err != nil {
+	return err
+}
+return parse(f)`,
+		Fix: `f, err := os.Open(path)
+if err != nil {
+	return err
+}
+defer f.Close()
+return parse(f)`,
+	}
+}
+
+// closerType is io.Closer, built here so that it is available whether or
+// not the analyzed code imports io.
+var closerType = types.NewInterfaceType([]*types.Func{
+	types.NewFunc(token.NoPos, nil, "Close", types.NewSignatureType(nil, nil, nil, nil,
+		types.NewTuple(types.NewVar(token.NoPos, nil, "", types.Universe.Lookup("error").Type())), false)),
+}, nil).Complete()
+
+// resourceOpen is a local variable assigned a closer returned by a call.
+type resourceOpen struct {
+	id   *ast.Ident
+	call *ast.CallExpr
+}
+
+func (d ResourceLeakDetector) Check(ctx *Context) []Finding {
+	var findings []Finding
+	for _, f := range ctx.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				if n.Body != nil {
+					findings = append(findings, d.checkFunc(ctx, n.Name.Name, n.Doc, n.Body)...)
+				}
+			case *ast.FuncLit:
+				findings = append(findings, d.checkFunc(ctx, "", nil, n.Body)...)
+			}
+			return true
+		})
+	}
+	return findings
+}
+
+// checkFunc checks the resources obtained directly in body (not in nested
+// function literals). name and doc describe the declared function, if any.
+func (d ResourceLeakDetector) checkFunc(ctx *Context, name string, doc *ast.CommentGroup, body *ast.BlockStmt) []Finding {
+	opens := map[*types.Var]resourceOpen{}
+	var order []*types.Var
+	var findings []Finding
+	record := func(lhs []ast.Expr, rhs []ast.Expr) {
+		if len(rhs) != 1 {
+			return
+		}
+		call, ok := ast.Unparen(rhs[0]).(*ast.CallExpr)
+		if !ok || callResults(ctx.Info, call) == nil {
+			return
+		}
+		for i, e := range lhs {
+			id, ok := e.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			if id.Name == "_" {
+				// A result assigned to _ can't be closed at all.
+				if res := callResults(ctx.Info, call); res != nil && res.Len() == len(lhs) && isCloser(res.At(i).Type()) {
+					f := ctx.NewFinding(d.Name(), SeverityWarning, id,
+						"%s from %s is discarded, so it is never closed", typeString(ctx, res.At(i).Type()), resourceSource(ctx, call))
+					f.Confidence = ConfidenceMedium
+					findings = append(findings, f)
+				}
+				continue
+			}
+			v := identVar(ctx.Info, id)
+			if v == nil || !isCloser(v.Type()) || !declaredIn(v, body) {
+				continue
+			}
+			if _, dup := opens[v]; !dup {
+				opens[v] = resourceOpen{id: id, call: call}
+				order = append(order, v)
+			}
+		}
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.AssignStmt:
+			record(n.Lhs, n.Rhs)
+		case *ast.ValueSpec:
+			lhs := make([]ast.Expr, len(n.Names))
+			for i, id := range n.Names {
+				lhs[i] = id
+			}
+			record(lhs, n.Values)
+		}
+		return true
+	})
+	if len(opens) == 0 {
+		return findings
+	}
+
+	closed := map[*types.Var]bool{}
+	escaped := map[*types.Var]bool{}
+	returned := map[*types.Var]*ast.Ident{}
+	inspectStack(body, func(n ast.Node, stack []ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok || len(stack) < 2 {
+			return true
+		}
+		v, ok := ctx.Info.Uses[id].(*types.Var)
+		if !ok {
+			return true
+		}
+		if _, ok := opens[v]; !ok {
+			return true
+		}
+		switch p := stack[len(stack)-2].(type) {
+		case *ast.SelectorExpr:
+			// Method calls and field accesses keep the value local.
+			if p.X == id && p.Sel.Name == "Close" {
+				closed[v] = true
+			}
+		case *ast.AssignStmt:
+			for _, lhs := range p.Lhs {
+				if lhs == id {
+					return true
+				}
+			}
+			escaped[v] = true
+		case *ast.BinaryExpr:
+			if p.Op != token.EQL && p.Op != token.NEQ {
+				escaped[v] = true
+			}
+		case *ast.ReturnStmt:
+			if returned[v] == nil {
+				returned[v] = id
+			}
+		default:
+			escaped[v] = true
+		}
+		return true
+	})
+
+	for _, v := range order {
+		if closed[v] || escaped[v] {
+			continue
+		}
+		open := opens[v]
+		from := resourceSource(ctx, open.call)
+		typ := typeString(ctx, v.Type())
+		if ret := returned[v]; ret != nil {
+			if doc != nil && strings.Contains(doc.Text(), "Close") {
+				continue
+			}
+			f := ctx.NewFinding(d.Name(), SeverityNote, ret,
+				"`%s` (%s from %s) is returned without being closed; the caller must close it", v.Name(), typ, from)
+			if name != "" {
+				f.Suggestion = "document in the comment on " + name + " that callers must call Close on the result"
+			}
+			f.Related = append(f.Related, Related{Position: ctx.Fset.Position(open.id.Pos()), Message: "`" + v.Name() + "` is obtained here"})
+			f.Confidence = ConfidenceLow
+			findings = append(findings, f)
+			continue
+		}
+		f := ctx.NewFinding(d.Name(), SeverityWarning, open.id,
+			"`%s` (%s from %s) is never closed", v.Name(), typ, from)
+		f.Suggestion = "add `defer " + v.Name() + ".Close()` once the value is known to be valid"
+		// Not every Closer holds a resource that leaks.
+		f.Confidence = ConfidenceMedium
+		findings = append(findings, f)
+	}
+	return findings
+}
+
+// resourceSource names the function call calls, for finding messages.
+func resourceSource(ctx *Context, call *ast.CallExpr) string {
+	if fn := calleeFunc(ctx.Info, call); fn != nil {
+		return fn.FullName()
+	}
+	return types.ExprString(call.Fun)
+}
+
+// isCloser reports whether a variable of type t has a Close() error method.
+func isCloser(t types.Type) bool {
+	if types.Implements(t, closerType) {
+		return true
+	}
+	if _, ok := t.Underlying().(*types.Pointer); ok || types.IsInterface(t) {
+		return false
+	}
+	return types.Implements(types.NewPointer(t), closerType)
+}
+
+// declaredIn reports whether v is a local variable declared in body.
+func declaredIn(v *types.Var, body *ast.BlockStmt) bool {
+	return !v.IsField() && body.Pos() <= v.Pos() && v.Pos() < body.End()
+}
@@ -0,0 +1,78 @@
+package codecheck
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Help returns the documentation of r as plain text, as `codecheck explain`
+// prints it: the rule id and default severity, the description, the
+// rationale, and the example and fix indented as code.
+func (r Rule) Help() string {
+	return r.help(false)
+}
+
+// help formats the documentation of r as plain text or as Markdown, for the
+// text and markdown forms of the help of a SARIF rule.
+func (r Rule) help(markdown bool) string {
+	var b strings.Builder
+	if markdown {
+		fmt.Fprintf(&b, "**%s** (default severity: %s)\n", r.ID, r.DefaultSeverity)
+	} else {
+		fmt.Fprintf(&b, "%s (default severity: %s)\n", r.ID, r.DefaultSeverity)
+	}
+	para := func(text string) {
+		if text == "" {
+			return
+		}
+		if !markdown {
+			text = wrap(text, 76)
+		}
+		fmt.Fprintf(&b, "\n%s\n", text)
+	}
+	if r.Description != "" {
+		para(r.Description + ".")
+	}
+	para(r.Doc.Rationale)
+	code := func(title, src string) {
+		if src == "" {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s:\n\n", title)
+		if markdown {
+			fmt.Fprintf(&b, "```go\n%s\n```\n", src)
+			return
+		}
+		for _, line := range strings.Split(src, "\n") {
+			if line == "" {
+				b.WriteString("\n")
+			} else {
+				b.WriteString("\t" + line + "\n")
+			}
+		}
+	}
+	code("Example", r.Doc.Example)
+	code("Fix", r.Doc.Fix)
+	return b.String()
+}
+
+// wrap breaks text into lines of at most width bytes at spaces, keeping
+// longer words whole.
+func wrap(text string, width int) string {
+	var b strings.Builder
+	n := 0
+	for i, word := range strings.Fields(text) {
+		if i > 0 {
+			if n+1+len(word) > width {
+				b.WriteString("\n")
+				n = 0
+			} else {
+				b.WriteString(" ")
+				n++
+			}
+		}
+		b.WriteString(word)
+		n += len(word)
+	}
+	return b.String()
+}
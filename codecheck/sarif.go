@@ -0,0 +1,208 @@
+package codecheck
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// SARIF 2.1.0 log, reduced to the properties codecheck emits.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool               sarifTool                   `json:"tool"`
+	OriginalURIBaseIDs map[string]sarifArtifactLoc `json:"originalUriBaseIds,omitempty"`
+	Results            []sarifResult               `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string                `json:"name"`
+	Version        string                `json:"version"`
+	InformationURI string                `json:"informationUri"`
+	Rules          []sarifReportingDescr `json:"rules"`
+}
+
+type sarifReportingDescr struct {
+	ID                   string            `json:"id"`
+	ShortDescription     sarifMessage      `json:"shortDescription"`
+	FullDescription      *sarifMessage     `json:"fullDescription,omitempty"`
+	Help                 *sarifMessage     `json:"help,omitempty"`
+	DefaultConfiguration sarifRuleDefaults `json:"defaultConfiguration"`
+}
+
+type sarifRuleDefaults struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text     string `json:"text"`
+	Markdown string `json:"markdown,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID           string          `json:"ruleId"`
+	RuleIndex        int             `json:"ruleIndex"`
+	Level            string          `json:"level"`
+	Message          sarifMessage    `json:"message"`
+	Locations        []sarifLocation `json:"locations"`
+	RelatedLocations []sarifLocation `json:"relatedLocations,omitempty"`
+	Properties       sarifProperties `json:"properties"`
+}
+
+type sarifProperties struct {
+	Confidence string `json:"confidence"`
+}
+
+type sarifLocation struct {
+	ID               int                   `json:"id,omitempty"`
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	Message          *sarifMessage         `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLoc `json:"artifactLocation"`
+	Region           sarifRegion      `json:"region"`
+}
+
+type sarifArtifactLoc struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+const srcRoot = "%SRCROOT%"
+
+// WriteSARIF writes findings as a SARIF 2.1.0 log. File paths are made
+// relative to root, which should be the repository root, so that code
+// scanning can map results onto the repository; files outside root are
+// written as absolute file URIs.
+func WriteSARIF(w io.Writer, rules []Rule, findings []Finding, root string) error {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+	driver := sarifDriver{
+		Name:           "codecheck",
+		Version:        Version,
+		InformationURI: "https://github.com/shivansh-2003/github-code/tree/main/codecheck",
+		Rules:          []sarifReportingDescr{},
+	}
+	index := map[string]int{}
+	addRule := func(r Rule) {
+		if r.Description == "" {
+			r.Description = r.ID
+		}
+		index[r.ID] = len(driver.Rules)
+		descr := sarifReportingDescr{
+			ID:                   r.ID,
+			ShortDescription:     sarifMessage{Text: r.Description},
+			DefaultConfiguration: sarifRuleDefaults{Level: r.DefaultSeverity.String()},
+		}
+		if r.Doc.Rationale != "" {
+			descr.FullDescription = &sarifMessage{Text: r.Doc.Rationale}
+			descr.Help = &sarifMessage{Text: r.Help(), Markdown: r.help(true)}
+		}
+		driver.Rules = append(driver.Rules, descr)
+	}
+	known := map[string]Rule{}
+	for _, r := range KnownRules() {
+		known[r.ID] = r
+	}
+	for _, r := range rules {
+		addRule(r)
+	}
+	results := []sarifResult{}
+	for _, f := range findings {
+		if _, ok := index[f.Rule]; !ok {
+			r, ok := known[f.Rule]
+			if !ok {
+				r = Rule{ID: f.Rule, DefaultSeverity: f.Severity}
+			}
+			addRule(r)
+		}
+		var related []sarifLocation
+		for i, r := range f.Related {
+			related = append(related, sarifLocation{
+				ID: i + 1,
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: artifactLocation(root, r.Position.Filename),
+					Region: sarifRegion{
+						StartLine:   r.Position.Line,
+						StartColumn: r.Position.Column,
+					},
+				},
+				Message: &sarifMessage{Text: r.Message},
+			})
+		}
+		results = append(results, sarifResult{
+			RuleID:    f.Rule,
+			RuleIndex: index[f.Rule],
+			Level:     f.Severity.String(),
+			Message:   sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: artifactLocation(root, f.Position.Filename),
+					Region: sarifRegion{
+						StartLine:   f.Position.Line,
+						StartColumn: f.Position.Column,
+						EndLine:     f.End.Line,
+						EndColumn:   f.End.Column,
+					},
+				},
+			}},
+			RelatedLocations: related,
+			Properties:       sarifProperties{Confidence: f.Confidence.String()},
+		})
+	}
+	// The run is built last, as the results may have added rules.
+	run := sarifRun{
+		Tool: sarifTool{Driver: driver},
+		OriginalURIBaseIDs: map[string]sarifArtifactLoc{
+			srcRoot: {URI: fileURI(root) + "/"},
+		},
+		Results: results,
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
+
+func artifactLocation(root, filename string) sarifArtifactLoc {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return sarifArtifactLoc{URI: filepath.ToSlash(filename)}
+	}
+	rel, ok := repoPath(root, abs)
+	if !ok {
+		return sarifArtifactLoc{URI: fileURI(abs)}
+	}
+	return sarifArtifactLoc{URI: (&url.URL{Path: rel}).EscapedPath(), URIBaseID: srcRoot}
+}
+
+func fileURI(abs string) string {
+	p := filepath.ToSlash(abs)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
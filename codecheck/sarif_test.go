@@ -0,0 +1,59 @@
+package codecheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/token"
+	"testing"
+)
+
+// TestSARIFRuleIndex checks that results of rules outside those passed to
+// WriteSARIF, such as unused-ignore, get a descriptor that their ruleIndex
+// refers to.
+func TestSARIFRuleIndex(t *testing.T) {
+	pos := token.Position{Filename: "a.go", Line: 3, Column: 2}
+	findings := []Finding{
+		{Rule: "nil-deref", Severity: SeverityError, Position: pos, End: pos, Message: "nil dereference"},
+		{Rule: UnusedIgnoreRule, Severity: SeverityWarning, Position: pos, End: pos, Message: "unused"},
+		{Rule: "acme/custom", Severity: SeverityNote, Position: pos, End: pos, Message: "custom"},
+	}
+	var rules []Rule
+	for _, r := range KnownRules() {
+		if r.ID != UnusedIgnoreRule {
+			rules = append(rules, r)
+		}
+	}
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, rules, findings, "."); err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Runs []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex int    `json:"ruleIndex"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	run := log.Runs[0]
+	if got, want := len(run.Tool.Driver.Rules), len(rules)+2; got != want {
+		t.Errorf("got %d rule descriptors, want %d", got, want)
+	}
+	for _, r := range run.Results {
+		if r.RuleIndex < 0 || r.RuleIndex >= len(run.Tool.Driver.Rules) {
+			t.Errorf("%s: ruleIndex %d out of range", r.RuleID, r.RuleIndex)
+		} else if id := run.Tool.Driver.Rules[r.RuleIndex].ID; id != r.RuleID {
+			t.Errorf("%s: ruleIndex %d refers to %s", r.RuleID, r.RuleIndex, id)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// ShadowingDetector reports `:=` declarations in a nested scope that shadow
+// a variable of the same name and type from an enclosing scope of the same
+// function, when the outer variable is still used after the inner scope
+// ends (or, for a named result, returned by a bare return). Values
+// assigned to the inner variable never reach the outer one, which is
+// the classic "the outer err stayed nil" bug. Shadowing an error variable
+// or a named result is a warning, anything else a note. Loop variables,
+// declarations in if and switch headers and copies like `x := x` are not
+// reported.
+type ShadowingDetector struct{}
+
+func (ShadowingDetector) Name() string { return "shadow" }
+
+func (ShadowingDetector) Description() string {
+	return "Variable declaration shadowing an outer variable that is used afterwards"
+}
+
+func (ShadowingDetector) DefaultSeverity() Severity { return SeverityWarning }
+
+func (ShadowingDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: "`:=` in an inner scope declares a new variable even when one of the same name exists outside it. Assignments inside the scope then go to the new variable and are lost when it ends, so the outer variable, often `err`, keeps its old value.",
+		Example: `var err error
+if retry {
+	result, err := fetch()
+	use(result, err)
+}
+return err`,
+		Fix: `var err error
+if retry {
+	var result Result
+	result, err = fetch()
+	use(result, err)
+}
+return err`,
+	}
+}
+
+func (d ShadowingDetector) Check(ctx *Context) []Finding {
+	uses := varReads(ctx)
+	// results holds the named results of every function, and
+	// bareReturns the positions of the bare returns of their function.
+	results := map[*types.Var]bool{}
+	bareReturns := map[*types.Var][]token.Pos{}
+	namedResults := func(ft *ast.FuncType, body *ast.BlockStmt) {
+		if ft.Results == nil || body == nil {
+			return
+		}
+		var named []*types.Var
+		for _, field := range ft.Results.List {
+			for _, name := range field.Names {
+				if v := identVar(ctx.Info, name); v != nil {
+					named = append(named, v)
+					results[v] = true
+				}
+			}
+		}
+		if len(named) == 0 {
+			return
+		}
+		ast.Inspect(body, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncLit:
+				return false
+			case *ast.ReturnStmt:
+				if len(n.Results) == 0 {
+					for _, v := range named {
+						bareReturns[v] = append(bareReturns[v], n.Pos())
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	var findings []Finding
+	for _, f := range ctx.Files {
+		inspectStack(f, func(n ast.Node, stack []ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncDecl:
+				namedResults(n.Type, n.Body)
+			case *ast.FuncLit:
+				namedResults(n.Type, n.Body)
+			case *ast.AssignStmt:
+				if n.Tok != token.DEFINE || isHeaderDecl(n, stack) {
+					return true
+				}
+				for i, lhs := range n.Lhs {
+					id, ok := lhs.(*ast.Ident)
+					if !ok || id.Name == "_" {
+						continue
+					}
+					inner, ok := ctx.Info.Defs[id].(*types.Var)
+					if !ok || inner == nil {
+						continue
+					}
+					if len(n.Lhs) == len(n.Rhs) {
+						if v := exprVar(ctx.Info, n.Rhs[i]); v != nil && v.Name() == id.Name {
+							continue // x := x
+						}
+					}
+					outer := outerVar(ctx, id, inner)
+					if outer == nil || !types.Identical(outer.Type(), inner.Type()) {
+						continue
+					}
+					if block, ok := stack[len(stack)-2].(*ast.BlockStmt); ok && handledError(ctx, block, inner) {
+						continue
+					}
+					if f := d.check(ctx, id, inner, outer, uses[outer], bareReturns[outer], results[outer]); f != nil {
+						findings = append(findings, *f)
+					}
+				}
+			}
+			return true
+		})
+	}
+	return findings
+}
+
+// outerVar returns the function-local variable that inner, declared by id,
+// shadows, if any.
+func outerVar(ctx *Context, id *ast.Ident, inner *types.Var) *types.Var {
+	scope := inner.Parent()
+	if scope == nil || scope.Parent() == nil {
+		return nil
+	}
+	_, obj := scope.Parent().LookupParent(inner.Name(), id.Pos())
+	outer, ok := obj.(*types.Var)
+	if !ok || outer.Parent() == nil || outer.Parent() == ctx.Pkg.Scope() || outer.Parent() == types.Universe {
+		return nil
+	}
+	return outer
+}
+
+// check reports inner, declared by id, if outer is used (at one of uses,
+// or by one of bareReturns when it is a named result) after inner's scope.
+func (d ShadowingDetector) check(ctx *Context, id *ast.Ident, inner, outer *types.Var, uses, bareReturns []token.Pos, namedResult bool) *Finding {
+	end := inner.Parent().End()
+	// after returns the first of ps past the inner scope.
+	after := func(ps []token.Pos) token.Pos {
+		first := token.NoPos
+		for _, p := range ps {
+			if p >= end && (!first.IsValid() || p < first) {
+				first = p
+			}
+		}
+		return first
+	}
+	used := after(uses)
+	if !used.IsValid() {
+		used = after(bareReturns)
+	}
+	if !used.IsValid() {
+		return nil
+	}
+	sev, conf := SeverityNote, ConfidenceLow
+	kind := "variable"
+	switch {
+	case namedResult:
+		sev, conf, kind = SeverityWarning, ConfidenceMedium, "named result"
+	case isError(outer.Type()):
+		sev, conf, kind = SeverityWarning, ConfidenceMedium, "error variable"
+	}
+	f := ctx.NewFinding(d.Name(), sev, id,
+		"`%s` shadows the %s declared at line %d, which is used at line %d after this scope; assignments here do not reach it",
+		id.Name, kind, ctx.Fset.Position(outer.Pos()).Line, ctx.Fset.Position(used).Line)
+	f.Related = append(f.Related,
+		Related{Position: ctx.Fset.Position(outer.Pos()), Message: "outer `" + outer.Name() + "` is declared here"},
+		Related{Position: ctx.Fset.Position(used), Message: "outer `" + outer.Name() + "` is used here"})
+	f.Suggestion = "assign with `=` instead of `:=` if the outer " + kind + " should be updated, or rename the inner one"
+	f.Confidence = conf
+	return &f
+}
+
+// handledError reports whether v is an error that block checks with
+// `if v != nil` and leaves the function or loop when it is set: the inner
+// error is dealt with where it is declared, and the shadowing is
+// deliberate.
+func handledError(ctx *Context, block *ast.BlockStmt, v *types.Var) bool {
+	if !isError(v.Type()) {
+		return false
+	}
+	for _, s := range block.List {
+		ifs, ok := s.(*ast.IfStmt)
+		if !ok || !terminates(ctx.Info, ifs.Body) {
+			continue
+		}
+		if c, ok := ast.Unparen(ifs.Cond).(*ast.BinaryExpr); ok && c.Op == token.NEQ &&
+			exprVar(ctx.Info, c.X) == v && isNilExpr(ctx.Info, c.Y) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHeaderDecl reports whether as is the init statement of a for, if or
+// switch statement.
+func isHeaderDecl(as *ast.AssignStmt, stack []ast.Node) bool {
+	if len(stack) < 2 {
+		return false
+	}
+	switch p := stack[len(stack)-2].(type) {
+	case *ast.ForStmt:
+		return p.Init == as
+	case *ast.IfStmt:
+		return p.Init == as
+	case *ast.SwitchStmt:
+		return p.Init == as
+	case *ast.TypeSwitchStmt:
+		return p.Init == as || p.Assign == as
+	}
+	return false
+}
+
+// isError reports whether t is the predeclared error type.
+func isError(t types.Type) bool {
+	return types.Identical(t, types.Universe.Lookup("error").Type())
+}
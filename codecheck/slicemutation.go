@@ -0,0 +1,162 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// SliceMutationDuringIterationDetector reports loops that reassign the
+// slice they iterate over. In a `for i := 0; i < len(s); i++` loop that
+// indexes s[i] the bound is re-evaluated on every iteration, so appending
+// to s moves it and re-slicing s shifts the remaining elements under the
+// index. A `range s` loop evaluates s once, so appended elements are never
+// visited. Strings, which can't be appended to in place, and re-slicing
+// from the front, as worklists do with `s = s[1:]`, are left alone.
+type SliceMutationDuringIterationDetector struct{}
+
+func (SliceMutationDuringIterationDetector) Name() string { return "slice-mutation" }
+
+func (SliceMutationDuringIterationDetector) Description() string {
+	return "Slice reassigned while a loop iterates over it"
+}
+
+func (SliceMutationDuringIterationDetector) DefaultSeverity() Severity { return SeverityWarning }
+
+func (SliceMutationDuringIterationDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: "A loop that appends to, or re-slices, the slice it iterates over either never visits the new elements (`range` evaluates the slice once) or, with a `len(s)` bound, may never end or skip elements as they shift under the index.",
+		Example: `for _, item := range queue {
+	queue = append(queue, children(item)...)
+}`,
+		Fix: `for len(queue) > 0 {
+	item := queue[0]
+	queue = append(queue[1:], children(item)...)
+}`,
+	}
+}
+
+func (d SliceMutationDuringIterationDetector) Check(ctx *Context) []Finding {
+	var findings []Finding
+	for _, f := range ctx.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch loop := n.(type) {
+			case *ast.ForStmt:
+				if x, i := lenBound(ctx.Info, loop.Cond); x != nil && isSlice(ctx.Info, x) && indexesWith(ctx.Info, loop.Body, x, i) {
+					findings = append(findings, d.checkBody(ctx, loop.Body, x, false)...)
+				}
+			case *ast.RangeStmt:
+				if isSlice(ctx.Info, loop.X) {
+					findings = append(findings, d.checkBody(ctx, loop.Body, loop.X, true)...)
+				}
+			}
+			return true
+		})
+	}
+	return findings
+}
+
+// lenBound returns x and i when cond compares the variable i against
+// len(x), as in `i < len(x)`.
+func lenBound(info *types.Info, cond ast.Expr) (ast.Expr, *types.Var) {
+	bin, ok := ast.Unparen(cond).(*ast.BinaryExpr)
+	if !ok {
+		return nil, nil
+	}
+	for _, pair := range [][2]ast.Expr{{bin.X, bin.Y}, {bin.Y, bin.X}} {
+		call, ok := ast.Unparen(pair[0]).(*ast.CallExpr)
+		if !ok || !isBuiltin(info, call, "len") || len(call.Args) != 1 {
+			continue
+		}
+		if i := exprVar(info, pair[1]); i != nil {
+			return call.Args[0], i
+		}
+	}
+	return nil, nil
+}
+
+func isSlice(info *types.Info, e ast.Expr) bool {
+	t := info.TypeOf(e)
+	if t == nil {
+		return false
+	}
+	_, ok := t.Underlying().(*types.Slice)
+	return ok
+}
+
+// indexesWith reports whether body indexes x with the variable i.
+func indexesWith(info *types.Info, body *ast.BlockStmt, x ast.Expr, i *types.Var) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if ix, ok := n.(*ast.IndexExpr); ok && exprVar(info, ix.Index) == i && sameExpr(info, ix.X, x) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// fromFront reports whether e re-slices x from the front, as in x[1:].
+func fromFront(info *types.Info, e, x ast.Expr) bool {
+	s, ok := ast.Unparen(e).(*ast.SliceExpr)
+	return ok && s.Low != nil && s.High == nil && sameExpr(info, s.X, x)
+}
+
+func (d SliceMutationDuringIterationDetector) checkBody(ctx *Context, body *ast.BlockStmt, x ast.Expr, isRange bool) []Finding {
+	var findings []Finding
+	name := types.ExprString(x)
+	ast.Inspect(body, func(n ast.Node) bool {
+		if _, ok := n.(*ast.FuncLit); ok {
+			return false
+		}
+		as, ok := n.(*ast.AssignStmt)
+		if !ok || as.Tok != token.ASSIGN || len(as.Lhs) != len(as.Rhs) {
+			return true
+		}
+		for i, lhs := range as.Lhs {
+			if !sameExpr(ctx.Info, lhs, x) {
+				continue
+			}
+			rhs := ast.Unparen(as.Rhs[i])
+			grows := false
+			if call, ok := rhs.(*ast.CallExpr); ok && isBuiltin(ctx.Info, call, "append") && len(call.Args) > 0 {
+				if fromFront(ctx.Info, call.Args[0], x) {
+					continue
+				}
+				grows = sameExpr(ctx.Info, call.Args[0], x)
+			}
+			if fromFront(ctx.Info, rhs, x) {
+				continue
+			}
+			var msg string
+			switch {
+			case isRange && grows:
+				msg = "`%s` is appended to while ranging over it; the range expression is evaluated once, so the appended elements are never visited"
+			case isRange:
+				msg = "`%s` is reassigned while ranging over it; the loop keeps iterating over the original slice"
+			case grows:
+				msg = "`%s` is appended to inside a loop bounded by len(%[1]s); the bound grows with every append, so the loop may never terminate"
+			default:
+				msg = "`%s` is reassigned inside a loop bounded by len(%[1]s); the remaining elements shift under the loop index"
+			}
+			f := ctx.NewFinding(d.Name(), SeverityWarning, as, msg, name)
+			if isRange {
+				// Iterating over the original elements is often intended.
+				f.Severity, f.Confidence = SeverityNote, ConfidenceLow
+			}
+			findings = append(findings, f)
+		}
+		return true
+	})
+	return findings
+}
+
+// sameExpr reports whether a and b denote the same variable or, for other
+// expressions, are written identically.
+func sameExpr(info *types.Info, a, b ast.Expr) bool {
+	va, vb := exprVar(info, a), exprVar(info, b)
+	if va != nil || vb != nil {
+		return va == vb
+	}
+	return types.ExprString(ast.Unparen(a)) == types.ExprString(ast.Unparen(b))
+}
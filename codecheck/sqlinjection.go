@@ -0,0 +1,330 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SQLInjectionDetector reports SQL queries built by concatenating string
+// literals with non-constant values, either in a single `+` chain or across
+// several `+=` statements onto the same variable. Text counts as SQL when
+// it has a statement's structure, not just a leading keyword; see
+// sqlStatement.
+type SQLInjectionDetector struct{}
+
+func (SQLInjectionDetector) Name() string { return "sql-injection" }
+
+func (SQLInjectionDetector) Description() string {
+	return "SQL query built by concatenating non-constant values"
+}
+
+func (SQLInjectionDetector) DefaultSeverity() Severity { return SeverityError }
+
+func (SQLInjectionDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: "Building a query by concatenating values into the SQL text lets whoever controls a value change the query itself: a name of `x' OR '1'='1` turns a lookup into a dump of the table. Placeholders send the values separately from the SQL, so they are never parsed as part of it.",
+		Example:   `rows, err := db.Query("SELECT * FROM users WHERE name = '" + name + "'")`,
+		Fix:       `rows, err := db.Query("SELECT * FROM users WHERE name = ?", name)`,
+	}
+}
+
+const sqlInjectionFix = "use placeholders in the query and pass the values separately, e.g. db.Query(query, args...)"
+
+// sqlStatement matches the start of SQL text: a statement with the clause
+// that makes it one, such as `SELECT ... FROM` or `UPDATE ... SET`, or a
+// WHERE clause with a comparison. A keyword alone, as in "Update
+// available: ", is English as often as SQL.
+var sqlStatement = regexp.MustCompile(`(?is)^(?:SELECT\b.*\bFROM\b|(?:INSERT|REPLACE)\s+INTO\b|UPDATE\b.*\bSET\b|DELETE\s+FROM\b|MERGE\s+INTO\b|WHERE\b.*(?:[=<>]|\bLIKE\b|\bBETWEEN\b|\bIN\s*\())`)
+
+func (d SQLInjectionDetector) Check(ctx *Context) []Finding {
+	var findings []Finding
+	var fixes map[ast.Expr]*SuggestedFix
+	report := func(n ast.Node, value ast.Expr, format string, args ...any) {
+		f := ctx.NewFinding(d.Name(), SeverityError, n, format, args...)
+		f.Suggestion = sqlInjectionFix
+		if isFormattedNumber(ctx.Info, value) {
+			// A formatted number or bool can't carry SQL syntax.
+			f.Confidence = ConfidenceLow
+		}
+		if e, ok := n.(ast.Expr); ok {
+			f.Fix = fixes[e]
+		}
+		findings = append(findings, f)
+	}
+	placeholder, knownDriver := sqlPlaceholder(ctx)
+	forEachFunc(ctx, func(body *ast.BlockStmt) {
+		fixes = parameterizeFixes(ctx, body, placeholder, knownDriver)
+		// queries holds variables currently holding a SQL query prefix.
+		queries := map[*types.Var]bool{}
+		ast.Inspect(body, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncLit:
+				return false
+			case *ast.AssignStmt:
+				if n.Tok == token.ADD_ASSIGN && len(n.Lhs) == 1 {
+					if v := exprVar(ctx.Info, n.Lhs[0]); v != nil && queries[v] {
+						if !isConstExpr(ctx.Info, n.Rhs[0]) {
+							report(n, n.Rhs[0], "SQL query `%s` is extended with non-constant value `%s`", v.Name(), types.ExprString(n.Rhs[0]))
+						}
+						return false
+					}
+				}
+				if len(n.Lhs) == len(n.Rhs) {
+					for i, lhs := range n.Lhs {
+						if v := exprVar(ctx.Info, lhs); v != nil && n.Tok != token.ADD_ASSIGN {
+							queries[v] = isSQLExpr(ctx.Info, n.Rhs[i], queries)
+						}
+					}
+				}
+			case *ast.ValueSpec:
+				if len(n.Names) == len(n.Values) {
+					for i, name := range n.Names {
+						if v := identVar(ctx.Info, name); v != nil {
+							queries[v] = isSQLExpr(ctx.Info, n.Values[i], queries)
+						}
+					}
+				}
+			case *ast.BinaryExpr:
+				if n.Op != token.ADD || isConstExpr(ctx.Info, n) {
+					return true
+				}
+				ops := concatOperands(n)
+				if !isSQLExpr(ctx.Info, n, queries) {
+					return true
+				}
+				for _, op := range ops[1:] {
+					if !isConstExpr(ctx.Info, op) {
+						report(n, op, "SQL query built by concatenating non-constant value `%s`", types.ExprString(op))
+						break
+					}
+				}
+				// The whole chain has been examined; don't report its sub-chains.
+				return false
+			}
+			return true
+		})
+	})
+	return findings
+}
+
+// isFormattedNumber reports whether e is a strconv call formatting a
+// number or bool as a string.
+func isFormattedNumber(info *types.Info, e ast.Expr) bool {
+	call, ok := ast.Unparen(e).(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	fn := calleeFunc(info, call)
+	if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != "strconv" {
+		return false
+	}
+	switch fn.Name() {
+	case "Itoa", "FormatInt", "FormatUint", "FormatFloat", "FormatBool", "FormatComplex":
+		return true
+	}
+	return false
+}
+
+// concatOperands flattens a left-associative chain of `+` into its operands.
+func concatOperands(e ast.Expr) []ast.Expr {
+	if b, ok := ast.Unparen(e).(*ast.BinaryExpr); ok && b.Op == token.ADD {
+		return append(concatOperands(b.X), b.Y)
+	}
+	return []ast.Expr{e}
+}
+
+// isSQLExpr reports whether e starts with SQL text: a variable known to
+// hold a query, or a string constant, or a concatenation starting with
+// one, whose text matches sqlStatement. The text of a concatenation is
+// that of its constant operands with a ? for each other one, so that the
+// clauses may be split across them.
+func isSQLExpr(info *types.Info, e ast.Expr, queries map[*types.Var]bool) bool {
+	ops := concatOperands(e)
+	if v := exprVar(info, ops[0]); v != nil && queries[v] {
+		return true
+	}
+	var text strings.Builder
+	for i, op := range ops {
+		tv, ok := info.Types[op]
+		if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+			if i == 0 {
+				return false
+			}
+			text.WriteString("?")
+			continue
+		}
+		text.WriteString(constant.StringVal(tv.Value))
+	}
+	return sqlStatement.MatchString(strings.TrimLeft(text.String(), " \t\r\n("))
+}
+
+func isConstExpr(info *types.Info, e ast.Expr) bool {
+	if tv, ok := info.Types[e]; ok {
+		return tv.Value != nil
+	}
+	_, ok := ast.Unparen(e).(*ast.BasicLit)
+	return ok
+}
+
+// sqlQueryMethods maps the methods of database/sql types that take a query
+// and its arguments to the index of the query parameter.
+var sqlQueryMethods = map[string]int{
+	"Query": 0, "QueryRow": 0, "Exec": 0,
+	"QueryContext": 1, "QueryRowContext": 1, "ExecContext": 1,
+}
+
+// sqlPlaceholder returns the placeholder syntax of the database driver the
+// package opens with sql.Open: "?" for MySQL and SQLite, "$" for
+// PostgreSQL (numbered $1, $2, ...) and "@p" for SQL Server. Without a
+// recognized driver it returns "?" and false.
+func sqlPlaceholder(ctx *Context) (string, bool) {
+	style := ""
+	for _, f := range ctx.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+			fn := calleeFunc(ctx.Info, call)
+			if fn == nil || fn.FullName() != "database/sql.Open" {
+				return true
+			}
+			tv := ctx.Info.Types[call.Args[0]]
+			if tv.Value == nil || tv.Value.Kind() != constant.String {
+				return true
+			}
+			var s string
+			switch constant.StringVal(tv.Value) {
+			case "mysql", "sqlite", "sqlite3":
+				s = "?"
+			case "postgres", "pgx", "pq":
+				s = "$"
+			case "sqlserver", "mssql":
+				s = "@p"
+			default:
+				s = "unknown"
+			}
+			if style != "" && style != s {
+				s = "unknown"
+			}
+			style = s
+			return true
+		})
+	}
+	if style == "" || style == "unknown" {
+		return "?", false
+	}
+	return style, true
+}
+
+// parameterizeFixes returns fixes for the concatenated queries passed
+// directly to database/sql query methods in body, rewriting
+// `db.Query("... WHERE name = '" + name + "'")` to
+// `db.Query("... WHERE name = ?", name)`. A fix is safe only if the
+// driver's placeholder syntax is known and every concatenated value
+// stands where SQL allows a parameter: after a comparison operator, a
+// comma, an opening parenthesis or LIKE, and either unquoted or enclosed
+// in a matching pair of quotes.
+func parameterizeFixes(ctx *Context, body *ast.BlockStmt, placeholder string, knownDriver bool) map[ast.Expr]*SuggestedFix {
+	fixes := map[ast.Expr]*SuggestedFix{}
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || call.Ellipsis.IsValid() {
+			return true
+		}
+		fn := calleeFunc(ctx.Info, call)
+		if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != "database/sql" {
+			return true
+		}
+		idx, ok := sqlQueryMethods[fn.Name()]
+		if !ok || len(call.Args) != idx+1 {
+			return true
+		}
+		q := ast.Unparen(call.Args[idx])
+		if b, ok := q.(*ast.BinaryExpr); !ok || b.Op != token.ADD {
+			return true
+		}
+		if fix := parameterize(ctx, q, placeholder); fix != nil {
+			fix.Safe = fix.Safe && knownDriver
+			fixes[q] = fix
+		}
+		return true
+	})
+	return fixes
+}
+
+func parameterize(ctx *Context, q ast.Expr, placeholder string) *SuggestedFix {
+	ops := concatOperands(q)
+	constText := func(e ast.Expr) (string, bool) {
+		tv, ok := ctx.Info.Types[e]
+		if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+			return "", false
+		}
+		return constant.StringVal(tv.Value), true
+	}
+	if _, ok := constText(ops[0]); !ok {
+		return nil
+	}
+	var text strings.Builder
+	var args []string
+	safe := true
+	strip := false // drop a leading quote from the next constant
+	for i, op := range ops {
+		if s, ok := constText(op); ok {
+			if strip {
+				s = s[1:]
+				strip = false
+			}
+			text.WriteString(s)
+			continue
+		}
+		before := text.String()
+		if q := before[max(len(before)-1, 0):]; q == "'" || q == `"` {
+			next, ok := "", false
+			if i+1 < len(ops) {
+				next, ok = constText(ops[i+1])
+			}
+			if !ok || !strings.HasPrefix(next, q) {
+				safe = false
+			} else {
+				before = before[:len(before)-1]
+				strip = true
+			}
+		}
+		if !allowsParameter(before) {
+			safe = false
+		}
+		text.Reset()
+		text.WriteString(before)
+		args = append(args, ctx.sourceText(op))
+		switch placeholder {
+		case "?":
+			text.WriteString("?")
+		default:
+			text.WriteString(placeholder + strconv.Itoa(len(args)))
+		}
+	}
+	return &SuggestedFix{
+		Message: "pass the concatenated values as query arguments",
+		Safe:    safe,
+		Edits:   []TextEdit{ctx.edit(q.Pos(), q.End(), strconv.Quote(text.String())+", "+strings.Join(args, ", "))},
+	}
+}
+
+// allowsParameter reports whether SQL text ending in before can be
+// followed by a parameter placeholder.
+func allowsParameter(before string) bool {
+	t := strings.TrimRight(before, " \t\r\n")
+	if t == "" {
+		return false
+	}
+	if strings.ContainsRune("=<>(,", rune(t[len(t)-1])) {
+		return true
+	}
+	return strings.HasSuffix(strings.ToUpper(t), " LIKE")
+}
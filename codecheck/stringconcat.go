@@ -0,0 +1,225 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// StringConcatLoopDetector reports strings built up in a loop with
+// `s += x` or `s = s + x`. Each concatenation copies all of s, so building
+// a string of n pieces this way takes O(n²) time; a strings.Builder takes
+// O(n). Only variables declared outside the loop are reported, as those
+// declared inside it start afresh on every iteration, and loops that run a
+// constant number of times, such as over an array or up to a constant, are
+// left alone, as are loops that already write to a strings.Builder or
+// bytes.Buffer.
+type StringConcatLoopDetector struct{}
+
+func (StringConcatLoopDetector) Name() string { return "string-concat-loop" }
+
+func (StringConcatLoopDetector) Description() string {
+	return "String built by concatenation in a loop, which takes quadratic time"
+}
+
+func (StringConcatLoopDetector) DefaultSeverity() Severity { return SeverityNote }
+
+func (StringConcatLoopDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: "Strings are immutable, so every `s += x` copies all of s into a new string. Building a string of n pieces in a loop this way takes time quadratic in its length, which becomes noticeable for long outputs; a strings.Builder appends in place.",
+		Example: `var out string
+for _, line := range lines {
+	out += line + "\n"
+}`,
+		Fix: `var b strings.Builder
+for _, line := range lines {
+	b.WriteString(line + "\n")
+}
+out := b.String()`,
+	}
+}
+
+func (d StringConcatLoopDetector) Check(ctx *Context) []Finding {
+	var findings []Finding
+	for _, f := range ctx.Files {
+		inspectStack(f, func(n ast.Node, stack []ast.Node) bool {
+			as, ok := n.(*ast.AssignStmt)
+			if !ok || len(as.Lhs) != 1 || len(as.Rhs) != 1 {
+				return true
+			}
+			s := exprVar(ctx.Info, as.Lhs[0])
+			if s == nil || !isString(s.Type()) || !concatenates(ctx.Info, as, s) {
+				return true
+			}
+			loop := concatLoop(ctx.Info, s, stack)
+			if loop == nil {
+				return true
+			}
+			header := loopHeader(ctx, loop)
+			f := ctx.NewFinding(d.Name(), SeverityNote, as,
+				"string `%s` is concatenated on every iteration of `%s`; each concatenation copies all of `%s`, so building it takes quadratic time",
+				s.Name(), header, s.Name())
+			if p := ctx.Fset.Position(loop.Pos()); p.Line != ctx.Fset.Position(as.Pos()).Line {
+				f.Related = append(f.Related, Related{Position: p, Message: "the loop: `" + header + "`"})
+			}
+			f.Suggestion = "build the string with a strings.Builder: `var b strings.Builder` before the loop, `b.WriteString(...)` in it and `" + s.Name() + " = b.String()` after it"
+			findings = append(findings, f)
+			return true
+		})
+	}
+	return findings
+}
+
+func isString(t types.Type) bool {
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Info()&types.IsString != 0
+}
+
+// concatenates reports whether as appends to s: `s += x` or `s = s + x`,
+// possibly with further `+ y` terms.
+func concatenates(info *types.Info, as *ast.AssignStmt, s *types.Var) bool {
+	switch as.Tok {
+	case token.ADD_ASSIGN:
+		return true
+	case token.ASSIGN:
+		e := ast.Unparen(as.Rhs[0])
+		for {
+			bin, ok := e.(*ast.BinaryExpr)
+			if !ok || bin.Op != token.ADD {
+				return false
+			}
+			if exprVar(info, bin.X) == s {
+				return true
+			}
+			e = ast.Unparen(bin.X)
+		}
+	}
+	return false
+}
+
+// concatLoop returns the innermost loop around the top of stack that
+// repeats an assignment to s, declared outside it, with no constant
+// iteration count and no Builder in its body, or nil.
+func concatLoop(info *types.Info, s *types.Var, stack []ast.Node) ast.Stmt {
+	for i := len(stack) - 2; i >= 0; i-- {
+		var body *ast.BlockStmt
+		switch n := stack[i].(type) {
+		case *ast.FuncLit, *ast.FuncDecl:
+			return nil
+		case *ast.ForStmt:
+			if constantTrips(info, n) {
+				continue
+			}
+			body = n.Body
+		case *ast.RangeStmt:
+			if constantRange(info, n) {
+				continue
+			}
+			body = n.Body
+		default:
+			continue
+		}
+		loop := stack[i].(ast.Stmt)
+		if s.Pos() >= loop.Pos() && s.Pos() < loop.End() {
+			// s starts afresh on every iteration of this loop.
+			return nil
+		}
+		if writesBuilder(info, body) {
+			return nil
+		}
+		return loop
+	}
+	return nil
+}
+
+// constantTrips reports whether loop counts up or down to a constant from
+// a constant, like `for i := 0; i < 4; i++`.
+func constantTrips(info *types.Info, loop *ast.ForStmt) bool {
+	bin, ok := ast.Unparen(loop.Cond).(*ast.BinaryExpr)
+	if !ok || loop.Init == nil {
+		return false
+	}
+	as, ok := loop.Init.(*ast.AssignStmt)
+	if !ok || len(as.Rhs) != 1 {
+		return false
+	}
+	isConst := func(e ast.Expr) bool {
+		tv, ok := info.Types[e]
+		return ok && tv.Value != nil
+	}
+	return isConst(as.Rhs[0]) && (isConst(bin.X) || isConst(bin.Y))
+}
+
+// constantRange reports whether loop ranges over an array, a pointer to
+// one, or a constant integer.
+func constantRange(info *types.Info, loop *ast.RangeStmt) bool {
+	tv, ok := info.Types[loop.X]
+	if !ok {
+		return false
+	}
+	if tv.Value != nil {
+		return true
+	}
+	t := tv.Type.Underlying()
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem().Underlying()
+	}
+	_, ok = t.(*types.Array)
+	return ok
+}
+
+// writesBuilder reports whether body calls a method of a strings.Builder
+// or bytes.Buffer.
+func writesBuilder(info *types.Info, body *ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		if found {
+			return false
+		}
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if s := info.Selections[sel]; s != nil && s.Kind() == types.MethodVal {
+				switch strings.TrimPrefix(s.Recv().String(), "*") {
+				case "strings.Builder", "bytes.Buffer":
+					found = true
+				}
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// loopHeader returns the source of loop's header, without the body.
+func loopHeader(ctx *Context, loop ast.Stmt) string {
+	var b strings.Builder
+	b.WriteString("for")
+	switch loop := loop.(type) {
+	case *ast.ForStmt:
+		if loop.Init == nil && loop.Post == nil {
+			if loop.Cond != nil {
+				b.WriteString(" " + ctx.sourceText(loop.Cond))
+			}
+			break
+		}
+		var parts []string
+		for _, n := range []ast.Node{loop.Init, loop.Cond, loop.Post} {
+			if n != nil {
+				parts = append(parts, ctx.sourceText(n))
+			} else {
+				parts = append(parts, "")
+			}
+		}
+		b.WriteString(" " + strings.Join(parts, "; "))
+	case *ast.RangeStmt:
+		if loop.Key != nil {
+			b.WriteString(" " + ctx.sourceText(loop.Key))
+			if loop.Value != nil {
+				b.WriteString(", " + ctx.sourceText(loop.Value))
+			}
+			b.WriteString(" " + loop.Tok.String())
+		}
+		b.WriteString(" range " + ctx.sourceText(loop.X))
+	}
+	return b.String()
+}
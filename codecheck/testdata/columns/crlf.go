@@ -0,0 +1,10 @@
+package columns
+
+import "os"
+
+func clean() {
+	if true {
+		_ = os.Remove("x")
+	}
+	x :=	"é"; _ = os.Remove(x)
+}
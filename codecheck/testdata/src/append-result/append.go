@@ -0,0 +1,26 @@
+package fixtures
+
+type buffer struct {
+	wbuf []byte
+}
+
+func explicitDiscard(b *buffer) {
+	_ = append(b.wbuf[:0], 'x')
+}
+
+func assignedBack(names []string, name string) []string {
+	names = append(names, name)
+	return names
+}
+
+func lost(names []string, name string) int {
+	var more []string
+	println(len(more))
+	more = append(names, name) // want "append-result: result of append to `names` is stored in `more`, which is never used, while `names` is used again at line 20"
+	return len(names)
+}
+
+func copied(names []string, name string) []string {
+	more := append(names, name)
+	return more
+}
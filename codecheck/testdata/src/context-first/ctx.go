@@ -0,0 +1,22 @@
+package fixtures
+
+import (
+	"context"
+	"testing"
+)
+
+func Fetch(url string, ctx context.Context) error { // want "context-first: .* is parameter 2 of `Fetch`; a context.Context should be the first parameter"
+	return ctx.Err()
+}
+
+func Get(ctx context.Context, url string) error {
+	return ctx.Err()
+}
+
+func helper(t *testing.T, ctx context.Context) {
+	t.Helper()
+}
+
+type Store interface {
+	Load(key string, ctx context.Context) error // want "context-first: .* is parameter 2 of `Load`"
+}
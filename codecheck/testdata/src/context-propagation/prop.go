@@ -0,0 +1,30 @@
+package fixtures
+
+import (
+	"context"
+	"net/http"
+)
+
+func fetch(ctx context.Context, url string) error {
+	return ctx.Err()
+}
+
+func detached(ctx context.Context) error {
+	return fetch(context.Background(), "a") // want "context-propagation: `context.Background\\(\\)` is passed to `fetch` although `ctx` is available here"
+}
+
+func todo(ctx context.Context) error {
+	return fetch(context.TODO(), "a") // want "context-propagation: `context.TODO\\(\\)` is passed to `fetch` although `ctx` is available here"
+}
+
+func handler(w http.ResponseWriter, r *http.Request) {
+	_ = fetch(context.Background(), "a") // want "context-propagation: `context.Background\\(\\)` is passed to `fetch` although `r.Context\\(\\)` is available here"
+}
+
+func passed(ctx context.Context) error {
+	return fetch(ctx, "a")
+}
+
+func main2() error {
+	return fetch(context.Background(), "a")
+}
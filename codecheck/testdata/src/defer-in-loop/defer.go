@@ -0,0 +1,37 @@
+package fixtures
+
+import "os"
+
+func closeAll(paths []string) error {
+	for _, p := range paths {
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close() // want "defer-in-loop: `defer f.Close\\(\\)` runs only when `closeAll` returns"
+	}
+	return nil
+}
+
+func fixed(files [3]*os.File) {
+	for _, f := range files {
+		defer f.Close() // want "defer-in-loop: `defer f.Close\\(\\)` runs only when `fixed` returns"
+	}
+}
+
+func perIteration(paths []string) error {
+	for _, p := range paths {
+		err := func() error {
+			f, err := os.Open(p)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return nil
+		}()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package divzero
+
+func constant(n int) int {
+	none := 0
+	return n / none // want `div-by-zero: division by zero: divisor .none. is always zero`
+}
+
+func average(xs []int) int {
+	sum := 0
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / len(xs) // want `possible division by zero: divisor .len\(xs\). is zero when len\(xs\) is 0`
+}
+
+func middle(s string) int {
+	return 100 % (len(s) - 5) // want `divisor .\(len\(s\) - 5\). is zero when len\(s\) is 5`
+}
+
+func compound(n int, xs []int) int {
+	n /= len(xs) // want `divisor .len\(xs\). is zero`
+	return n
+}
+
+func guardedByIf(xs []int) int {
+	if len(xs) == 0 {
+		return 0
+	}
+	return 100 / len(xs)
+}
+
+func guardedByAnd(xs []int) bool {
+	return len(xs) > 0 && 100/len(xs) > 10
+}
+
+func guardedByOr(xs []int) bool {
+	return len(xs) == 0 || 100/len(xs) > 10
+}
+
+func guardedByEmptyString(s string) int {
+	if s == "" {
+		return 0
+	}
+	return 100 / len(s)
+}
+
+func floats(x float64, xs []float64) float64 {
+	return x / float64(len(xs))
+}
+
+func caller() int {
+	return average(nil)
+}
+
+func rangeGuard(xs []int) int {
+	n := 0
+	for _, x := range xs {
+		n += x / len(xs)
+	}
+	return n
+}
+
+func rangeOffByOne(xs []int) int {
+	n := 0
+	for _, x := range xs {
+		n += x / (len(xs) - 1) // want `possible division by zero: divisor .\(len\(xs\) - 1\). is zero when len\(xs\) is 1`
+	}
+	return n
+}
@@ -0,0 +1,39 @@
+package fixtures
+
+import "errors"
+
+func flush() error { // want "error-always-ignored: every call of `flush` in the package ignores the error it returns \\(2 calls"
+	return errors.New("flush")
+}
+
+func load() (int, error) {
+	return 0, nil
+}
+
+func once() error {
+	return nil
+}
+
+func handled() error {
+	return nil
+}
+
+func callers() int {
+	_ = flush()
+	defer flush()
+	n, _ := load()
+	if err := load2(); err != nil {
+		return 0
+	}
+	_ = once()
+	_ = handled()
+	if err := handled(); err != nil {
+		return 1
+	}
+	return n
+}
+
+func load2() error {
+	_, err := load()
+	return err
+}
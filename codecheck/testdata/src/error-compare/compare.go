@@ -0,0 +1,46 @@
+package fixtures
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+var errNotFound = errors.New("not found")
+
+func fresh(err error) bool {
+	return err == errors.New("not found") // want "error-compare: .* is always false: `errors.New\\(\"not found\"\\)` makes a new error"
+}
+
+func local(err error) bool {
+	other := errors.New("other")
+	return err == other // want "error-compare: .* compares errors by identity against `other`, which is not a package-level sentinel error"
+}
+
+func sentinel(err error) bool {
+	return err == errNotFound // want "error-compare: .* misses `errNotFound` if the error has been wrapped"
+}
+
+func pathError(path string) bool {
+	_, err := os.Stat(path)
+	return err == os.ErrNotExist // want "error-compare: .* misses `os.ErrNotExist` wrapped in a \\*PathError"
+}
+
+func read(r io.Reader, buf []byte) bool {
+	_, err := r.Read(buf)
+	return err == io.EOF
+}
+
+func isNil(err error) bool {
+	return err != nil
+}
+
+type myError struct{}
+
+func (myError) Error() string { return "mine" }
+
+func (myError) Is(target error) bool { return target == errNotFound }
+
+func wrapped(err error) bool {
+	return errors.Is(err, errNotFound)
+}
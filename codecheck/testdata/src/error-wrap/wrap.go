@@ -0,0 +1,43 @@
+package fixtures
+
+import (
+	"errors"
+	"os"
+)
+
+func copyFile(dst, src string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err // want "error-wrap: `return err` passes on the error from `os.ReadFile` without context; `copyFile` has 2 calls that can fail"
+	}
+	err = os.WriteFile(dst, data, 0o644)
+	if err != nil {
+		return err // want "error-wrap: `return err` passes on the error from `os.WriteFile` without context"
+	}
+	return nil
+}
+
+func single(path string) error {
+	_, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+var errEmpty = errors.New("empty")
+
+func sentinel(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err // want "error-wrap: `return err` passes on the error from `os.ReadFile`"
+	}
+	if len(data) == 0 {
+		err = errEmpty
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return errors.New("remove failed")
+	}
+	return nil
+}
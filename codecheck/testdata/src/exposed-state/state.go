@@ -0,0 +1,28 @@
+package fixtures
+
+type Set struct {
+	items []string
+	index map[string]int
+	buf   []byte
+	Names []string
+}
+
+func (s *Set) Items() []string {
+	return s.items // want "exposed-state: `Items` returns .* field `items` itself, not a copy"
+}
+
+func (s *Set) Index() map[string]int {
+	return s.index // want "exposed-state: `Index` returns .* field `index` itself, not a copy"
+}
+
+func (s *Set) Copy() []string {
+	return append([]string(nil), s.items...)
+}
+
+func (s *Set) PublicNames() []string {
+	return s.Names
+}
+
+func (s *Set) items2() []string {
+	return s.items
+}
@@ -0,0 +1,11 @@
+// Package generated has code generated by a tool and code that only says
+// it is, too late for it to count.
+package generated
+
+// Code generated by hand. DO NOT EDIT.
+
+import "os"
+
+func lateRemove() {
+	_ = os.Remove("x") // want "ignored-error: error returned by os.Remove is discarded"
+}
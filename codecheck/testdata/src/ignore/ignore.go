@@ -0,0 +1,32 @@
+package ignore
+
+import "os"
+
+func suppressed(path string) {
+	//codecheck:ignore ignored-error the file may not exist
+	_ = os.Remove(path)
+	_ = os.Remove(path + ".bak") //codecheck:ignore ignored-error
+	//codecheck:ignore
+	_ = os.Remove(path + ".tmp")
+}
+
+func notSuppressed(path string) {
+	//codecheck:ignore ignored-error only the next statement
+	_ = os.Remove(path)
+	_ = os.Remove(path + ".bak") // want `ignored-error: error returned by os.Remove`
+}
+
+func stale(path string) error {
+	//codecheck:ignore ignored-error // want `unused-ignore: ignore directive .//codecheck:ignore ignored-error // want .*. does not suppress any finding`
+	return os.Remove(path)
+}
+
+func unknownRule(path string) error {
+	//codecheck:ignore no-such-rule // want `unused-ignore: ignore directive names unknown rule "no-such-rule"`
+	return os.Remove(path)
+}
+
+func disabledRule(path string) error {
+	//codecheck:ignore sql-injection
+	return os.Remove(path)
+}
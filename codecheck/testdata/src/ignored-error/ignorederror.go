@@ -0,0 +1,27 @@
+package ignorederror
+
+import (
+	"os"
+	"strconv"
+)
+
+func remove(path string) {
+	_ = os.Remove(path) // want `ignored-error: error returned by os.Remove is discarded in .os.Remove\(path\).`
+}
+
+func parse(s string) int {
+	n, _ := strconv.Atoi(s) // want `error returned by strconv.Atoi is discarded`
+	return n
+}
+
+func handled(path string) error {
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	return nil
+}
+
+func notAnError(s string) string {
+	_, rest := s[:1], s[1:]
+	return rest
+}
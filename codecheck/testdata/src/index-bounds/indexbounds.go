@@ -0,0 +1,180 @@
+package indexbounds
+
+func first(xs []int) int {
+	return xs[0] // want `index-bounds: .xs\[0\]. panics when len\(xs\) is 0`
+}
+
+func tail(s string) string {
+	return s[1:] // want `.s\[1:\]. panics when len\(s\) is 0`
+}
+
+func checked(xs []int) int {
+	if len(xs) == 0 {
+		return 0
+	}
+	return xs[0]
+}
+
+func enclosingIf(xs []int) int {
+	if len(xs) > 2 {
+		return xs[2]
+	}
+	return 0
+}
+
+func tooShortCheck(xs []int) int {
+	if len(xs) > 0 {
+		return xs[1] // want `.xs\[1\]. panics when len\(xs\) is 1`
+	}
+	return 0
+}
+
+func andGuard(s string) bool {
+	return len(s) == 1 && s[0] == 'x'
+}
+
+func orGuard(s string) bool {
+	return len(s) == 0 || s[0] == '#'
+}
+
+func emptyStringReturn(s string) byte {
+	if s == "" {
+		return 0
+	}
+	return s[0]
+}
+
+func emptyStringAnd(s string) bool {
+	return s != "" && s[0] == '/'
+}
+
+func emptyStringWrongWay(s string) bool {
+	return s == "" && s[0] == '/' // want `.s\[0\]. panics when len\(s\) is 0`
+}
+
+func caller() int {
+	return first(nil)
+}
+
+func loopOverOther(xs, ys []int) int {
+	n := 0
+	for i := range xs {
+		n += ys[i] // want `.ys\[i\]. uses the index of a range over .xs.`
+	}
+	return n
+}
+
+func loopBoundInclusive(xs []int) int {
+	n := 0
+	for i := 0; i <= len(xs); i++ {
+		n += xs[i] // want `index-bounds: .xs\[i\].`
+	}
+	return n
+}
+
+func appended(xs []int) int {
+	xs = append(xs, 1)
+	return xs[0]
+}
+
+func rangeGuard(xs []int) int {
+	for range xs {
+		return xs[0]
+	}
+	return 0
+}
+
+func rangeValueGuard(xs []string) int {
+	n := 0
+	for _, x := range xs {
+		n += len(x) + len(xs[0])
+	}
+	return n
+}
+
+func rangeNotFirst(xs []int) int {
+	for range xs {
+		return xs[1] // want `.xs\[1\]. panics when len\(xs\) is 1`
+	}
+	return 0
+}
+
+func rangeReassigned(xs []int) int {
+	n := 0
+	for range xs {
+		n += xs[0] // want `.xs\[0\]. panics when len\(xs\) is 0`
+		xs = nil
+	}
+	return n
+}
+
+func switchGuard(xs []int) int {
+	switch len(xs) {
+	case 0:
+		return 0
+	}
+	return xs[0]
+}
+
+func switchCase(xs []int) int {
+	switch len(xs) {
+	case 0:
+		return 0
+	case 1, 2:
+		return xs[0]
+	default:
+		return xs[2]
+	}
+}
+
+func switchCaseTooShort(xs []int) int {
+	switch len(xs) {
+	case 0, 1:
+		return xs[0] // want `.xs\[0\]. panics when len\(xs\) is 0`
+	}
+	return 0
+}
+
+func switchBreaks(xs []int) int {
+	switch len(xs) {
+	case 0:
+		break
+	}
+	return xs[0] // want `.xs\[0\]. panics when len\(xs\) is 0`
+}
+
+func taglessSwitch(xs []int) int {
+	switch {
+	case len(xs) == 0:
+		return 0
+	}
+	return xs[0]
+}
+
+func guardAssigned(xs []int) int {
+	if len(xs) > 0 {
+		xs = nil
+		return xs[0] // want `.xs\[0\]. panics when len\(xs\) is 0`
+	}
+	return 0
+}
+
+func guardAssignedAfter(xs []int) int {
+	if len(xs) == 0 {
+		return 0
+	}
+	n := xs[0]
+	xs = xs[:0]
+	return n + xs[0] // want `.xs\[0\]. panics when len\(xs\) is 0`
+}
+
+func guardAssignedInLoop(xs []int) int {
+	n := 0
+	if len(xs) > 0 {
+		for i := 0; i < 3; i++ {
+			n += xs[0] // want `.xs\[0\]. panics when len\(xs\) is 0`
+			xs = nil
+		}
+	}
+	return n
+}
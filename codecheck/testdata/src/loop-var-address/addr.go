@@ -0,0 +1,37 @@
+package fixtures
+
+type item struct{ n int }
+
+func pointers(xs []item) []*item {
+	var ptrs []*item
+	for _, x := range xs {
+		ptrs = append(ptrs, &x) // want "loop-var-address: `&x` is .*, but before Go 1.22 loop variable `x` is one variable shared by every iteration"
+	}
+	return ptrs
+}
+
+func byIndex(xs []item) []*item {
+	var ptrs []*item
+	for i := range xs {
+		ptrs = append(ptrs, &xs[i])
+	}
+	return ptrs
+}
+
+func find(xs []item, n int) *item {
+	for _, x := range xs {
+		if x.n == n {
+			return &x // want "loop-var-address: `&x` returns the address of loop variable `x`, a copy of the element of `xs`"
+		}
+	}
+	return nil
+}
+
+func local(xs []item) int {
+	sum := 0
+	for _, x := range xs {
+		p := &x
+		sum += p.n
+	}
+	return sum
+}
@@ -0,0 +1,31 @@
+package fixtures
+
+import "sync"
+
+func spawn(items []string, use func(string)) {
+	var wg sync.WaitGroup
+	for _, item := range items {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			use(item) // want "loop-var-capture: the .* reads loop variable `item`, which before Go 1.22 is one variable shared by every iteration"
+		}()
+	}
+	wg.Wait()
+}
+
+func copied(items []string, use func(string)) {
+	for _, item := range items {
+		item := item
+		go func() { use(item) }()
+	}
+	for _, item := range items {
+		go func(item string) { use(item) }(item)
+	}
+}
+
+func deferred(n int, use func(int)) {
+	for i := 0; i < n; i++ {
+		defer func() { use(i) }() // want "loop-var-capture: the .* reads loop variable `i`"
+	}
+}
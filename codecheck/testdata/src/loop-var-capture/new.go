@@ -0,0 +1,9 @@
+//go:build go1.22
+
+package fixtures
+
+func spawnNew(items []string, use func(string)) {
+	for _, item := range items {
+		go func() { use(item) }()
+	}
+}
@@ -0,0 +1,57 @@
+package maybeuninit
+
+import "io"
+
+func send(ok bool) {
+	var ch chan int
+	if ok {
+		ch = make(chan int, 1)
+	}
+	ch <- 1 // want `maybe-uninitialized: sending on nil channel .ch. blocks forever`
+}
+
+func closeIt() {
+	var ch chan int
+	close(ch) // want `closing nil channel .ch. panics`
+}
+
+func call(debug bool) {
+	var log func(string)
+	if debug {
+		log = func(s string) { println(s) }
+	}
+	log("start") // want `calling nil function .log. panics`
+}
+
+func method(r io.Reader, wrap bool) {
+	var c io.Closer
+	if wrap {
+		c = io.NopCloser(r)
+	}
+	c.Close() // want `calling method .Close. on nil interface .c. panics`
+}
+
+func assigned() {
+	var ch chan int
+	ch = make(chan int, 1)
+	ch <- 1
+	close(ch)
+}
+
+func inSelect() {
+	var ch chan int
+	select {
+	case ch <- 1:
+	default:
+	}
+}
+
+func guarded(debug bool) {
+	var log func(string)
+	if debug {
+		log = func(s string) { println(s) }
+	}
+	if log != nil {
+		log("start")
+	}
+}
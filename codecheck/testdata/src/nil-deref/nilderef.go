@@ -0,0 +1,116 @@
+package nilderef
+
+type config struct {
+	Name string
+	Next *config
+}
+
+func load(path string) *config { return &config{Name: path} }
+
+func assignedOnOneBranch(path string) string {
+	var cfg *config
+	if path != "" {
+		cfg = load(path)
+	}
+	return cfg.Name // want `nil-deref: nil dereference of .cfg.: it is still nil when .path != "". is false`
+}
+
+func neverAssigned() string {
+	var cfg *config
+	return cfg.Name // want `nil dereference of .cfg.: it is nil on every path`
+}
+
+func star() config {
+	var cfg *config
+	return *cfg // want `nil dereference of .cfg.`
+}
+
+func assignedOnEveryBranch(path string) string {
+	var cfg *config
+	if path != "" {
+		cfg = load(path)
+	} else {
+		cfg = load("default")
+	}
+	return cfg.Name
+}
+
+func checkedBeforeUse(path string) string {
+	var cfg *config
+	if path != "" {
+		cfg = load(path)
+	}
+	if cfg == nil {
+		return ""
+	}
+	return cfg.Name
+}
+
+func shortCircuitAnd(path string) bool {
+	var cfg *config
+	if path != "" {
+		cfg = load(path)
+	}
+	if cfg != nil && cfg.Name != "" {
+		return true
+	}
+	return false
+}
+
+func shortCircuitOr(path string) bool {
+	var cfg *config
+	if path != "" {
+		cfg = load(path)
+	}
+	if cfg == nil || cfg.Name == "" {
+		return false
+	}
+	return true
+}
+
+func shortCircuitLoop(path string) int {
+	var cfg *config
+	if path != "" {
+		cfg = load(path)
+	}
+	n := 0
+	for cfg != nil && cfg.Next != nil {
+		cfg = cfg.Next
+		n++
+	}
+	return n
+}
+
+func shortCircuitCase(path string) string {
+	var cfg *config
+	if path != "" {
+		cfg = load(path)
+	}
+	switch {
+	case cfg != nil && cfg.Name != "":
+		return cfg.Name
+	}
+	return ""
+}
+
+func rightOperandOnlyWhenNil(path string) bool {
+	var cfg *config
+	if path != "" {
+		cfg = load(path)
+	}
+	if cfg == nil && cfg.Name != "" { // want `nil dereference of .cfg.`
+		return true
+	}
+	return false
+}
+
+func reportedOnce(path string) string {
+	var cfg *config
+	if path != "" {
+		cfg = load(path)
+	}
+	if path == "" || cfg.Name == "" { // want `nil dereference of .cfg.`
+		return cfg.Name
+	}
+	return ""
+}
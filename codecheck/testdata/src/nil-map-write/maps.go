@@ -0,0 +1,63 @@
+package fixtures
+
+func local(keys []string) map[string]int {
+	var counts map[string]int
+	for _, k := range keys {
+		counts[k]++ // want "nil-map-write: writing to nil map `counts` panics"
+	}
+	return counts
+}
+
+func made(keys []string) map[string]int {
+	counts := make(map[string]int)
+	for _, k := range keys {
+		counts[k]++
+	}
+	return counts
+}
+
+func reassigned(keys []string) map[string]bool {
+	var seen map[string]bool
+	if seen == nil {
+		seen = map[string]bool{}
+	}
+	for _, k := range keys {
+		seen[k] = true
+	}
+	return seen
+}
+
+func Set(m map[string]int, k string) {
+	m[k] = 1 // want "nil-map-write: writing to map parameter `m` panics if the caller passes nil"
+}
+
+func SetChecked(m map[string]int, k string) {
+	if m == nil {
+		return
+	}
+	m[k] = 1
+}
+
+type registry struct {
+	names map[string]bool
+	ids   map[string]int
+}
+
+func newRegistry() *registry {
+	return &registry{ids: map[string]int{}}
+}
+
+func (r *registry) add(name string) {
+	r.names[name] = true // want "nil-map-write: writing to map field `r.names` panics: nothing in package fixtures assigns the field"
+	r.ids[name] = len(r.ids)
+}
+
+func reads(keys []string) int {
+	var m map[string]int
+	n := 0
+	for _, k := range keys {
+		n += m[k]
+		delete(m, k)
+	}
+	return n
+}
@@ -0,0 +1,56 @@
+package fixtures
+
+func noPost(s []int) int {
+	sum := 0
+	for i := 0; i < len(s); { // want "non-terminating-loop: loop condition `i < len\\(s\\)` never changes"
+		sum += s[0]
+	}
+	return sum
+}
+
+func neverCatchesUp(s []int) []int {
+	for i := 0; i < len(s); i-- { // want "non-terminating-loop: loop condition `i < len\\(s\\)` never becomes false"
+		s = append(s, i)
+	}
+	return s
+}
+
+func counts(s []int) int {
+	sum := 0
+	for i := 0; i < len(s); i++ {
+		sum += s[i]
+	}
+	return sum
+}
+
+func breaks(s []int) int {
+	i := 0
+	for i < len(s) {
+		if s[i] == 0 {
+			break
+		}
+	}
+	return i
+}
+
+func pads(s string, n int) string {
+	for len(s) < n {
+		s += "x"
+	}
+	return s
+}
+
+func grows(s []int, n int) []int {
+	for len(s) < n {
+		s = append(s, 0)
+	}
+	return s
+}
+
+func external(done func() bool) int {
+	n := 0
+	for !done() {
+		n++
+	}
+	return n
+}
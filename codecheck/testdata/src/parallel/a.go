@@ -0,0 +1,23 @@
+// Package parallel gives the parallel analysis test findings of several
+// rules spread over several files.
+package parallel
+
+import (
+	"database/sql"
+	"os"
+)
+
+type user struct{ Name string }
+
+func name() string {
+	var u *user
+	return u.Name
+}
+
+func lookup(db *sql.DB, id string) {
+	_, _ = db.Query("SELECT * FROM users WHERE id = " + id)
+}
+
+func remove(path string) {
+	_ = os.Remove(path)
+}
@@ -0,0 +1,15 @@
+package parallel
+
+import "fmt"
+
+func ratio(xs []int) int {
+	return 100 / len(xs)
+}
+
+func first(xs []int) int {
+	return xs[0]
+}
+
+func report(n int) {
+	fmt.Printf("%s items\n", n)
+}
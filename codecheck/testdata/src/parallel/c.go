@@ -0,0 +1,21 @@
+package parallel
+
+import "os"
+
+func size(path string) int64 {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return 0
+	}
+	return fi.Size()
+}
+
+func grow(xs []int) {
+	for _, x := range xs {
+		xs = append(xs, x)
+	}
+}
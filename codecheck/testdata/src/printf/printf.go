@@ -0,0 +1,31 @@
+package printf
+
+import (
+	"errors"
+	"fmt"
+	"log"
+)
+
+func verbs(n int, s string, err error) {
+	fmt.Printf("%s items\n", n)        // want `printf: .%s. in the format of fmt.Printf expects a string or \[\]byte, but argument 1 .n. is int`
+	fmt.Printf("%d of %d\n", n)        // want `.%d. in the format of fmt.Printf reads argument 2, but the call has 1 argument`
+	fmt.Printf("%d\n", n, s)           // want `fmt.Printf has 2 arguments but its format uses 1 argument; .s. is not formatted`
+	log.Printf("%z\n", n)              // want `format of log.Printf has unknown verb .%z.`
+	_ = fmt.Sprintf("failed: %w", err) // want `fmt.Sprintf does not support the error-wrapping verb .%w.`
+	fmt.Printf("%d %s %v %q\n", n, s, err, s)
+	_ = fmt.Errorf("failed: %w", err)
+	fmt.Println("100%")
+}
+
+//codecheck:printf
+func logf(format string, args ...any) {
+	fmt.Printf(format, args...)
+}
+
+func marked(n int) {
+	logf("%s\n", n) // want `.%s. in the format of fixtures/printf.logf expects`
+}
+
+func wrapped() error {
+	return errors.New("x")
+}
@@ -0,0 +1,30 @@
+package fixtures
+
+func past() []int {
+	s := make([]int, 3)
+	return s[:5] // want "reslice-bounds: `s\\[:5\\]` panics: the bound 5 is past the capacity of `s`"
+}
+
+func appended(x int) []int {
+	s := []int{1, 2, 3}
+	s = append(s[:4], x) // want "reslice-bounds: `s\\[:4\\]` panics: the bound 4 is past the capacity of `s`"
+	return s
+}
+
+func upToCap() []int {
+	s := make([]int, 0, 8)
+	return s[:8]
+}
+
+func str() string {
+	name := "abc"
+	return name[:4] // want "reslice-bounds: `name\\[:4\\]` panics: the bound 4 is past the length of `name`"
+}
+
+func branches(big bool) []int {
+	s := make([]int, 2)
+	if big {
+		s = make([]int, 10)
+	}
+	return s[:6]
+}
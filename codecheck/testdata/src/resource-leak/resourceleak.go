@@ -0,0 +1,60 @@
+package resourceleak
+
+import (
+	"database/sql"
+	"os"
+)
+
+func size(path string) (int64, error) {
+	f, err := os.Open(path) // want `resource-leak: .f. \(\*os.File from os.Open\) is never closed`
+	if err != nil {
+		return 0, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
+
+func discarded(path string) error {
+	_, err := os.Open(path) // want `resource-leak: \*os.File from os.Open is discarded, so it is never closed`
+	return err
+}
+
+func closed(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Stat()
+	return err
+}
+
+func returned(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	return db, nil // want `.db. \(\*sql.DB from database/sql.Open\) is returned without being closed`
+}
+
+// openDB opens the database; callers must Close it.
+func openDB(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+type holder struct{ f *os.File }
+
+func stored(path string) (*holder, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &holder{f: f}, nil
+}
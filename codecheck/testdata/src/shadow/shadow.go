@@ -0,0 +1,41 @@
+package shadow
+
+import "os"
+
+func lostError(path string, force bool) error {
+	var err error
+	if force {
+		_, err := os.Stat(path) // want `shadow: .err. shadows the error variable declared at line 6, which is used at line 11 after this scope`
+		_ = err
+	}
+	return err
+}
+
+func namedResult(path string) (n int64, err error) {
+	if path != "" {
+		fi, err := os.Stat(path) // want `.err. shadows the `
+		if err == nil {
+			n = fi.Size()
+		}
+	}
+	return n, err
+}
+
+func unusedAfter(path string) error {
+	err := os.Remove(path)
+	if err != nil {
+		if err := os.Remove(path + ".bak"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func otherType(xs []int) int {
+	n := len(xs)
+	for _, x := range xs {
+		n := float64(x)
+		_ = n
+	}
+	return n
+}
@@ -0,0 +1,81 @@
+package slicemutation
+
+func rangeAppend(queue []int) []int {
+	for _, item := range queue {
+		queue = append(queue, item*2) // want `slice-mutation: .queue. is appended to while ranging over it`
+	}
+	return queue
+}
+
+func rangeReassign(xs []int) {
+	for range xs {
+		xs = nil // want `.xs. is reassigned while ranging over it`
+	}
+}
+
+func indexAppend(xs []int) []int {
+	for i := 0; i < len(xs); i++ {
+		if xs[i] > 0 {
+			xs = append(xs, -xs[i]) // want `.xs. is appended to inside a loop bounded by len\(xs\)`
+		}
+	}
+	return xs
+}
+
+func indexReassign(xs []int) []int {
+	for i := 0; i < len(xs); i++ {
+		if xs[i] == 0 {
+			xs = append(xs[:i], xs[i+1:]...) // want `.xs. is reassigned inside a loop bounded by len\(xs\)`
+		}
+	}
+	return xs
+}
+
+func worklist(queue []int) int {
+	n := 0
+	for len(queue) > 0 {
+		item := queue[0]
+		queue = append(queue[1:], item/2)
+		n++
+	}
+	return n
+}
+
+func popFront(xs []int, i int) []int {
+	for i < len(xs) {
+		if xs[i] < 0 {
+			return xs
+		}
+		xs = xs[1:]
+	}
+	return xs
+}
+
+func notIndexed(xs []int, i int) []int {
+	for ; i < len(xs); i++ {
+		xs = append(xs, i)
+	}
+	return xs
+}
+
+func trimLeft(s string) string {
+	for i := 0; i < len(s); i++ {
+		if s[i] != ' ' {
+			s = s[i:]
+			break
+		}
+	}
+	for _, c := range s {
+		if c == '#' {
+			s = ""
+		}
+	}
+	return s
+}
+
+func differentSlice(xs, ys []int) []int {
+	for i := 0; i < len(xs); i++ {
+		ys = append(ys, xs[i])
+	}
+	return ys
+}
@@ -0,0 +1,57 @@
+package sqlinjection
+
+import (
+	"database/sql"
+	"strconv"
+)
+
+func byName(db *sql.DB, name string) (*sql.Rows, error) {
+	return db.Query("SELECT * FROM users WHERE name = '" + name + "'") // want `sql-injection: SQL query`
+}
+
+func byID(db *sql.DB, id int) (*sql.Rows, error) {
+	return db.Query("SELECT * FROM users WHERE id = " + strconv.Itoa(id)) // want `sql-injection: SQL query`
+}
+
+func built(db *sql.DB, table, name string) (*sql.Rows, error) {
+	q := "SELECT * FROM users"
+	q += " WHERE name = '" + name + "'" // want `sql-injection: SQL query .q. is extended with non-constant value`
+	return db.Query(q)
+}
+
+const table = "users"
+
+func constant(db *sql.DB) (*sql.Rows, error) {
+	return db.Query("SELECT * FROM " + table + " WHERE active")
+}
+
+func placeholders(db *sql.DB, name string) (*sql.Rows, error) {
+	return db.Query("SELECT * FROM users WHERE name = ?", name)
+}
+
+func notSQL(name string) string {
+	return "hello, " + name
+}
+
+func english(name string) []string {
+	return []string{
+		"Update available: " + name,
+		"select the best option, " + name,
+		"Where are you, " + name,
+		"Delete " + name + " from the list?",
+		"Insert coin, " + name,
+	}
+}
+
+func split(db *sql.DB, name string) (*sql.Rows, error) {
+	return db.Query("SELECT * " + "FROM users WHERE name = '" + name + "'") // want `sql-injection: SQL query`
+}
+
+func statements(db *sql.DB, name string, id string) {
+	db.Exec("UPDATE users SET name = '" + name + "'")           // want `sql-injection: SQL query`
+	db.Exec("DELETE FROM users WHERE id = " + id)               // want `sql-injection: SQL query`
+	db.Exec("INSERT INTO users (name) VALUES ('" + name + "')") // want `sql-injection: SQL query`
+	db.Exec("MERGE INTO users USING staged ON id = " + id)      // want `sql-injection: SQL query`
+	db.Query("select name\nfrom users where id = " + id)        // want `sql-injection: SQL query`
+	db.Query("WHERE id = " + id)                                // want `sql-injection: SQL query`
+}
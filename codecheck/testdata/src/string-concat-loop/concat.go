@@ -0,0 +1,50 @@
+package fixtures
+
+import "strings"
+
+func joined(names []string) string {
+	s := ""
+	for _, n := range names {
+		s += n + "," // want "string-concat-loop: string `s` is concatenated on every iteration"
+	}
+	return s
+}
+
+func plus(names []string) string {
+	var s string
+	for i := 0; i < len(names); i++ {
+		s = s + names[i] // want "string-concat-loop: string `s` is concatenated on every iteration"
+	}
+	return s
+}
+
+func inner(names []string) int {
+	n := 0
+	for _, name := range names {
+		s := ""
+		s += name
+		n += len(s)
+	}
+	return n
+}
+
+func fixed(parts [4]string) string {
+	s := ""
+	for _, p := range parts {
+		s += p
+	}
+	for i := 0; i < 3; i++ {
+		s += "-"
+	}
+	return s
+}
+
+func builder(names []string) string {
+	var b strings.Builder
+	s := ""
+	for _, n := range names {
+		b.WriteString(n)
+		s += n
+	}
+	return b.String() + s
+}
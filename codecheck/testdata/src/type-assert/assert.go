@@ -0,0 +1,41 @@
+package fixtures
+
+import (
+	"fmt"
+	"io"
+)
+
+func unchecked(v any) string {
+	return v.(string) // want "type-assert: type assertion `v.\\(string\\)` panics if `v` doesn't hold a value of type `string`"
+}
+
+func commaOK(v any) string {
+	s, ok := v.(string)
+	if !ok {
+		return ""
+	}
+	return s
+}
+
+func switched(v any) int {
+	switch v.(type) {
+	case int:
+		return v.(int)
+	}
+	return 0
+}
+
+func checked(v any) string {
+	if _, ok := v.(fmt.Stringer); ok {
+		return v.(fmt.Stringer).String()
+	}
+	return ""
+}
+
+type badReader interface {
+	Read() int
+}
+
+func impossible(r io.Reader) int {
+	return r.(badReader).Read() // want "type-assert: type assertion `r.\\(badReader\\)` always panics"
+}
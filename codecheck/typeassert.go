@@ -0,0 +1,173 @@
+package codecheck
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// TypeAssertDetector reports single-result type assertions `x.(T)`, which
+// panic if x doesn't hold a T, and suggests the comma-ok form instead.
+// Assertions in comma-ok form are left alone, as are those a type switch or
+// an if statement has already checked: `x.(T)` in the `case T:` clause of
+// `switch x.(type)`, or in the body of `if _, ok := x.(T); ok`. Assertions
+// that can never succeed, because T is an interface with a method x's
+// interface type also has but with a different signature, are errors.
+type TypeAssertDetector struct{}
+
+func (TypeAssertDetector) Name() string { return "type-assert" }
+
+func (TypeAssertDetector) Description() string {
+	return "Type assertion without comma-ok that panics if the type doesn't match"
+}
+
+func (TypeAssertDetector) DefaultSeverity() Severity { return SeverityWarning }
+
+func (TypeAssertDetector) Doc() RuleDoc {
+	return RuleDoc{
+		Rationale: "The single-result form `x.(T)` panics when x holds a different type. Values handed across API boundaries, decoded from JSON or stored in a context change type more often than expected; the two-result form lets the code handle the mismatch.",
+		Example:   `id := ctx.Value(userKey).(string)`,
+		Fix: `id, ok := ctx.Value(userKey).(string)
+if !ok {
+	return errors.New("no user in context")
+}`,
+	}
+}
+
+func (d TypeAssertDetector) Check(ctx *Context) []Finding {
+	// commaOk holds the assertions whose second result is taken.
+	commaOk := map[*ast.TypeAssertExpr]bool{}
+	for _, f := range ctx.Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			var lhs int
+			var rhs []ast.Expr
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				lhs, rhs = len(n.Lhs), n.Rhs
+			case *ast.ValueSpec:
+				lhs, rhs = len(n.Names), n.Values
+			}
+			if lhs == 2 && len(rhs) == 1 {
+				if ta, ok := ast.Unparen(rhs[0]).(*ast.TypeAssertExpr); ok {
+					commaOk[ta] = true
+				}
+			}
+			return true
+		})
+	}
+
+	var findings []Finding
+	for _, f := range ctx.Files {
+		inspectStack(f, func(n ast.Node, stack []ast.Node) bool {
+			ta, ok := n.(*ast.TypeAssertExpr)
+			if !ok || ta.Type == nil || commaOk[ta] {
+				return true
+			}
+			t := ctx.Info.TypeOf(ta.Type)
+			if t == nil || assertChecked(ctx.Info, ta, t, stack) {
+				return true
+			}
+			findings = append(findings, d.report(ctx, ta, t, stack))
+			return true
+		})
+	}
+	return findings
+}
+
+// assertChecked reports whether an enclosing type switch case or if
+// statement has already established that ta.X holds a t.
+func assertChecked(info *types.Info, ta *ast.TypeAssertExpr, t types.Type, stack []ast.Node) bool {
+	for i := len(stack) - 2; i >= 0; i-- {
+		switch n := stack[i].(type) {
+		case *ast.FuncLit, *ast.FuncDecl:
+			return false
+		case *ast.CaseClause:
+			if i < 2 {
+				continue
+			}
+			ts, ok := stack[i-2].(*ast.TypeSwitchStmt)
+			if !ok || len(n.List) != 1 || !types.Identical(info.TypeOf(n.List[0]), t) {
+				continue
+			}
+			var guard ast.Expr
+			switch s := ts.Assign.(type) {
+			case *ast.ExprStmt:
+				guard = s.X
+			case *ast.AssignStmt:
+				guard = s.Rhs[0]
+			}
+			if g, ok := ast.Unparen(guard).(*ast.TypeAssertExpr); ok && sameExpr(info, g.X, ta.X) {
+				return true
+			}
+		case *ast.IfStmt:
+			// Only the body is checked, not the else branch.
+			if stack[i+1] != n.Body {
+				continue
+			}
+			as, ok := n.Init.(*ast.AssignStmt)
+			if !ok || len(as.Lhs) != 2 || len(as.Rhs) != 1 {
+				continue
+			}
+			g, ok := ast.Unparen(as.Rhs[0]).(*ast.TypeAssertExpr)
+			okVar := exprVar(info, as.Lhs[1])
+			if ok && okVar != nil && exprVar(info, n.Cond) == okVar && sameExpr(info, g.X, ta.X) &&
+				types.Identical(info.TypeOf(g.Type), t) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (d TypeAssertDetector) report(ctx *Context, ta *ast.TypeAssertExpr, t types.Type, stack []ast.Node) Finding {
+	xs, ts := ctx.sourceText(ta.X), ctx.sourceText(ta.Type)
+	if m, want, have := impossibleAssert(ctx.Info.TypeOf(ta.X), t); m != nil {
+		f := ctx.NewFinding(d.Name(), SeverityError, ta,
+			"type assertion `%s` always panics: `%s` has method `%s` with signature `%s`, but `%s` requires `%s`",
+			ctx.sourceText(ta), typeString(ctx, ctx.Info.TypeOf(ta.X)), m.Name(), typeString(ctx, have), ts, typeString(ctx, want))
+		f.Suggestion = "assert to a type that values of `" + xs + "` can hold"
+		return f
+	}
+	f := ctx.NewFinding(d.Name(), SeverityWarning, ta,
+		"type assertion `%s` panics if `%s` doesn't hold a value of type `%s`", ctx.sourceText(ta), xs, ts)
+	// Most single-result assertions are deliberate: the code knows what
+	// the value holds.
+	f.Confidence = ConfidenceLow
+	v := "v"
+	if as, ok := stack[len(stack)-2].(*ast.AssignStmt); ok && len(as.Lhs) == 1 && len(as.Rhs) == 1 {
+		if id, ok := as.Lhs[0].(*ast.Ident); ok && id.Name != "_" {
+			v = id.Name
+		}
+	}
+	f.Suggestion = "use the two-result form and handle the mismatch: `" + v + ", ok := " + xs + ".(" + ts + ")`"
+	return f
+}
+
+// impossibleAssert returns a method that both x, an interface type, and t,
+// the asserted interface type, have with different signatures, so that no
+// value of x's type can hold a t, along with t's and x's signatures of it.
+func impossibleAssert(x, t types.Type) (*types.Func, types.Type, types.Type) {
+	if x == nil {
+		return nil, nil, nil
+	}
+	xi, ok := x.Underlying().(*types.Interface)
+	if !ok {
+		return nil, nil, nil
+	}
+	if _, ok := t.(*types.TypeParam); ok {
+		return nil, nil, nil
+	}
+	ti, ok := t.Underlying().(*types.Interface)
+	if !ok {
+		return nil, nil, nil
+	}
+	for i := range ti.NumMethods() {
+		tm := ti.Method(i)
+		for j := range xi.NumMethods() {
+			xm := xi.Method(j)
+			if xm.Name() == tm.Name() && !types.Identical(xm.Type(), tm.Type()) {
+				return tm, tm.Type(), xm.Type()
+			}
+		}
+	}
+	return nil, nil, nil
+}